@@ -0,0 +1,36 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/etecs-ru/liquid/v2"
+)
+
+// printAST implements the ast subcommand. It prints the syntax tree of a template, as JSON.
+func printAST(args []string) error {
+	if len(args) != 1 || args[0] == "-h" || args[0] == "--help" {
+		fmt.Fprintf(stdout, "usage: %s ast FILE\n", os.Args[0]) // nolint: gas
+		if len(args) != 1 {
+			exit(1)
+		}
+		return nil
+	}
+	source, err := ioutil.ReadFile(args[0])
+	if err != nil {
+		return err
+	}
+	root, perr := liquid.NewEngine().ParseAST(source, args[0])
+	if perr != nil {
+		return errors.New(liquid.FormatError(perr, args[0], source))
+	}
+	b, err := json.MarshalIndent(root, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(stdout, "%s\n", b)
+	return err
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/etecs-ru/liquid/v2"
+	"github.com/etecs-ru/liquid/v2/codegen"
+	"github.com/etecs-ru/liquid/v2/parser"
+)
+
+// compile implements the compile subcommand. It compiles the templates to a Go file, that defines
+// a variable for each template. The name of the variable is the template's base name, in camel case.
+func compile(args []string) error {
+	flags := flag.NewFlagSet("compile", flag.ContinueOnError)
+	flags.SetOutput(stdout)
+	pkg := flags.String("pkg", "templates", "the `name` of the package of the generated file")
+	mapName := flags.String("map", "", "if set, the `name` of a generated map from each template's path to its variable")
+	output := flags.String("o", "", "write the generated file to `file`, instead of to stdout")
+	flags.Usage = func() {
+		fmt.Fprintf(stdout, "usage: %s compile [flags] FILE...\n", os.Args[0]) // nolint: gas
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return nil
+		}
+		exit(1)
+		return nil
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		exit(1)
+		return nil
+	}
+	var templates []codegen.Template
+	for _, path := range flags.Args() {
+		source, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		templates = append(templates, codegen.Template{Name: codegen.VarName(path), Path: path, Source: string(source)})
+	}
+	out, err := liquid.NewEngine().GenerateGo(codegen.Options{Package: *pkg, Map: *mapName}, templates...)
+	if err != nil {
+		if perr, ok := err.(parser.Error); ok {
+			for _, t := range templates {
+				if t.Path == perr.Path() {
+					return errors.New(liquid.FormatError(perr, t.Path, []byte(t.Source)))
+				}
+			}
+		}
+		return err
+	}
+	if *output == "" {
+		_, err = stdout.Write(out)
+		return err
+	}
+	return ioutil.WriteFile(*output, out, 0644)
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/etecs-ru/liquid/v2"
+)
+
+// format implements the fmt subcommand. It prints the formatted source of each file, or with -w,
+// rewrites the files whose formatting differs. With -l, it lists these files instead.
+func format(args []string) error {
+	flags := flag.NewFlagSet("fmt", flag.ContinueOnError)
+	flags.SetOutput(stdout)
+	write := flags.Bool("w", false, "write the result to the file, instead of to stdout")
+	list := flags.Bool("l", false, "list the files whose formatting differs, instead of printing them")
+	indent := flags.String("indent", "  ", "the `string` that nested tags are indented by")
+	flags.Usage = func() {
+		fmt.Fprintf(stdout, "usage: %s fmt [flags] FILE...\n", os.Args[0]) // nolint: gas
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return nil
+		}
+		exit(1)
+		return nil
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		exit(1)
+		return nil
+	}
+	engine := liquid.NewEngine()
+	for _, path := range flags.Args() {
+		source, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		out, perr := engine.FormatTemplate(source, path, *indent)
+		if perr != nil {
+			return errors.New(liquid.FormatError(perr, path, source))
+		}
+		changed := !bytes.Equal(source, out)
+		if *list && changed {
+			fmt.Fprintln(stdout, path) // nolint: gas
+		}
+		switch {
+		case *write && changed:
+			info, err := os.Stat(path)
+			if err != nil {
+				return err
+			}
+			if err := ioutil.WriteFile(path, out, info.Mode().Perm()); err != nil {
+				return err
+			}
+		case !*write && !*list:
+			if _, err := stdout.Write(out); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
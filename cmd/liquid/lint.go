@@ -0,0 +1,201 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/etecs-ru/liquid/v2"
+	"github.com/etecs-ru/liquid/v2/parser"
+)
+
+// A problem is an error or warning that lint reports.
+type problem struct {
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	Severity string `json:"severity"` // "error" or "warning"
+	Message  string `json:"message"`
+}
+
+// String formats the problem as file:line:col: message, as GitHub and most editors expect.
+func (p problem) String() string {
+	msg := p.Message
+	if p.Severity == "warning" {
+		msg = "warning: " + msg
+	}
+	return fmt.Sprintf("%s:%d:%d: %s", p.File, p.Line, p.Column, msg)
+}
+
+type linter struct {
+	engine  *liquid.Engine
+	globals map[string]bool // nil if undefined variables aren't reported
+}
+
+// lint implements the lint subcommand. It reports the problems in the templates in each
+// directory, and exits with status 1 if there are any errors.
+func lint(args []string) error {
+	flags := flag.NewFlagSet("lint", flag.ContinueOnError)
+	flags.SetOutput(stdout)
+	format := flags.String("format", "text", "output `format`: text or json")
+	globals := flags.String("globals", "", "comma-separated `names` of the variables that the templates may use without defining them; if set, other undefined variables are reported")
+	include := flags.String("include", "", "comma-separated `directories` that are searched for included templates")
+	exts := flags.String("ext", ".liquid,.html", "comma-separated file `extensions` of the templates")
+	flags.Usage = func() {
+		fmt.Fprintf(stdout, "usage: %s lint [flags] DIR...\n", os.Args[0]) // nolint: gas
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return nil
+		}
+		exit(1)
+		return nil
+	}
+	if flags.NArg() == 0 || (*format != "text" && *format != "json") {
+		flags.Usage()
+		exit(1)
+		return nil
+	}
+	l := linter{engine: liquid.NewEngine()}
+	if *include != "" {
+		l.engine.SearchPaths(splitList(*include)...)
+	}
+	if *globals != "" {
+		l.globals = map[string]bool{}
+		for _, name := range splitList(*globals) {
+			l.globals[name] = true
+		}
+	}
+	var problems []problem
+	for _, dir := range flags.Args() {
+		err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() || !hasExt(path, splitList(*exts)) {
+				return err
+			}
+			ps, err := l.lintFile(path)
+			problems = append(problems, ps...)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if err := writeProblems(problems, *format); err != nil {
+		return err
+	}
+	for _, p := range problems {
+		if p.Severity == "error" {
+			exit(1)
+			break
+		}
+	}
+	return nil
+}
+
+// lintFile returns the problems in the template at path.
+func (l linter) lintFile(path string) ([]problem, error) {
+	source, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var problems []problem
+	report := func(severity string, err liquid.SourceError) {
+		msg := err.Error()
+		if m, ok := err.(interface{ Message() string }); ok {
+			msg = m.Message()
+		}
+		problems = append(problems, problem{path, err.LineNumber(), err.ColumnNumber(), severity, msg})
+	}
+	tpl, perr := l.engine.ParseTemplateAll(source, path, 1)
+	if perr != nil {
+		for _, err := range perr.(liquid.ErrorList) {
+			report("error", err)
+		}
+		return problems, nil
+	}
+	a := tpl.Analyze()
+	add := func(severity string, loc parser.SourceLoc, format string, args ...interface{}) {
+		problems = append(problems, problem{path, loc.LineNo, loc.ColNo, severity, fmt.Sprintf(format, args...)})
+	}
+	for _, ref := range a.Filters {
+		if !l.engine.HasFilter(ref.Name) {
+			add("error", ref.Loc, "undefined filter %q", ref.Name)
+		}
+	}
+	for _, ref := range a.Includes {
+		if _, err := l.engine.FindTemplate(ref.Name, path); os.IsNotExist(err) {
+			add("error", ref.Loc, "template %q not found", ref.Name)
+		} else if err != nil {
+			add("error", ref.Loc, "%s", err)
+		}
+	}
+	for _, err := range a.Warnings {
+		report("warning", err)
+	}
+	used := map[string]bool{}
+	for _, ref := range a.Locals {
+		used[ref.Name()] = true
+	}
+	for _, def := range a.Definitions {
+		if (def.Tag == "assign" || def.Tag == "capture") && !used[def.Name] {
+			add("warning", def.Loc, "%s is assigned but never used", def.Name)
+		}
+	}
+	if l.globals != nil {
+		for _, ref := range a.Globals {
+			if !l.globals[ref.Name()] {
+				add("warning", ref.Loc, "undefined variable %q", ref.Name())
+			}
+		}
+	}
+	sort.SliceStable(problems, func(i, j int) bool {
+		a, b := problems[i], problems[j]
+		return a.Line < b.Line || a.Line == b.Line && a.Column < b.Column
+	})
+	return problems, nil
+}
+
+func writeProblems(problems []problem, format string) error {
+	if format == "json" {
+		if problems == nil {
+			problems = []problem{}
+		}
+		b, err := json.MarshalIndent(problems, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(stdout, "%s\n", b)
+		return err
+	}
+	for _, p := range problems {
+		if _, err := fmt.Fprintln(stdout, p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func hasExt(path string, exts []string) bool {
+	for _, ext := range exts {
+		if filepath.Ext(path) == ext {
+			return true
+		}
+	}
+	return false
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
@@ -6,10 +6,15 @@
 //
 // 	echo '{{ "Hello " | append: "World" }}' | liquid
 // 	liquid source.tpl
+// 	liquid lint -format json templates
+// 	liquid fmt -w templates/page.html
+// 	liquid ast templates/page.html
+// 	liquid compile -pkg templates -o templates.go templates/page.html
 package main
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -42,6 +47,14 @@ func run(args []string) error {
 			return err
 		}
 		return render(buf.Bytes(), "")
+	case args[0] == "lint":
+		return lint(args[1:])
+	case args[0] == "fmt":
+		return format(args[1:])
+	case args[0] == "ast":
+		return printAST(args[1:])
+	case args[0] == "compile":
+		return compile(args[1:])
 	case args[0] == "-h" || args[0] == "--help":
 		usage()
 	case strings.HasPrefix(args[0], "-"):
@@ -62,18 +75,18 @@ func run(args []string) error {
 }
 
 func render(b []byte, filename string) (err error) {
-	tpl, err := liquid.NewEngine().ParseTemplate(b)
-	if err != nil {
-		return err
+	tpl, perr := liquid.NewEngine().ParseTemplateLocation(b, filename, 1)
+	if perr != nil {
+		return errors.New(liquid.FormatError(perr, filename, b))
 	}
-	out, err := tpl.Render(map[string]interface{}{})
-	if err != nil {
-		return err
+	out, rerr := tpl.Render(map[string]interface{}{})
+	if rerr != nil {
+		return errors.New(liquid.FormatError(rerr, filename, b))
 	}
 	_, err = stdout.Write(out)
 	return err
 }
 
 func usage() {
-	fmt.Fprintf(stdout, "usage: %s [FILE]\n", os.Args[0]) // nolint: gas
+	fmt.Fprintf(stdout, "usage: %s [FILE]\n       %s lint [flags] DIR...\n       %s fmt [flags] FILE...\n       %s ast FILE\n       %s compile [flags] FILE...\n", os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0]) // nolint: gas
 }
@@ -2,6 +2,10 @@ package main
 
 import (
 	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -25,6 +29,12 @@ func TestMainFunc(t *testing.T) {
 	require.NoError(t, run([]string{"testdata/source.txt"}))
 	require.Contains(t, buf.String(), "file system")
 
+	// error
+	stdin = bytes.NewBufferString("line 1\n{{ x | nope }}")
+	err := run([]string{})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "   2 | {{ x | nope }}\n     | ^")
+
 	// missing file
 	require.Error(t, run([]string{"testdata/missing_file"}))
 
@@ -46,3 +56,142 @@ func TestMainFunc(t *testing.T) {
 	require.NoError(t, run([]string{"file1", "file2"}))
 	require.Equal(t, 1, exitCode)
 }
+
+func TestLint(t *testing.T) {
+	exitCode := 0
+	exit = func(n int) { exitCode = n }
+	buf := new(bytes.Buffer)
+	stdout = buf
+	require.NoError(t, run([]string{"lint", "-globals", "page, product", "testdata/lint"}))
+	require.Equal(t, 1, exitCode)
+	out := buf.String()
+	require.Contains(t, out, "testdata/lint/broken.liquid:1:1: unterminated \"if\" block\n")
+	require.Contains(t, out, "testdata/lint/broken.liquid:2:1: syntax error in \"y |\"\n")
+	require.Contains(t, out, "testdata/lint/page.html:1:1: warning: unused is assigned but never used\n")
+	require.Contains(t, out, "testdata/lint/page.html:1:64: undefined filter \"nope\"\n")
+	require.Contains(t, out, "testdata/lint/page.html:2:26: template \"missing.html\" not found\n")
+	require.Contains(t, out, "testdata/lint/page.html:3:11: warning: else isn't the last clause of if")
+	require.Contains(t, out, "testdata/lint/page.html:3:45: warning: undefined variable \"user\"\n")
+	require.NotContains(t, out, "title")
+	require.NotContains(t, out, "card.html")
+	require.NotContains(t, out, "notes.txt")
+
+	// json; warnings alone don't fail
+	exitCode = 0
+	buf.Reset()
+	require.NoError(t, run([]string{"lint", "-format", "json", "-globals", "a", "testdata/lint/card.html"}))
+	require.Equal(t, 0, exitCode)
+	var problems []problem
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &problems))
+	require.Equal(t, []problem{{"testdata/lint/card.html", 1, 1, "warning", `undefined variable "product"`}}, problems)
+
+	// usage
+	buf.Reset()
+	require.NoError(t, run([]string{"lint"}))
+	require.Equal(t, 1, exitCode)
+	require.Contains(t, buf.String(), "usage:")
+
+	require.Error(t, run([]string{"lint", "testdata/missing_dir"}))
+}
+
+func TestFormat(t *testing.T) {
+	exitCode := 0
+	exit = func(n int) { exitCode = n }
+	buf := new(bytes.Buffer)
+	stdout = buf
+	golden, err := ioutil.ReadFile("testdata/fmt/page.golden")
+	require.NoError(t, err)
+
+	require.NoError(t, run([]string{"fmt", "testdata/fmt/page.html"}))
+	require.Equal(t, string(golden), buf.String())
+
+	buf.Reset()
+	require.NoError(t, run([]string{"fmt", "-l", "testdata/fmt/page.html", "testdata/fmt/page.golden"}))
+	require.Equal(t, "testdata/fmt/page.html\n", buf.String())
+
+	// -w
+	dir, err := ioutil.TempDir("", "liquid")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir) // nolint: errcheck
+	path := filepath.Join(dir, "page.html")
+	source, err := ioutil.ReadFile("testdata/fmt/page.html")
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(path, source, 0644))
+	buf.Reset()
+	require.NoError(t, run([]string{"fmt", "-w", path}))
+	require.Empty(t, buf.String())
+	out, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, string(golden), string(out))
+
+	// errors
+	err = run([]string{"fmt", "testdata/lint/broken.liquid"})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "testdata/lint/broken.liquid")
+	require.Error(t, run([]string{"fmt", "testdata/missing_file"}))
+
+	// usage
+	buf.Reset()
+	require.NoError(t, run([]string{"fmt"}))
+	require.Equal(t, 1, exitCode)
+	require.Contains(t, buf.String(), "usage:")
+}
+
+func TestAST(t *testing.T) {
+	exitCode := 0
+	exit = func(n int) { exitCode = n }
+	buf := new(bytes.Buffer)
+	stdout = buf
+	require.NoError(t, run([]string{"ast", "testdata/fmt/page.golden"}))
+	var root struct {
+		Type     string
+		Children []struct{ Type, Name string }
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &root))
+	require.Equal(t, "seq", root.Type)
+	require.Equal(t, "block", root.Children[1].Type)
+	require.Equal(t, "for", root.Children[1].Name)
+
+	require.Error(t, run([]string{"ast", "testdata/lint/broken.liquid"}))
+
+	buf.Reset()
+	require.NoError(t, run([]string{"ast"}))
+	require.Equal(t, 1, exitCode)
+	require.Contains(t, buf.String(), "usage:")
+}
+
+func TestCompile(t *testing.T) {
+	exitCode := 0
+	exit = func(n int) { exitCode = n }
+	buf := new(bytes.Buffer)
+	stdout = buf
+	require.NoError(t, run([]string{"compile", "-pkg", "pages", "-map", "All", "testdata/fmt/page.html"}))
+	require.Contains(t, buf.String(), "package pages")
+	require.Contains(t, buf.String(), "var Page = &render.GoTemplate{")
+	require.Contains(t, buf.String(), `"testdata/fmt/page.html": Page,`)
+
+	// -o
+	dir, err := ioutil.TempDir("", "liquid")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir) // nolint: errcheck
+	path := filepath.Join(dir, "pages.go")
+	buf.Reset()
+	require.NoError(t, run([]string{"compile", "-o", path, "testdata/fmt/page.html"}))
+	require.Empty(t, buf.String())
+	out, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	require.Contains(t, string(out), "package templates")
+
+	// errors
+	err = run([]string{"compile", "testdata/lint/broken.liquid"})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "testdata/lint/broken.liquid")
+	require.Error(t, run([]string{"compile", "testdata/missing_file"}))
+	require.Error(t, run([]string{"compile", "testdata/fmt/page.html", "testdata/fmt/page.golden"}))
+
+	// usage
+	buf.Reset()
+	require.NoError(t, run([]string{"compile"}))
+	require.Equal(t, 1, exitCode)
+	require.Contains(t, buf.String(), "usage:")
+}
@@ -0,0 +1,654 @@
+// Package codegen compiles templates to Go source code.
+//
+// The code that is generated for a template defines a render.GoTemplate, that
+// Engine.LoadGoTemplate loads. The code renders text, objects, and the assign, capture, case,
+// comment, for, if, raw and unless tags, and break and continue within a for loop, without the
+// interpreter. It evaluates their expressions directly, on Go values, and calls filters through
+// the filter dictionary of the configuration that it's loaded with. It renders the other tags
+// with the interpreter. Its output is the same as the interpreter's.
+//
+// The generated code assumes that the tags that it renders are the standard tags. It should be
+// loaded with the configuration that it was generated with; LoadGoTemplate returns an error if
+// the template doesn't parse to the same syntax tree.
+package codegen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"math"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/etecs-ru/liquid/v2/expressions"
+	"github.com/etecs-ru/liquid/v2/parser"
+	"github.com/etecs-ru/liquid/v2/render"
+)
+
+// A Template is a template to compile.
+type Template struct {
+	Name   string // the name of the generated variable; it must be a Go identifier
+	Path   string // the path of the template, for error reporting and the {% include %} tag
+	Source string
+}
+
+// Options configure the generated file.
+type Options struct {
+	Package string // the name of the package of the generated file
+	Map     string // if non-empty, the name of a generated map from each template's path to its variable
+}
+
+// Generate returns the source of a Go file that defines a *render.GoTemplate variable for each
+// template. The templates are compiled with cfg. Generate returns the first error; a syntax error
+// in a template is a parser.Error.
+func Generate(cfg render.Config, opts Options, templates []Template) ([]byte, error) {
+	f := &file{cfg: cfg, imports: map[string]bool{"render": true}}
+	names := map[string]bool{}
+	for _, t := range templates {
+		switch {
+		case !token.IsIdentifier(t.Name):
+			return nil, fmt.Errorf("%q isn't a Go identifier", t.Name)
+		case names[t.Name]:
+			return nil, fmt.Errorf("two templates are named %s", t.Name)
+		}
+		names[t.Name] = true
+		if err := f.template(t); err != nil {
+			return nil, err
+		}
+	}
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by liquid. DO NOT EDIT.\n\npackage %s\n\nimport (\n", opts.Package)
+	for _, pkg := range []string{"render", "tags", "values"} {
+		if f.imports[pkg] {
+			fmt.Fprintf(&out, "\t%q\n", "github.com/etecs-ru/liquid/v2/"+pkg)
+		}
+	}
+	fmt.Fprintf(&out, ")\n")
+	if opts.Map != "" {
+		fmt.Fprintf(&out, "\n// %s maps the path of each template to its variable.\nvar %s = map[string]*render.GoTemplate{\n", opts.Map, opts.Map)
+		for _, t := range templates {
+			fmt.Fprintf(&out, "%q: %s,\n", t.Path, t.Name)
+		}
+		fmt.Fprintf(&out, "}\n")
+	}
+	out.Write(f.buf.Bytes())
+	return format.Source(out.Bytes())
+}
+
+// VarName returns a variable name for the template at path: its base name, without its
+// extension, in camel case. For example, the name of "layouts/product-card.html" is ProductCard.
+func VarName(path string) string {
+	base := filepath.Base(path)
+	base = strings.TrimSuffix(base, filepath.Ext(base))
+	var name string
+	for _, word := range strings.FieldsFunc(base, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		name += strings.ToUpper(word[:1]) + word[1:]
+	}
+	if name == "" || !unicode.IsLetter([]rune(name)[0]) {
+		name = "T" + name
+	}
+	return name
+}
+
+// A file is the generated code of a file.
+type file struct {
+	cfg     render.Config
+	buf     bytes.Buffer
+	imports map[string]bool
+}
+
+func (f *file) template(t Template) error {
+	loc := parser.SourceLoc{Pathname: t.Path, LineNo: 1}
+	// Compile the template, to report the errors that the interpreter would.
+	if _, err := f.cfg.Compile(t.Source, loc); err != nil {
+		return err
+	}
+	root, err := f.cfg.Parse(t.Source, loc)
+	if err != nil {
+		return err
+	}
+	ast, merr := parser.MarshalASTBinary(root, t.Source)
+	if merr != nil {
+		return merr
+	}
+	if root, err = f.cfg.Rewrite(root, loc); err != nil {
+		return err
+	}
+	g := &generator{
+		file:   f,
+		prefix: strings.ToLower(t.Name[:1]) + t.Name[1:],
+		index:  map[parser.ASTNode]int{},
+		keys:   map[string]int{},
+	}
+	parser.Inspect(root, func(n parser.ASTNode) bool {
+		if n != nil {
+			g.index[n] = len(g.index)
+		}
+		return true
+	})
+	g.node(root)
+	if g.err != nil {
+		return g.err
+	}
+
+	fmt.Fprintf(&f.buf, "\n// %s is the template %s, compiled to Go.\n", t.Name, strconv.Quote(t.Path))
+	fmt.Fprintf(&f.buf, "var %s = &render.GoTemplate{\nPath: %q,\nSource: %s,\n", t.Name, t.Path, goString(t.Source))
+	fmt.Fprintf(&f.buf, "AST: []byte(%q),\nFingerprint: %q,\nChecksum: %#x,\n", ast, f.cfg.Fingerprint(), parser.Checksum(root))
+	if len(g.fragments) > 0 {
+		var ks []string
+		for _, k := range g.fragments {
+			ks = append(ks, strconv.Itoa(k))
+		}
+		fmt.Fprintf(&f.buf, "Fragments: []int{%s},\n", strings.Join(ks, ", "))
+	}
+	fmt.Fprintf(&f.buf, "Render: render%s,\n}\n", strings.ToUpper(t.Name[:1])+t.Name[1:])
+	if len(g.keyList) > 0 {
+		f.imports["values"] = true
+		fmt.Fprintf(&f.buf, "\nvar %sKeys = [...]values.Value{\n", g.prefix)
+		for _, key := range g.keyList {
+			fmt.Fprintf(&f.buf, "values.ValueOf(%s),\n", key)
+		}
+		fmt.Fprintf(&f.buf, "}\n")
+	}
+	for _, v := range g.vars {
+		fmt.Fprintf(&f.buf, "\n%s\n", v)
+	}
+	fmt.Fprintf(&f.buf, "\nfunc render%s(r *render.Runtime) {\n", strings.ToUpper(t.Name[:1])+t.Name[1:])
+	f.buf.Write(g.body.Bytes())
+	fmt.Fprintf(&f.buf, "}\n")
+	return nil
+}
+
+// goString returns a Go string literal; a raw string literal, if s can be one.
+func goString(s string) string {
+	if strings.ContainsAny(s, "`\r") || !utf8Printable(s) {
+		return strconv.Quote(s)
+	}
+	return "`" + s + "`"
+}
+
+func utf8Printable(s string) bool {
+	for _, r := range s {
+		if r == unicode.ReplacementChar || (!unicode.IsPrint(r) && r != '\n' && r != '\t') {
+			return false
+		}
+	}
+	return true
+}
+
+// A generator generates the render function of a template.
+type generator struct {
+	*file
+	prefix    string
+	index     map[parser.ASTNode]int // the number of each node, in the order of parser.Inspect
+	body      bytes.Buffer
+	fragments []int
+	keys      map[string]int // the index of each property name in keyList
+	keyList   []string
+	vars      []string // package-level declarations
+	depth     int      // the number of pushed writers
+	loops     []int    // the depth outside the body of each enclosing loop
+	err       error
+}
+
+func (g *generator) printf(format string, a ...interface{}) {
+	fmt.Fprintf(&g.body, format, a...)
+	g.body.WriteByte('\n')
+}
+
+// nodes generates the code of a sequence of nodes. It returns true if the code ends with a
+// break or continue statement.
+func (g *generator) nodes(nodes []parser.ASTNode) bool {
+	for _, n := range nodes {
+		if g.node(n) {
+			return true
+		}
+	}
+	return false
+}
+
+// node generates the code of a node. It returns true if the code ends with a break or continue
+// statement, after which the rest of the enclosing sequence isn't rendered.
+func (g *generator) node(node parser.ASTNode) bool {
+	k := g.index[node]
+	switch n := node.(type) {
+	case *parser.ASTSeq:
+		return g.nodes(n.Children)
+	case *parser.ASTText:
+		g.printf("r.Text(%d)", k)
+	case *parser.ASTRaw:
+		g.printf("r.Raw(%d)", k)
+	case *parser.ASTComment:
+	case *parser.ASTObject:
+		expr := expressions.Syntax(n.Expr)
+		if expr == nil {
+			g.fragment(k)
+			break
+		}
+		g.printf("r.Tag(%d, %t)", k, n.TrimLeft)
+		if value, ok := g.cfg.Constant(n.Expr); ok && isConstant(value) {
+			// The interpreter writes the text of a constant object, that it computes once, too.
+			if value != nil {
+				g.printf("r.Write(%s)", goString(fmt.Sprint(value)))
+			}
+		} else {
+			g.printf("r.Write(%s)", g.expr(expr))
+		}
+		g.trimRight(n.Token)
+	case *parser.ASTTag:
+		return g.tag(n, k)
+	case *parser.ASTBlock:
+		native, terminated := g.block(n, k)
+		if !native {
+			g.fragment(k)
+		}
+		return terminated
+	default:
+		g.fragment(k)
+	}
+	return false
+}
+
+func (g *generator) fragment(k int) {
+	g.fragments = append(g.fragments, k)
+	g.printf("r.Fragment(%d)", k)
+}
+
+func (g *generator) trimRight(tok parser.Token) {
+	if tok.TrimRight {
+		g.printf("r.TrimRight()")
+	}
+}
+
+func (g *generator) tag(n *parser.ASTTag, k int) bool {
+	switch {
+	case n.Name == "assign":
+		stmt, err := expressions.ParseStatement(expressions.AssignStatementSelector, n.Args)
+		if err != nil || expressions.Syntax(stmt.Assignment.ValueFn) == nil {
+			break
+		}
+		g.printf("r.Tag(%d, %t)", k, n.TrimLeft)
+		g.printf("r.Assign(%q, %s)", stmt.Assignment.Variable, g.expr(expressions.Syntax(stmt.Assignment.ValueFn)))
+		g.trimRight(n.Token)
+		return false
+	case (n.Name == "break" || n.Name == "continue") && len(g.loops) > 0:
+		g.printf("r.Tag(%d, %t)", k, n.TrimLeft)
+		g.printf("r.Discard(%d)", g.depth-g.loops[len(g.loops)-1])
+		g.printf("%s", n.Name)
+		return true
+	}
+	g.fragment(k)
+	return false
+}
+
+// block generates the code of a block, unless the block is rendered by the interpreter. It
+// returns whether it generated the code, and whether the code ends with a break or continue
+// statement.
+func (g *generator) block(n *parser.ASTBlock, k int) (native, terminated bool) {
+	switch n.Name {
+	case "if", "unless":
+		return g.ifBlock(n, k)
+	case "case":
+		return g.caseBlock(n, k)
+	case "for":
+		return g.forBlock(n, k)
+	case "capture":
+		fields := strings.Fields(n.Args)
+		if len(fields) == 0 {
+			return false, false
+		}
+		g.printf("r.At(%d)", k)
+		g.printf("r.BeginCapture()")
+		g.depth++
+		terminated := g.nodes(n.Body)
+		g.depth--
+		if !terminated {
+			g.printf("r.EndCapture(%d, %q)", k, fields[0])
+		}
+		return true, terminated
+	}
+	return false, false
+}
+
+func (g *generator) ifBlock(n *parser.ASTBlock, k int) (native, terminated bool) {
+	cond, err := expressions.Parse(n.Args)
+	if err != nil || expressions.Syntax(cond) == nil {
+		return false, false
+	}
+	test := g.test(expressions.Syntax(cond))
+	if n.Name == "unless" {
+		test = "!(" + test + ")"
+	}
+	branches := []branch{{test, n.Body}}
+	for _, c := range n.Clauses {
+		switch c.Name {
+		case "elsif":
+			cond, err := expressions.Parse(c.Args)
+			if err != nil || expressions.Syntax(cond) == nil {
+				return false, false
+			}
+			branches = append(branches, branch{g.test(expressions.Syntax(cond)), c.Body})
+		case "else":
+			branches = append(branches, branch{"", c.Body})
+		default:
+			return false, false
+		}
+	}
+	g.printf("r.At(%d)", k)
+	return true, g.branches(branches, k)
+}
+
+func (g *generator) caseBlock(n *parser.ASTBlock, k int) (native, terminated bool) {
+	sel, err := expressions.Parse(n.Args)
+	if err != nil || expressions.Syntax(sel) == nil {
+		return false, false
+	}
+	var branches []branch
+	for _, c := range n.Clauses {
+		switch c.Name {
+		case "when":
+			stmt, err := expressions.ParseStatement(expressions.WhenStatementSelector, c.Args)
+			if err != nil {
+				return false, false
+			}
+			var tests []string
+			for _, expr := range stmt.When.Exprs {
+				if expressions.Syntax(expr) == nil {
+					return false, false
+				}
+				g.imports["values"] = true
+				tests = append(tests, fmt.Sprintf("values.Equal(sel, r.Interface(%s))", g.expr(expressions.Syntax(expr))))
+			}
+			branches = append(branches, branch{strings.Join(tests, " || "), c.Body})
+		case "else":
+			branches = append(branches, branch{"", c.Body})
+		default:
+			return false, false
+		}
+	}
+	g.printf("r.At(%d)", k)
+	g.printf("{")
+	g.printf("sel := r.Interface(%s)", g.expr(expressions.Syntax(sel)))
+	if len(branches) == 0 || branches[0].test == "" {
+		g.printf("_ = sel")
+	}
+	terminated = g.branches(branches, k)
+	g.printf("}")
+	return true, terminated
+}
+
+// A branch is a clause of an if, unless or case block. Its test is "" for an else clause.
+type branch struct {
+	test string
+	body []parser.ASTNode
+}
+
+// branches generates the code of the branches of the block k. The branches after an else
+// clause are never rendered. It returns true if there's an else clause, and the code of every
+// branch ends with a break or continue statement.
+func (g *generator) branches(branches []branch, k int) bool {
+	terminated := true
+	for i, b := range branches {
+		switch {
+		case b.test == "" && i == 0:
+			g.printf("{")
+		case b.test == "":
+			g.printf("} else {")
+		case i == 0:
+			g.printf("if %s {", b.test)
+		default:
+			g.printf("} else if %s {", b.test)
+		}
+		if !g.blockBody(b.body, k) {
+			terminated = false
+		}
+		if b.test == "" {
+			if len(branches) > 0 {
+				g.printf("}")
+			}
+			return terminated
+		}
+	}
+	if len(branches) > 0 {
+		g.printf("}")
+	}
+	return false
+}
+
+// blockBody generates the code of the body of the block k.
+func (g *generator) blockBody(nodes []parser.ASTNode, k int) bool {
+	g.printf("r.Push()")
+	g.depth++
+	terminated := g.nodes(nodes)
+	g.depth--
+	if !terminated {
+		g.printf("r.Pop(%d)", k)
+	}
+	return terminated
+}
+
+func (g *generator) forBlock(n *parser.ASTBlock, k int) (native, terminated bool) {
+	stmt, err := expressions.ParseStatement(expressions.LoopStatementSelector, n.Args)
+	if err != nil || expressions.Syntax(stmt.Loop.Expr) == nil || leaks(n.Body) {
+		return false, false
+	}
+	var elseClause *parser.ASTBlock
+	switch {
+	case len(n.Clauses) == 1 && n.Clauses[0].Name == "else":
+		elseClause = n.Clauses[0]
+	case len(n.Clauses) > 0:
+		return false, false
+	}
+	loop := stmt.Loop
+	limit := "nil"
+	if loop.Limit != nil {
+		name := fmt.Sprintf("%sLimit%d", g.prefix, k)
+		g.vars = append(g.vars, fmt.Sprintf("var %s = %d", name, *loop.Limit))
+		limit = "&" + name
+	}
+	g.imports["tags"] = true
+	g.printf("r.At(%d)", k)
+	test := "it != nil"
+	if elseClause != nil {
+		test += " && it.Len() > 0"
+	}
+	g.printf("if it := tags.NewLoopIterator(r.Interface(%s), %t, %d, %s); %s {", g.expr(expressions.Syntax(loop.Expr)), loop.Reversed, loop.Offset, limit, test)
+	g.printf("v, f := r.GetDirect(%q), r.GetDirect(\"forloop\")", loop.Variable)
+	g.printf("for i := 0; i < it.Len(); i++ {")
+	g.printf("r.LoopIteration(%d)", k)
+	g.printf("r.Set(%q, it.Index(i))", loop.Variable)
+	g.printf("r.Set(\"forloop\", it.Forloop(i))")
+	g.loops = append(g.loops, g.depth)
+	g.blockBody(n.Body, k)
+	g.loops = g.loops[:len(g.loops)-1]
+	g.printf("}")
+	g.printf("r.Set(\"forloop\", f)")
+	g.printf("r.Set(%q, v)", loop.Variable)
+	if elseClause != nil {
+		g.printf("} else {")
+		g.blockBody(elseClause.Body, k)
+	}
+	g.printf("}")
+	return true, false
+}
+
+// leaks returns true if nodes contain a break or continue tag, within a block that the
+// interpreter renders, that isn't within the body of a loop. The interpreter would return the
+// tag's control flow error to the generated code, instead of to the generated loop.
+func leaks(nodes []parser.ASTNode) bool {
+	for _, node := range nodes {
+		n, ok := node.(*parser.ASTBlock)
+		switch {
+		case !ok:
+		case n.Name == "for" || n.Name == "tablerow":
+			// The loop catches a break or continue in its body, but not in its else clause. The
+			// loop may be rendered by the interpreter.
+			for _, c := range n.Clauses {
+				if breaks(c) {
+					return true
+				}
+			}
+		case n.Name == "if" || n.Name == "unless" || n.Name == "case" || n.Name == "capture":
+			if leaks(n.Body) {
+				return true
+			}
+			for _, c := range n.Clauses {
+				if leaks(c.Body) {
+					return true
+				}
+			}
+		default:
+			if breaks(n) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// breaks returns true if n contains a break or continue tag that isn't within the body of a loop.
+func breaks(n parser.ASTNode) bool {
+	found := false
+	parser.Inspect(n, func(node parser.ASTNode) bool {
+		switch n := node.(type) {
+		case *parser.ASTTag:
+			found = found || n.Name == "break" || n.Name == "continue"
+		case *parser.ASTBlock:
+			if n.Name == "for" || n.Name == "tablerow" {
+				for _, c := range n.Clauses {
+					found = found || breaks(c)
+				}
+				return false
+			}
+		}
+		return true
+	})
+	return found
+}
+
+// expr returns the Go expression, of type interface{}, that evaluates an expression as the
+// interpreter does. Its value holds a Go value or a values.Value; see render.Runtime.
+func (g *generator) expr(node expressions.Node) string { // nolint: gocyclo
+	switch n := node.(type) {
+	case *expressions.Literal:
+		return g.literal(n.Value)
+	case *expressions.Variable:
+		return fmt.Sprintf("r.Get(%q)", n.Name)
+	case *expressions.Property:
+		return fmt.Sprintf("r.Property(%s, %s)", g.expr(n.Object), g.key(n.Name))
+	case *expressions.Index:
+		return fmt.Sprintf("r.Index(%s, %s)", g.expr(n.Object), g.expr(n.Index))
+	case *expressions.Filter:
+		if value, ok := g.cfg.Constant(expressions.FromSyntax(n)); ok && isConstant(value) {
+			// A pure filter of constants has the same value at each render.
+			return g.literal(value)
+		}
+		s := fmt.Sprintf("r.Apply(r.Filter(%q), %s", n.Name, g.expr(n.Receiver))
+		for _, arg := range n.Args {
+			s += ", " + g.expr(arg)
+		}
+		return s + ")"
+	case *expressions.Range:
+		return fmt.Sprintf("r.Range(r.Int(%s), r.Int(%s))", g.expr(n.Start), g.expr(n.End))
+	case *expressions.Binary:
+		return g.binary(n)
+	default:
+		g.fail("unknown expression type %T", n)
+		return "nil"
+	}
+}
+
+// binary returns the Go expression, of type bool, that evaluates a comparison or logical operation.
+func (g *generator) binary(n *expressions.Binary) string {
+	switch n.Op {
+	case "and", "or":
+		op := map[string]string{"and": "&&", "or": "||"}[n.Op]
+		return fmt.Sprintf("%s %s %s", g.operand(n.Left), op, g.operand(n.Right))
+	}
+	a, b := g.expr(n.Left), g.expr(n.Right)
+	format, ok := map[string]string{
+		"contains": "r.Contains(%s, %s)",
+		"==":       "r.Equal(%s, %s)",
+		"!=":       "!r.Equal(%s, %s)",
+		"<":        "r.Less(%s, %s)",
+		">":        "r.Greater(%s, %s)",
+		">=":       "r.GreaterOrEqual(%s, %s)",
+		"<=":       "r.LessOrEqual(%s, %s)",
+	}[n.Op]
+	if !ok {
+		g.fail("unknown operator %q", n.Op)
+	}
+	return fmt.Sprintf(format, a, b)
+}
+
+// operand returns the Go expression, of type bool, of the test of an operand of and or or.
+func (g *generator) operand(n expressions.Node) string {
+	if b, ok := n.(*expressions.Binary); ok {
+		return "(" + g.binary(b) + ")"
+	}
+	return fmt.Sprintf("r.Test(%s)", g.expr(n))
+}
+
+// test returns the Go expression, of type bool, that is true unless the value of an expression
+// is nil or false.
+func (g *generator) test(n expressions.Node) string {
+	if b, ok := n.(*expressions.Binary); ok {
+		return g.binary(b)
+	}
+	return fmt.Sprintf("r.Truthy(%s)", g.expr(n))
+}
+
+// literal returns the Go constant of a literal.
+func (g *generator) literal(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "nil"
+	case bool, int:
+		return fmt.Sprint(v)
+	case float64:
+		s := strconv.FormatFloat(v, 'g', -1, 64)
+		if !strings.ContainsAny(s, ".eE") {
+			s += ".0"
+		}
+		return s
+	case string:
+		return strconv.Quote(v)
+	default:
+		g.fail("unknown literal type %T", v)
+		return "nil"
+	}
+}
+
+// isConstant returns true if a value has a Go constant, that literal returns.
+func isConstant(value interface{}) bool {
+	switch v := value.(type) {
+	case nil, bool, int, string:
+		return true
+	case float64:
+		return !math.IsInf(v, 0) && !math.IsNaN(v)
+	}
+	return false
+}
+
+// key returns a reference to the values.Value of a property name, that is created once.
+func (g *generator) key(name string) string {
+	s := strconv.Quote(name)
+	i, ok := g.keys[s]
+	if !ok {
+		i = len(g.keyList)
+		g.keys[s] = i
+		g.keyList = append(g.keyList, s)
+	}
+	return fmt.Sprintf("%sKeys[%d]", g.prefix, i)
+}
+
+func (g *generator) fail(format string, a ...interface{}) {
+	if g.err == nil {
+		g.err = fmt.Errorf(format, a...)
+	}
+}
@@ -0,0 +1,113 @@
+package codegen
+
+import (
+	"io"
+	"testing"
+
+	"github.com/etecs-ru/liquid/v2/filters"
+	"github.com/etecs-ru/liquid/v2/parser"
+	"github.com/etecs-ru/liquid/v2/render"
+	"github.com/etecs-ru/liquid/v2/tags"
+	"github.com/stretchr/testify/require"
+)
+
+func testConfig() render.Config {
+	cfg := render.NewConfig()
+	filters.AddStandardFilters(&cfg)
+	tags.AddStandardTags(cfg)
+	return cfg
+}
+
+func TestVarName(t *testing.T) {
+	tests := []struct{ in, expected string }{
+		{"page.html", "Page"},
+		{"layouts/product-card.html", "ProductCard"},
+		{"_header.liquid", "Header"},
+		{"404.html", "T404"},
+		{"-.html", "T"},
+	}
+	for _, test := range tests {
+		require.Equal(t, test.expected, VarName(test.in), test.in)
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	cfg := testConfig()
+	out, err := Generate(cfg, Options{Package: "templates"}, []Template{
+		{Name: "Page", Path: "page.html", Source: `{{ a | upcase }}{% for x in xs %}{% cycle "a", "b" %}{% endfor %}`},
+	})
+	require.NoError(t, err)
+	s := string(out)
+	require.Contains(t, s, "// Code generated by liquid. DO NOT EDIT.")
+	require.Contains(t, s, "package templates")
+	require.Contains(t, s, "var Page = &render.GoTemplate{")
+	require.Contains(t, s, `r.Write(r.Apply(r.Filter("upcase"), r.Get("a")))`)
+	require.Contains(t, s, "tags.NewLoopIterator(")
+	require.Regexp(t, `Fragments: +\[\]int\{3\},`, s)
+	require.Regexp(t, `AST: +\[\]byte\(`, s)
+	require.Regexp(t, `Fingerprint: +"`+cfg.Fingerprint()+`",`, s)
+	require.NotContains(t, s, "map[string]*render.GoTemplate")
+
+	// constant objects and filters are evaluated once, as the interpreter does
+	out, err = Generate(cfg, Options{Package: "templates"}, []Template{
+		{Name: "Page", Path: "page.html", Source: `{{ "a" | upcase }}{{ 2.5 | times: 2 }}{{ nil }}{{ x | append: "b" | append: "c" }}{{ x.y | default: 1.0 }}`},
+	})
+	require.NoError(t, err)
+	s = string(out)
+	require.Contains(t, s, "r.Write(`A`)")
+	require.Contains(t, s, "r.Write(`5`)")
+	require.Contains(t, s, `r.Write(r.Apply(r.Filter("append"), r.Apply(r.Filter("append"), r.Get("x"), "b"), "c"))`)
+	require.Contains(t, s, `r.Write(r.Apply(r.Filter("default"), r.Property(r.Get("x"), pageKeys[0]), 1.0))`)
+	require.Contains(t, s, `values.ValueOf("y"),`)
+	require.NotContains(t, s, "r.Write(nil)")
+
+	// a loop's else clause is rendered when the loop has no iterations
+	out, err = Generate(cfg, Options{Package: "templates"}, []Template{
+		{Name: "Page", Path: "page.html", Source: `{% for x in xs %}{{ x }}{% else %}none{% endfor %}`},
+	})
+	require.NoError(t, err)
+	s = string(out)
+	require.Contains(t, s, "; it != nil && it.Len() > 0 {")
+	require.Contains(t, s, "} else {")
+	require.NotContains(t, s, "Fragments:")
+
+	// a loop whose body breaks out of another block is rendered by the interpreter
+	cfg.AddBlock("wrap").Compiler(func(c render.BlockNode) (func(io.Writer, render.Context) error, error) {
+		return func(w io.Writer, ctx render.Context) error {
+			return ctx.RenderChildren(w)
+		}, nil
+	})
+	out, err = Generate(cfg, Options{Package: "templates", Map: "All"}, []Template{
+		{Name: "Page", Path: "page.html", Source: `{% for x in xs %}{% wrap %}{% break %}{% endwrap %}{% endfor %}`},
+	})
+	require.NoError(t, err)
+	s = string(out)
+	require.Contains(t, s, `var All = map[string]*render.GoTemplate{`)
+	require.Regexp(t, `Fragments: +\[\]int\{1\},`, s)
+	require.NotContains(t, s, "tags.NewLoopIterator(")
+}
+
+func TestGenerate_errors(t *testing.T) {
+	cfg := testConfig()
+	tests := []struct {
+		templates []Template
+		expected  string
+	}{
+		{[]Template{{Name: "page-1"}}, `"page-1" isn't a Go identifier`},
+		{[]Template{{Name: "Page"}, {Name: "Page"}}, "two templates are named Page"},
+		{[]Template{{Name: "Page", Source: "{% if a %}"}}, `unterminated "if" block`},
+		{[]Template{{Name: "Page", Source: "{% undefined_tag %}"}}, "undefined tag"},
+	}
+	for _, test := range tests {
+		_, err := Generate(cfg, Options{Package: "templates"}, test.templates)
+		require.Error(t, err, test.expected)
+		require.Contains(t, err.Error(), test.expected)
+	}
+
+	_, err := Generate(cfg, Options{Package: "templates"}, []Template{{Name: "Page", Path: "page.html", Source: "line 1\n{{ a | }}"}})
+	require.Error(t, err)
+	perr, ok := err.(parser.Error)
+	require.True(t, ok)
+	require.Equal(t, "page.html", perr.Path())
+	require.Equal(t, 2, perr.LineNumber())
+}
@@ -0,0 +1,1090 @@
+// Code generated by liquid. DO NOT EDIT.
+
+package corpus
+
+import (
+	"github.com/etecs-ru/liquid/v2/render"
+	"github.com/etecs-ru/liquid/v2/tags"
+	"github.com/etecs-ru/liquid/v2/values"
+)
+
+// Templates maps the path of each template to its variable.
+var Templates = map[string]*render.GoTemplate{
+	"testdata/assign.liquid":     Assign,
+	"testdata/control.liquid":    Control,
+	"testdata/fallback.liquid":   Fallback,
+	"testdata/loops.liquid":      Loops,
+	"testdata/objects.liquid":    Objects,
+	"testdata/whitespace.liquid": Whitespace,
+}
+
+// Assign is the template "testdata/assign.liquid", compiled to Go.
+var Assign = &render.GoTemplate{
+	Path: "testdata/assign.liquid",
+	Source: `{% assign greeting = "Hello" | append: ", " | append: user.name %}{{ greeting }}
+{% assign count = numbers | size %}{% if count > 3 %}{% assign big = true %}{% endif %}{{ count }} {{ big }}
+{% capture summary %}
+  {{ greeting }} has {{ count }} numbers{% if big %}, a lot{% endif %}.
+{% endcapture %}[{{ summary | strip }}]
+{% assign list = "" %}{% for p in products %}{% assign list = list | append: p.title | append: ";" %}{% endfor %}{{ list }}
+{% capture empty_capture %}{% endcapture %}[{{ empty_capture }}]
+`,
+	AST:         []byte("\x16testdata/assign.liquid\x01\x15\x04\x01\x00B\x03\x06\n5\x01\x01\x03\x02B\x0e\x00\x00\x03\b\x01C\n\x02\bgreeting\x02\x00P\x01\x00\x00\x00\x00\x01Q\x04\x01Q#\x03\x06\n\x16\x02\x01\x05\x01t\x12\x03\x02\x06\t\x02$\x01\x04\x01\x86\x01\x17\x03\x06\n\n\x026\x00\x01\x01\x9d\x01\v\x03\x05\b\x00\x02M\x03\x02\xa8\x01\v\x00\x00\x03\x05\x02X\a\x02\x05count\x02\x00\xb3\x01\x01\x00\x00\x00\x00\x02c\x03\x02\xb4\x01\t\x00\x00\x03\x03\x02d\x05\x02\x03big\x02\x00\xbd\x01\x01\x00\x00\x00\x00\x02m\x05\x01\xbe\x01\x15\x03\a\v\a\x03\x01\a\x02\x00\xd3\x01\x03\x00\x00\x00\x00\x03\x16\x03\x02\xd6\x01\x0e\x00\x00\x03\b\x04\x03\n\x02\bgreeting\x02\x00\xe4\x01\x05\x00\x00\x00\x00\x04\x11\x03\x02\xe9\x01\v\x00\x00\x03\x05\x04\x16\a\x02\x05count\x02\x00\xf4\x01\b\x00\x00\x00\x00\x04!\x05\x01\xfc\x01\f\x03\x02\x06\x03\x04)\x01\x02\x00\x88\x02\a\x00\x00\x00\x00\x045\x00\x01\x01\x8f\x02\v\x03\x05\b\x00\x04<\x02\x00\x9a\x02\x02\x00\x00\x00\x00\x04G\x00\x01\x01\x9c\x02\x10\x03\n\r\x00\x05\x01\x02\x00\xac\x02\x01\x00\x00\x00\x00\x05\x11\x03\x02\xad\x02\x15\x00\x00\x03\x0f\x05\x12\x11\x05\x05strip\x00\x02\asummary\x02\x00\xc2\x02\x02\x00\x00\x00\x00\x05'\x04\x01\xc4\x02\x16\x03\x06\n\t\x06\x01\x05\x01\xda\x02\x17\x03\x03\a\r\x06\x17\x01\x04\x01\xf1\x028\x03\x06\n+\x06.\x00\x01\x01\xa9\x03\f\x03\x06\t\x00\x06f\x03\x02\xb5\x03\n\x00\x00\x03\x04\x06r\x06\x02\x04list\x02\x00\xbf\x03\x01\x00\x00\x00\x00\x06|\x05\x01\xc0\x03\x1b\x03\a\v\r\a\x01\x00\x00\x01\x01\xdb\x03\x10\x03\n\r\x00\a\x1c\x02\x00\xeb\x03\x01\x00\x00\x00\x00\a,\x03\x02\xec\x03\x13\x00\x00\x03\r\a-\x0f\x02\rempty_capture\x02\x00\xff\x03\x02\x00\x00\x00\x00\a@"),
+	Fingerprint: "957d1759c343cb7786b04e2907c43e041e72240ebf11b616db43c25513a8c931",
+	Checksum:    0xd8a4f4dbbc48e867,
+	Render:      renderAssign,
+}
+
+var assignKeys = [...]values.Value{
+	values.ValueOf("name"),
+	values.ValueOf("title"),
+}
+
+func renderAssign(r *render.Runtime) {
+	r.Tag(1, false)
+	r.Assign("greeting", r.Apply(r.Filter("append"), "Hello, ", r.Property(r.Get("user"), assignKeys[0])))
+	r.Tag(2, false)
+	r.Write(r.Get("greeting"))
+	r.Text(3)
+	r.Tag(4, false)
+	r.Assign("count", r.Apply(r.Filter("size"), r.Get("numbers")))
+	r.At(5)
+	if r.Greater(r.Get("count"), 3) {
+		r.Push()
+		r.Tag(6, false)
+		r.Assign("big", true)
+		r.Pop(5)
+	}
+	r.Tag(7, false)
+	r.Write(r.Get("count"))
+	r.Text(8)
+	r.Tag(9, false)
+	r.Write(r.Get("big"))
+	r.Text(10)
+	r.At(11)
+	r.BeginCapture()
+	r.Text(12)
+	r.Tag(13, false)
+	r.Write(r.Get("greeting"))
+	r.Text(14)
+	r.Tag(15, false)
+	r.Write(r.Get("count"))
+	r.Text(16)
+	r.At(17)
+	if r.Truthy(r.Get("big")) {
+		r.Push()
+		r.Text(18)
+		r.Pop(17)
+	}
+	r.Text(19)
+	r.EndCapture(11, "summary")
+	r.Text(20)
+	r.Tag(21, false)
+	r.Write(r.Apply(r.Filter("strip"), r.Get("summary")))
+	r.Text(22)
+	r.Tag(23, false)
+	r.Assign("list", "")
+	r.At(24)
+	if it := tags.NewLoopIterator(r.Interface(r.Get("products")), false, 0, nil); it != nil {
+		v, f := r.GetDirect("p"), r.GetDirect("forloop")
+		for i := 0; i < it.Len(); i++ {
+			r.LoopIteration(24)
+			r.Set("p", it.Index(i))
+			r.Set("forloop", it.Forloop(i))
+			r.Push()
+			r.Tag(25, false)
+			r.Assign("list", r.Apply(r.Filter("append"), r.Apply(r.Filter("append"), r.Get("list"), r.Property(r.Get("p"), assignKeys[1])), ";"))
+			r.Pop(24)
+		}
+		r.Set("forloop", f)
+		r.Set("p", v)
+	}
+	r.Tag(26, false)
+	r.Write(r.Get("list"))
+	r.Text(27)
+	r.At(28)
+	r.BeginCapture()
+	r.EndCapture(28, "empty_capture")
+	r.Text(29)
+	r.Tag(30, false)
+	r.Write(r.Get("empty_capture"))
+	r.Text(31)
+}
+
+// Control is the template "testdata/control.liquid", compiled to Go.
+var Control = &render.GoTemplate{
+	Path: "testdata/control.liquid",
+	Source: `{% if user.admin %}admin{% elsif user.name == "Ann" %}Ann{% else %}other{% endif %}
+{% if n > 2 and n < 10 %}between{% endif %} {% if n >= 3 or false %}ge{% endif %} {% if n <= 3 %}le{% endif %}
+{% if n != 3 %}ne{% else %}eq{% endif %} {% if user.tags contains "b" %}tagged{% endif %} {% if "abc" contains "bc" %}sub{% endif %}
+{% unless user.admin %}not admin{% elsif n %}n{% endunless %}
+{% unless n %}no n{% else %}n={{ n }}{% endunless %}
+{% if missing %}missing{% elsif empty %}empty{% else %}neither{% endif %}
+{% case user.name %}
+  {% when "Bob", "Ann" %}Bob or Ann
+  {% when "Eve" %}Eve
+  {% else %}someone
+{% endcase %}
+{% case n %}{% when 1 %}one{% when 3 %}three{% endcase %}
+{% case n %}{% else %}always{% endcase %}
+{% case nothing %}{% when 1 %}one{% endcase %}
+{% if products.size > 1 %}{% if products[0].price < products[1].price %}cheaper first{% endif %}{% endif %}
+`,
+	AST:         []byte("\x17testdata/control.liquid\x01\x1e\x05\x01\x00\x13\x03\x02\x06\n\x01\x01\x01\x02\x00\x13\x05\x00\x00\x00\x00\x01\x14\x02\x05\x01\x18\x1e\x03\x05\t\x12\x01\x19\x01\x02\x006\x03\x00\x00\x00\x00\x017\x00\x00\x05\x019\n\x03\x04\a\x00\x01:\x01\x02\x00C\x05\x00\x00\x00\x00\x01D\x00\x00\x01\x01H\v\x03\x05\b\x00\x01I\x02\x00S\x01\x00\x00\x00\x00\x01T\x05\x01T\x19\x03\x02\x06\x10\x02\x01\x01\x02\x00m\a\x00\x00\x00\x00\x02\x1a\x00\x01\x01t\v\x03\x05\b\x00\x02!\x02\x00\x7f\x01\x00\x00\x00\x00\x02,\x05\x01\x80\x01\x18\x03\x02\x06\x0f\x02-\x01\x02\x00\x98\x01\x02\x00\x00\x00\x00\x02E\x00\x01\x01\x9a\x01\v\x03\x05\b\x00\x02G\x02\x00\xa5\x01\x01\x00\x00\x00\x00\x02R\x05\x01\xa6\x01\x0f\x03\x02\x06\x06\x02S\x01\x02\x00\xb5\x01\x02\x00\x00\x00\x00\x02b\x00\x01\x01\xb7\x01\v\x03\x05\b\x00\x02d\x02\x00\xc2\x01\x01\x00\x00\x00\x00\x02o\x05\x01\xc3\x01\x0f\x03\x02\x06\x06\x03\x01\x01\x02\x00\xd2\x01\x02\x00\x00\x00\x00\x03\x10\x01\x05\x01\xd4\x01\n\x03\x04\a\x00\x03\x12\x01\x02\x00\xde\x01\x02\x00\x00\x00\x00\x03\x1c\x00\x00\x01\x01\xe0\x01\v\x03\x05\b\x00\x03\x1e\x02\x00\xeb\x01\x01\x00\x00\x00\x00\x03)\x05\x01\xec\x01\x1f\x03\x02\x06\x16\x03*\x01\x02\x00\x8b\x02\x06\x00\x00\x00\x00\x03I\x00\x01\x01\x91\x02\v\x03\x05\b\x00\x03O\x02\x00\x9c\x02\x01\x00\x00\x00\x00\x03Z\x05\x01\x9d\x02\x1c\x03\x02\x06\x13\x03[\x01\x02\x00\xb9\x02\x03\x00\x00\x00\x00\x03w\x00\x01\x01\xbc\x02\v\x03\x05\b\x00\x03z\x02\x00\xc7\x02\x01\x00\x00\x00\x00\x03\x85\x01\x05\x01\xc8\x02\x17\x03\x06\n\n\x04\x01\x01\x02\x00\xdf\x02\t\x00\x00\x00\x00\x04\x18\x01\x05\x01\xe8\x02\r\x03\x05\t\x01\x04!\x01\x02\x00\xf5\x02\x01\x00\x00\x00\x00\x04.\x00\x00\x01\x01\xf6\x02\x0f\x03\t\f\x00\x04/\x02\x00\x85\x03\x01\x00\x00\x00\x00\x04>\x05\x01\x86\x03\x0e\x03\x06\n\x01\x05\x01\x01\x02\x00\x94\x03\x04\x00\x00\x00\x00\x05\x0f\x01\x05\x01\x98\x03\n\x03\x04\a\x00\x05\x13\x02\x02\x00\xa2\x03\x02\x00\x00\x00\x00\x05\x1d\x03\x02\xa4\x03\a\x00\x00\x03\x01\x05\x1f\x03\x02\x01n\x00\x00\x01\x01\xab\x03\x0f\x03\t\f\x00\x05&\x02\x00\xba\x03\x01\x00\x00\x00\x00\x055\x05\x01\xbb\x03\x10\x03\x02\x06\a\x06\x01\x01\x02\x00\xcb\x03\a\x00\x00\x00\x00\x06\x11\x02\x05\x01\xd2\x03\x11\x03\x05\t\x05\x06\x18\x01\x02\x00\xe3\x03\x05\x00\x00\x00\x00\x06)\x00\x00\x05\x01\xe8\x03\n\x03\x04\a\x00\x06.\x01\x02\x00\xf2\x03\a\x00\x00\x00\x00\x068\x00\x00\x01\x01\xf9\x03\v\x03\x05\b\x00\x06?\x02\x00\x84\x04\x01\x00\x00\x00\x00\x06J\x05\x01\x85\x04\x14\x03\x04\b\t\a\x01\x01\x02\x00\x99\x04\x03\x00\x00\x00\x00\a\x15\x03\x05\x01\x9c\x04\x17\x03\x04\b\f\b\x03\x01\x02\x00\xb3\x04\r\x00\x00\x00\x00\b\x1a\x00\x00\x05\x01\xc0\x04\x10\x03\x04\b\x05\t\x03\x01\x02\x00\xd0\x04\x06\x00\x00\x00\x00\t\x13\x00\x00\x05\x01\xd6\x04\n\x03\x04\a\x00\n\x03\x01\x02\x00\xe0\x04\b\x00\x00\x00\x00\n\r\x00\x00\x01\x01\xe8\x04\r\x03\a\n\x00\v\x01\x02\x00\xf5\x04\x01\x00\x00\x00\x00\v\x0e\x05\x01\xf6\x04\f\x03\x04\b\x01\f\x01\x00\x02\x05\x01\x82\x05\f\x03\x04\b\x01\f\r\x01\x02\x00\x8e\x05\x03\x00\x00\x00\x00\f\x19\x00\x00\x05\x01\x91\x05\f\x03\x04\b\x01\f\x1c\x01\x02\x00\x9d\x05\x05\x00\x00\x00\x00\f(\x00\x00\x01\x01\xa2\x05\r\x03\a\n\x00\f-\x02\x00\xaf\x05\x01\x00\x00\x00\x00\f:\x05\x01\xb0\x05\f\x03\x04\b\x01\r\x01\x00\x01\x05\x01\xbc\x05\n\x03\x04\a\x00\r\r\x01\x02\x00\xc6\x05\x06\x00\x00\x00\x00\r\x17\x00\x00\x01\x01\xcc\x05\r\x03\a\n\x00\r\x1d\x02\x00\xd9\x05\x01\x00\x00\x00\x00\r*\x05\x01\xda\x05\x12\x03\x04\b\a\x0e\x01\x00\x01\x05\x01\xec\x05\f\x03\x04\b\x01\x0e\x13\x01\x02\x00\xf8\x05\x03\x00\x00\x00\x00\x0e\x1f\x00\x00\x01\x01\xfb\x05\r\x03\a\n\x00\x0e\"\x02\x00\x88\x06\x01\x00\x00\x00\x00\x0e/\x05\x01\x89\x06\x1a\x03\x02\x06\x11\x0f\x01\x01\x05\x01\xa3\x06.\x03\x02\x06%\x0f\x1b\x01\x02\x00\xd1\x06\r\x00\x00\x00\x00\x0fI\x00\x01\x01\xde\x06\v\x03\x05\b\x00\x0fV\x00\x01\x01\xe9\x06\v\x03\x05\b\x00\x0fa\x02\x00\xf4\x06\x01\x00\x00\x00\x00\x0fl"),
+	Fingerprint: "957d1759c343cb7786b04e2907c43e041e72240ebf11b616db43c25513a8c931",
+	Checksum:    0x6bbcb59565071c23,
+	Render:      renderControl,
+}
+
+var controlKeys = [...]values.Value{
+	values.ValueOf("admin"),
+	values.ValueOf("name"),
+	values.ValueOf("tags"),
+	values.ValueOf("size"),
+	values.ValueOf("price"),
+}
+
+func renderControl(r *render.Runtime) {
+	r.At(1)
+	if r.Truthy(r.Property(r.Get("user"), controlKeys[0])) {
+		r.Push()
+		r.Text(2)
+		r.Pop(1)
+	} else if r.Equal(r.Property(r.Get("user"), controlKeys[1]), "Ann") {
+		r.Push()
+		r.Text(4)
+		r.Pop(1)
+	} else {
+		r.Push()
+		r.Text(6)
+		r.Pop(1)
+	}
+	r.Text(7)
+	r.At(8)
+	if (r.Greater(r.Get("n"), 2)) && (r.Less(r.Get("n"), 10)) {
+		r.Push()
+		r.Text(9)
+		r.Pop(8)
+	}
+	r.Text(10)
+	r.At(11)
+	if (r.GreaterOrEqual(r.Get("n"), 3)) || r.Test(false) {
+		r.Push()
+		r.Text(12)
+		r.Pop(11)
+	}
+	r.Text(13)
+	r.At(14)
+	if r.LessOrEqual(r.Get("n"), 3) {
+		r.Push()
+		r.Text(15)
+		r.Pop(14)
+	}
+	r.Text(16)
+	r.At(17)
+	if !r.Equal(r.Get("n"), 3) {
+		r.Push()
+		r.Text(18)
+		r.Pop(17)
+	} else {
+		r.Push()
+		r.Text(20)
+		r.Pop(17)
+	}
+	r.Text(21)
+	r.At(22)
+	if r.Contains(r.Property(r.Get("user"), controlKeys[2]), "b") {
+		r.Push()
+		r.Text(23)
+		r.Pop(22)
+	}
+	r.Text(24)
+	r.At(25)
+	if r.Contains("abc", "bc") {
+		r.Push()
+		r.Text(26)
+		r.Pop(25)
+	}
+	r.Text(27)
+	r.At(28)
+	if !(r.Truthy(r.Property(r.Get("user"), controlKeys[0]))) {
+		r.Push()
+		r.Text(29)
+		r.Pop(28)
+	} else if r.Truthy(r.Get("n")) {
+		r.Push()
+		r.Text(31)
+		r.Pop(28)
+	}
+	r.Text(32)
+	r.At(33)
+	if !(r.Truthy(r.Get("n"))) {
+		r.Push()
+		r.Text(34)
+		r.Pop(33)
+	} else {
+		r.Push()
+		r.Text(36)
+		r.Tag(37, false)
+		r.Write(r.Get("n"))
+		r.Pop(33)
+	}
+	r.Text(38)
+	r.At(39)
+	if r.Truthy(r.Get("missing")) {
+		r.Push()
+		r.Text(40)
+		r.Pop(39)
+	} else if r.Truthy(r.Get("empty")) {
+		r.Push()
+		r.Text(42)
+		r.Pop(39)
+	} else {
+		r.Push()
+		r.Text(44)
+		r.Pop(39)
+	}
+	r.Text(45)
+	r.At(46)
+	{
+		sel := r.Interface(r.Property(r.Get("user"), controlKeys[1]))
+		if values.Equal(sel, r.Interface("Bob")) || values.Equal(sel, r.Interface("Ann")) {
+			r.Push()
+			r.Text(49)
+			r.Pop(46)
+		} else if values.Equal(sel, r.Interface("Eve")) {
+			r.Push()
+			r.Text(51)
+			r.Pop(46)
+		} else {
+			r.Push()
+			r.Text(53)
+			r.Pop(46)
+		}
+	}
+	r.Text(54)
+	r.At(55)
+	{
+		sel := r.Interface(r.Get("n"))
+		if values.Equal(sel, r.Interface(1)) {
+			r.Push()
+			r.Text(57)
+			r.Pop(55)
+		} else if values.Equal(sel, r.Interface(3)) {
+			r.Push()
+			r.Text(59)
+			r.Pop(55)
+		}
+	}
+	r.Text(60)
+	r.At(61)
+	{
+		sel := r.Interface(r.Get("n"))
+		_ = sel
+		{
+			r.Push()
+			r.Text(63)
+			r.Pop(61)
+		}
+	}
+	r.Text(64)
+	r.At(65)
+	{
+		sel := r.Interface(r.Get("nothing"))
+		if values.Equal(sel, r.Interface(1)) {
+			r.Push()
+			r.Text(67)
+			r.Pop(65)
+		}
+	}
+	r.Text(68)
+	r.At(69)
+	if r.Greater(r.Property(r.Get("products"), controlKeys[3]), 1) {
+		r.Push()
+		r.At(70)
+		if r.Less(r.Property(r.Index(r.Get("products"), 0), controlKeys[4]), r.Property(r.Index(r.Get("products"), 1), controlKeys[4])) {
+			r.Push()
+			r.Text(71)
+			r.Pop(70)
+		}
+		r.Pop(69)
+	}
+	r.Text(72)
+}
+
+// Fallback is the template "testdata/fallback.liquid", compiled to Go.
+var Fallback = &render.GoTemplate{
+	Path: "testdata/fallback.liquid",
+	Source: `{% include "partials/item.html" with products[0] %}
+{% render "partials/item.html", item: products[1] %}
+{% increment counter %}{% increment counter %}{% decrement counter %}
+{% tablerow x in numbers cols: 2 %}{{ x }}{% if x == 3 %}{% break %}{% endif %}{% endtablerow %}
+{% for x in numbers %}{% tablerow y in numbers limit: 1 %}{{ x }}{{ y }}{% break %}{% endtablerow %}{% endfor %}
+{% for x in numbers %}{% for y in numbers %}{{ y }}{% break %}{% endfor %}{% endfor %}
+{% tablerow x in empty %}{{ x }}{% else %}none{% endtablerow %}
+{% for x in numbers %}{% tablerow y in empty %}{% else %}{{ x }}{% break %}{% endtablerow %}{% endfor %}
+`,
+	AST:         []byte("\x18testdata/fallback.liquid\x01\x12\x04\x01\x003\x03\a\v%\x01\x01\x02\x003\x01\x00\x00\x00\x00\x014\x04\x0144\x03\x06\n'\x02\x01\x02\x00h\x01\x00\x00\x00\x00\x025\x04\x01i\x17\x03\t\r\a\x03\x01\x04\x01\x80\x01\x17\x03\t\r\a\x03\x18\x04\x01\x97\x01\x17\x03\t\r\a\x03/\x02\x00\xae\x01\x01\x00\x00\x00\x00\x03F\x05\x01\xaf\x01#\x03\b\f\x14\x04\x01\x02\x03\x02\xd2\x01\a\x00\x00\x03\x01\x04$\x03\x02\x01x\x05\x01\xd9\x01\x0f\x03\x02\x06\x06\x04+\x01\x04\x01\xe8\x01\v\x03\x05\b\x00\x04:\x00\x01\x01\xf3\x01\v\x03\x05\b\x00\x04E\x00\x01\x01\xfe\x01\x11\x03\v\x0e\x00\x04P\x02\x00\x8f\x02\x01\x00\x00\x00\x00\x04a\x05\x01\x90\x02\x16\x03\x03\a\f\x05\x01\x01\x05\x01\xa6\x02$\x03\b\f\x15\x05\x17\x03\x03\x02\xca\x02\a\x00\x00\x03\x01\x05;\x03\x02\x01x\x03\x02\xd1\x02\a\x00\x00\x03\x01\x05B\x03\x02\x01y\x04\x01\xd8\x02\v\x03\x05\b\x00\x05I\x00\x01\x01\xe3\x02\x11\x03\v\x0e\x00\x05T\x00\x01\x01\xf4\x02\f\x03\x06\t\x00\x05e\x02\x00\x80\x03\x01\x00\x00\x00\x00\x05q\x05\x01\x81\x03\x16\x03\x03\a\f\x06\x01\x01\x05\x01\x97\x03\x16\x03\x03\a\f\x06\x17\x02\x03\x02\xad\x03\a\x00\x00\x03\x01\x06-\x03\x02\x01y\x04\x01\xb4\x03\v\x03\x05\b\x00\x064\x00\x01\x01\xbf\x03\f\x03\x06\t\x00\x06?\x00\x01\x01\xcb\x03\f\x03\x06\t\x00\x06K\x02\x00\xd7\x03\x01\x00\x00\x00\x00\x06W\x05\x01\xd8\x03\x19\x03\b\f\n\a\x01\x01\x03\x02\xf1\x03\a\x00\x00\x03\x01\a\x1a\x03\x02\x01x\x01\x05\x01\xf8\x03\n\x03\x04\a\x00\a!\x01\x02\x00\x82\x04\x04\x00\x00\x00\x00\a+\x00\x00\x01\x01\x86\x04\x11\x03\v\x0e\x00\a/\x02\x00\x97\x04\x01\x00\x00\x00\x00\a@\x05\x01\x98\x04\x16\x03\x03\a\f\b\x01\x01\x05\x01\xae\x04\x19\x03\b\f\n\b\x17\x00\x01\x05\x01\xc7\x04\n\x03\x04\a\x00\b0\x02\x03\x02\xd1\x04\a\x00\x00\x03\x01\b:\x03\x02\x01x\x04\x01\xd8\x04\v\x03\x05\b\x00\bA\x00\x00\x01\x01\xe3\x04\x11\x03\v\x0e\x00\bL\x00\x01\x01\xf4\x04\f\x03\x06\t\x00\b]\x02\x00\x80\x05\x01\x00\x00\x00\x00\bi"),
+	Fingerprint: "957d1759c343cb7786b04e2907c43e041e72240ebf11b616db43c25513a8c931",
+	Checksum:    0xda73b6e72fda0afb,
+	Fragments:   []int{1, 3, 5, 6, 7, 9, 15, 25, 30},
+	Render:      renderFallback,
+}
+
+func renderFallback(r *render.Runtime) {
+	r.Fragment(1)
+	r.Text(2)
+	r.Fragment(3)
+	r.Text(4)
+	r.Fragment(5)
+	r.Fragment(6)
+	r.Fragment(7)
+	r.Text(8)
+	r.Fragment(9)
+	r.Text(13)
+	r.At(14)
+	if it := tags.NewLoopIterator(r.Interface(r.Get("numbers")), false, 0, nil); it != nil {
+		v, f := r.GetDirect("x"), r.GetDirect("forloop")
+		for i := 0; i < it.Len(); i++ {
+			r.LoopIteration(14)
+			r.Set("x", it.Index(i))
+			r.Set("forloop", it.Forloop(i))
+			r.Push()
+			r.Fragment(15)
+			r.Pop(14)
+		}
+		r.Set("forloop", f)
+		r.Set("x", v)
+	}
+	r.Text(19)
+	r.At(20)
+	if it := tags.NewLoopIterator(r.Interface(r.Get("numbers")), false, 0, nil); it != nil {
+		v, f := r.GetDirect("x"), r.GetDirect("forloop")
+		for i := 0; i < it.Len(); i++ {
+			r.LoopIteration(20)
+			r.Set("x", it.Index(i))
+			r.Set("forloop", it.Forloop(i))
+			r.Push()
+			r.At(21)
+			if it := tags.NewLoopIterator(r.Interface(r.Get("numbers")), false, 0, nil); it != nil {
+				v, f := r.GetDirect("y"), r.GetDirect("forloop")
+				for i := 0; i < it.Len(); i++ {
+					r.LoopIteration(21)
+					r.Set("y", it.Index(i))
+					r.Set("forloop", it.Forloop(i))
+					r.Push()
+					r.Tag(22, false)
+					r.Write(r.Get("y"))
+					r.Tag(23, false)
+					r.Discard(1)
+					break
+				}
+				r.Set("forloop", f)
+				r.Set("y", v)
+			}
+			r.Pop(20)
+		}
+		r.Set("forloop", f)
+		r.Set("x", v)
+	}
+	r.Text(24)
+	r.Fragment(25)
+	r.Text(29)
+	r.Fragment(30)
+	r.Text(35)
+}
+
+// Loops is the template "testdata/loops.liquid", compiled to Go.
+var Loops = &render.GoTemplate{
+	Path: "testdata/loops.liquid",
+	Source: `{% for p in products %}{{ forloop.index }}/{{ forloop.length }}: {{ p.title }}{% unless forloop.last %}, {% endunless %}{% endfor %}
+{% for x in numbers reversed %}{{ x }}{% endfor %}
+{% for x in numbers limit: 2 offset: 1 %}{{ x }}{% endfor %}
+{% for x in numbers offset: 10 %}{{ x }}{% endfor %}
+{% for x in (1..n) %}{{ x }}{% if forloop.first %}<{% endif %}{% endfor %}
+{% for x in empty %}never{% endfor %}{% for x in missing %}never{% endfor %}
+{% for x in numbers %}
+  {% if x == 2 %}{% continue %}{% endif %}
+  {% if x > 3 %}{% break %}{% endif %}
+  [{{ x }}]
+{% endfor %}
+{% for p in products %}{% for t in p.tags %}{{ p.title }}:{{ t }}{% if t == "b" %}{% break %}{% endif %} {% endfor %};{% endfor %}
+{% for x in numbers %}{% cycle "odd", "even" %}{% endfor %}
+{% for x in numbers %}{% case x %}{% when 1 %}{% continue %}{% when 2 %}two {% else %}{% break %}{% endcase %}{{ x }}{% endfor %}
+{% for x in numbers %}{% capture c %}<{{ x }}>{% if x == 2 %}{% break %}{% endif %}{% endcapture %}{{ c }}{% endfor %}{{ c }}
+{% assign x = "outer" %}{% for x in numbers limit: 1 %}{{ x }}{% endfor %}{{ x }} {{ forloop }}
+{% for x in numbers %}{% if x == 1 %}a {% break %}{% else %}b {% continue %}{% endif %}{% endfor %}
+{% for x in empty %}never{% else %}else{% endfor %} {% for x in missing %}never{% else %}else{% endfor %} {% for x in numbers offset: 5 %}never{% else %}else{% endfor %} {% for x in numbers limit: 1 %}{{ x }}{% else %}never{% endfor %}
+{% for x in numbers %}{% for y in empty %}{{ y }}{% else %}{% if x == 4 %}{% break %}{% endif %}{{ x }}{% endfor %}{% endfor %}
+`,
+	AST:         []byte("\x15testdata/loops.liquid\x01*\x05\x01\x00\x17\x03\x03\a\r\x01\x01\x06\x03\x02\x17\x13\x00\x00\x03\r\x01\x18\x10\x03\x05index\x02\aforloop\x02\x00*\x01\x00\x00\x00\x00\x01+\x03\x02+\x14\x00\x00\x03\x0e\x01,\x11\x03\x06length\x02\aforloop\x02\x00?\x02\x00\x00\x00\x00\x01@\x03\x02A\r\x00\x00\x03\a\x01B\n\x03\x05title\x02\x01p\x05\x01N\x19\x03\x06\n\f\x01O\x01\x02\x00g\x02\x00\x00\x00\x00\x01h\x00\x01\x01i\x0f\x03\t\f\x00\x01j\x00\x01\x01x\f\x03\x06\t\x00\x01y\x02\x00\x84\x01\x01\x00\x00\x00\x00\x01\x85\x01\x05\x01\x85\x01\x1f\x03\x03\a\x15\x02\x01\x01\x03\x02\xa4\x01\a\x00\x00\x03\x01\x02 \x03\x02\x01x\x00\x01\x01\xab\x01\f\x03\x06\t\x00\x02'\x02\x00\xb7\x01\x01\x00\x00\x00\x00\x023\x05\x01\xb8\x01)\x03\x03\a\x1f\x03\x01\x01\x03\x02\xe1\x01\a\x00\x00\x03\x01\x03*\x03\x02\x01x\x00\x01\x01\xe8\x01\f\x03\x06\t\x00\x031\x02\x00\xf4\x01\x01\x00\x00\x00\x00\x03=\x05\x01\xf5\x01!\x03\x03\a\x17\x04\x01\x01\x03\x02\x96\x02\a\x00\x00\x03\x01\x04\"\x03\x02\x01x\x00\x01\x01\x9d\x02\f\x03\x06\t\x00\x04)\x02\x00\xa9\x02\x01\x00\x00\x00\x00\x045\x05\x01\xaa\x02\x15\x03\x03\a\v\x05\x01\x02\x03\x02\xbf\x02\a\x00\x00\x03\x01\x05\x16\x03\x02\x01x\x05\x01\xc6\x02\x16\x03\x02\x06\r\x05\x1d\x01\x02\x00\xdc\x02\x01\x00\x00\x00\x00\x053\x00\x01\x01\xdd\x02\v\x03\x05\b\x00\x054\x00\x01\x01\xe8\x02\f\x03\x06\t\x00\x05?\x02\x00\xf4\x02\x01\x00\x00\x00\x00\x05K\x05\x01\xf5\x02\x14\x03\x03\a\n\x06\x01\x01\x02\x00\x89\x03\x05\x00\x00\x00\x00\x06\x15\x00\x01\x01\x8e\x03\f\x03\x06\t\x00\x06\x1a\x05\x01\x9a\x03\x16\x03\x03\a\f\x06&\x01\x02\x00\xb0\x03\x05\x00\x00\x00\x00\x06<\x00\x01\x01\xb5\x03\f\x03\x06\t\x00\x06A\x02\x00\xc1\x03\x01\x00\x00\x00\x00\x06M\x05\x01\xc2\x03\x16\x03\x03\a\f\a\x01\a\x02\x00\xd8\x03\x03\x00\x00\x00\x00\a\x17\x05\x01\xdb\x03\x0f\x03\x02\x06\x06\b\x03\x01\x04\x01\xea\x03\x0e\x03\b\v\x00\b\x12\x00\x01\x01\xf8\x03\v\x03\x05\b\x00\b \x02\x00\x83\x04\x03\x00\x00\x00\x00\b+\x05\x01\x86\x04\x0e\x03\x02\x06\x05\t\x03\x01\x04\x01\x94\x04\v\x03\x05\b\x00\t\x11\x00\x01\x01\x9f\x04\v\x03\x05\b\x00\t\x1c\x02\x00\xaa\x04\x04\x00\x00\x00\x00\t'\x03\x02\xae\x04\a\x00\x00\x03\x01\n\x04\x03\x02\x01x\x02\x00\xb5\x04\x02\x00\x00\x00\x00\n\v\x00\x01\x01\xb7\x04\f\x03\x06\t\x00\v\x01\x02\x00\xc3\x04\x01\x00\x00\x00\x00\v\r\x05\x01\xc4\x04\x17\x03\x03\a\r\f\x01\x02\x05\x01\xdb\x04\x15\x03\x03\a\v\f\x18\x05\x03\x02\xf0\x04\r\x00\x00\x03\a\f-\n\x03\x05title\x02\x01p\x02\x00\xfd\x04\x01\x00\x00\x00\x00\f:\x03\x02\xfe\x04\a\x00\x00\x03\x01\f;\x03\x02\x01t\x05\x01\x85\x05\x11\x03\x02\x06\b\fB\x01\x04\x01\x96\x05\v\x03\x05\b\x00\fS\x00\x01\x01\xa1\x05\v\x03\x05\b\x00\f^\x02\x00\xac\x05\x01\x00\x00\x00\x00\fi\x00\x01\x01\xad\x05\f\x03\x06\t\x00\fj\x02\x00\xb9\x05\x01\x00\x00\x00\x00\fv\x00\x01\x01\xba\x05\f\x03\x06\t\x00\fw\x02\x00\xc6\x05\x01\x00\x00\x00\x00\f\x83\x01\x05\x01\xc7\x05\x16\x03\x03\a\f\r\x01\x01\x04\x01\xdd\x05\x19\x03\x05\t\r\r\x17\x00\x01\x01\xf6\x05\f\x03\x06\t\x00\r0\x02\x00\x82\x06\x01\x00\x00\x00\x00\r<\x05\x01\x83\x06\x16\x03\x03\a\f\x0e\x01\x02\x05\x01\x99\x06\f\x03\x04\b\x01\x0e\x17\x00\x03\x05\x01\xa5\x06\f\x03\x04\b\x01\x0e#\x01\x04\x01\xb1\x06\x0e\x03\b\v\x00\x0e/\x00\x00\x05\x01\xbf\x06\f\x03\x04\b\x01\x0e=\x01\x02\x00\xcb\x06\x04\x00\x00\x00\x00\x0eI\x00\x00\x05\x01\xcf\x06\n\x03\x04\a\x00\x0eM\x01\x04\x01\xd9\x06\v\x03\x05\b\x00\x0eW\x00\x00\x01\x01\xe4\x06\r\x03\a\n\x00\x0eb\x03\x02\xf1\x06\a\x00\x00\x03\x01\x0eo\x03\x02\x01x\x00\x01\x01\xf8\x06\f\x03\x06\t\x00\x0ev\x02\x00\x84\a\x01\x00\x00\x00\x00\x0e\x82\x01\x05\x01\x85\a\x16\x03\x03\a\f\x0f\x01\x02\x05\x01\x9b\a\x0f\x03\a\v\x01\x0f\x17\x04\x02\x00\xaa\a\x01\x00\x00\x00\x00\x0f&\x03\x02\xab\a\a\x00\x00\x03\x01\x0f'\x03\x02\x01x\x02\x00\xb2\a\x01\x00\x00\x00\x00\x0f.\x05\x01\xb3\a\x0f\x03\x02\x06\x06\x0f/\x01\x04\x01\xc2\a\v\x03\x05\b\x00\x0f>\x00\x01\x01\xcd\a\v\x03\x05\b\x00\x0fI\x00\x01\x01\xd8\a\x10\x03\n\r\x00\x0fT\x03\x02\xe8\a\a\x00\x00\x03\x01\x0fd\x03\x02\x01c\x00\x01\x01\xef\a\f\x03\x06\t\x00\x0fk\x03\x02\xfb\a\a\x00\x00\x03\x01\x0fw\x03\x02\x01c\x02\x00\x82\b\x01\x00\x00\x00\x00\x0f~\x04\x01\x83\b\x18\x03\x06\n\v\x10\x01\x05\x01\x9b\b\x1f\x03\x03\a\x15\x10\x19\x01\x03\x02\xba\b\a\x00\x00\x03\x01\x108\x03\x02\x01x\x00\x01\x01\xc1\b\f\x03\x06\t\x00\x10?\x03\x02\xcd\b\a\x00\x00\x03\x01\x10K\x03\x02\x01x\x02\x00\xd4\b\x01\x00\x00\x00\x00\x10R\x03\x02\xd5\b\r\x00\x00\x03\a\x10S\t\x02\aforloop\x02\x00\xe2\b\x01\x00\x00\x00\x00\x10`\x05\x01\xe3\b\x16\x03\x03\a\f\x11\x01\x01\x05\x01\xf9\b\x0f\x03\x02\x06\x06\x11\x17\x02\x02\x00\x88\t\x02\x00\x00\x00\x00\x11&\x04\x01\x8a\t\v\x03\x05\b\x00\x11(\x01\x05\x01\x95\t\n\x03\x04\a\x00\x113\x02\x02\x00\x9f\t\x02\x00\x00\x00\x00\x11=\x04\x01\xa1\t\x0e\x03\b\v\x00\x11?\x00\x00\x01\x01\xaf\t\v\x03\x05\b\x00\x11M\x00\x01\x01\xba\t\f\x03\x06\t\x00\x11X\x02\x00\xc6\t\x01\x00\x00\x00\x00\x11d\x05\x01\xc7\t\x14\x03\x03\a\n\x12\x01\x01\x02\x00\xdb\t\x05\x00\x00\x00\x00\x12\x15\x01\x05\x01\xe0\t\n\x03\x04\a\x00\x12\x1a\x01\x02\x00\xea\t\x04\x00\x00\x00\x00\x12$\x00\x00\x01\x01\xee\t\f\x03\x06\t\x00\x12(\x02\x00\xfa\t\x01\x00\x00\x00\x00\x124\x05\x01\xfb\t\x16\x03\x03\a\f\x125\x01\x02\x00\x91\n\x05\x00\x00\x00\x00\x12K\x01\x05\x01\x96\n\n\x03\x04\a\x00\x12P\x01\x02\x00\xa0\n\x04\x00\x00\x00\x00\x12Z\x00\x00\x01\x01\xa4\n\f\x03\x06\t\x00\x12^\x02\x00\xb0\n\x01\x00\x00\x00\x00\x12j\x05\x01\xb1\n \x03\x03\a\x16\x12k\x01\x02\x00\xd1\n\x05\x00\x00\x00\x00\x12\x8b\x01\x01\x05\x01\xd6\n\n\x03\x04\a\x00\x12\x90\x01\x01\x02\x00\xe0\n\x04\x00\x00\x00\x00\x12\x9a\x01\x00\x00\x01\x01\xe4\n\f\x03\x06\t\x00\x12\x9e\x01\x02\x00\xf0\n\x01\x00\x00\x00\x00\x12\xaa\x01\x05\x01\xf1\n\x1f\x03\x03\a\x15\x12\xab\x01\x01\x03\x02\x90\v\a\x00\x00\x03\x01\x12\xca\x01\x03\x02\x01x\x01\x05\x01\x97\v\n\x03\x04\a\x00\x12\xd1\x01\x01\x02\x00\xa1\v\x05\x00\x00\x00\x00\x12\xdb\x01\x00\x00\x01\x01\xa6\v\f\x03\x06\t\x00\x12\xe0\x01\x02\x00\xb2\v\x01\x00\x00\x00\x00\x12\xec\x01\x05\x01\xb3\v\x16\x03\x03\a\f\x13\x01\x01\x05\x01\xc9\v\x14\x03\x03\a\n\x13\x17\x01\x03\x02\xdd\v\a\x00\x00\x03\x01\x13+\x03\x02\x01y\x01\x05\x01\xe4\v\n\x03\x04\a\x00\x132\x02\x05\x01\xee\v\x0f\x03\x02\x06\x06\x13<\x01\x04\x01\xfd\v\v\x03\x05\b\x00\x13K\x00\x01\x01\x88\f\v\x03\x05\b\x00\x13V\x03\x02\x93\f\a\x00\x00\x03\x01\x13a\x03\x02\x01x\x00\x00\x01\x01\x9a\f\f\x03\x06\t\x00\x13h\x00\x01\x01\xa6\f\f\x03\x06\t\x00\x13t\x02\x00\xb2\f\x01\x00\x00\x00\x00\x13\x80\x01"),
+	Fingerprint: "957d1759c343cb7786b04e2907c43e041e72240ebf11b616db43c25513a8c931",
+	Checksum:    0xebf01a2bf3e0bb4b,
+	Fragments:   []int{51, 108},
+	Render:      renderLoops,
+}
+
+var loopsKeys = [...]values.Value{
+	values.ValueOf("index"),
+	values.ValueOf("length"),
+	values.ValueOf("title"),
+	values.ValueOf("last"),
+	values.ValueOf("first"),
+	values.ValueOf("tags"),
+}
+
+var loopsLimit13 = 2
+
+var loopsLimit74 = 1
+
+var loopsLimit103 = 1
+
+func renderLoops(r *render.Runtime) {
+	r.At(1)
+	if it := tags.NewLoopIterator(r.Interface(r.Get("products")), false, 0, nil); it != nil {
+		v, f := r.GetDirect("p"), r.GetDirect("forloop")
+		for i := 0; i < it.Len(); i++ {
+			r.LoopIteration(1)
+			r.Set("p", it.Index(i))
+			r.Set("forloop", it.Forloop(i))
+			r.Push()
+			r.Tag(2, false)
+			r.Write(r.Property(r.Get("forloop"), loopsKeys[0]))
+			r.Text(3)
+			r.Tag(4, false)
+			r.Write(r.Property(r.Get("forloop"), loopsKeys[1]))
+			r.Text(5)
+			r.Tag(6, false)
+			r.Write(r.Property(r.Get("p"), loopsKeys[2]))
+			r.At(7)
+			if !(r.Truthy(r.Property(r.Get("forloop"), loopsKeys[3]))) {
+				r.Push()
+				r.Text(8)
+				r.Pop(7)
+			}
+			r.Pop(1)
+		}
+		r.Set("forloop", f)
+		r.Set("p", v)
+	}
+	r.Text(9)
+	r.At(10)
+	if it := tags.NewLoopIterator(r.Interface(r.Get("numbers")), true, 0, nil); it != nil {
+		v, f := r.GetDirect("x"), r.GetDirect("forloop")
+		for i := 0; i < it.Len(); i++ {
+			r.LoopIteration(10)
+			r.Set("x", it.Index(i))
+			r.Set("forloop", it.Forloop(i))
+			r.Push()
+			r.Tag(11, false)
+			r.Write(r.Get("x"))
+			r.Pop(10)
+		}
+		r.Set("forloop", f)
+		r.Set("x", v)
+	}
+	r.Text(12)
+	r.At(13)
+	if it := tags.NewLoopIterator(r.Interface(r.Get("numbers")), false, 1, &loopsLimit13); it != nil {
+		v, f := r.GetDirect("x"), r.GetDirect("forloop")
+		for i := 0; i < it.Len(); i++ {
+			r.LoopIteration(13)
+			r.Set("x", it.Index(i))
+			r.Set("forloop", it.Forloop(i))
+			r.Push()
+			r.Tag(14, false)
+			r.Write(r.Get("x"))
+			r.Pop(13)
+		}
+		r.Set("forloop", f)
+		r.Set("x", v)
+	}
+	r.Text(15)
+	r.At(16)
+	if it := tags.NewLoopIterator(r.Interface(r.Get("numbers")), false, 10, nil); it != nil {
+		v, f := r.GetDirect("x"), r.GetDirect("forloop")
+		for i := 0; i < it.Len(); i++ {
+			r.LoopIteration(16)
+			r.Set("x", it.Index(i))
+			r.Set("forloop", it.Forloop(i))
+			r.Push()
+			r.Tag(17, false)
+			r.Write(r.Get("x"))
+			r.Pop(16)
+		}
+		r.Set("forloop", f)
+		r.Set("x", v)
+	}
+	r.Text(18)
+	r.At(19)
+	if it := tags.NewLoopIterator(r.Interface(r.Range(r.Int(1), r.Int(r.Get("n")))), false, 0, nil); it != nil {
+		v, f := r.GetDirect("x"), r.GetDirect("forloop")
+		for i := 0; i < it.Len(); i++ {
+			r.LoopIteration(19)
+			r.Set("x", it.Index(i))
+			r.Set("forloop", it.Forloop(i))
+			r.Push()
+			r.Tag(20, false)
+			r.Write(r.Get("x"))
+			r.At(21)
+			if r.Truthy(r.Property(r.Get("forloop"), loopsKeys[4])) {
+				r.Push()
+				r.Text(22)
+				r.Pop(21)
+			}
+			r.Pop(19)
+		}
+		r.Set("forloop", f)
+		r.Set("x", v)
+	}
+	r.Text(23)
+	r.At(24)
+	if it := tags.NewLoopIterator(r.Interface(r.Get("empty")), false, 0, nil); it != nil {
+		v, f := r.GetDirect("x"), r.GetDirect("forloop")
+		for i := 0; i < it.Len(); i++ {
+			r.LoopIteration(24)
+			r.Set("x", it.Index(i))
+			r.Set("forloop", it.Forloop(i))
+			r.Push()
+			r.Text(25)
+			r.Pop(24)
+		}
+		r.Set("forloop", f)
+		r.Set("x", v)
+	}
+	r.At(26)
+	if it := tags.NewLoopIterator(r.Interface(r.Get("missing")), false, 0, nil); it != nil {
+		v, f := r.GetDirect("x"), r.GetDirect("forloop")
+		for i := 0; i < it.Len(); i++ {
+			r.LoopIteration(26)
+			r.Set("x", it.Index(i))
+			r.Set("forloop", it.Forloop(i))
+			r.Push()
+			r.Text(27)
+			r.Pop(26)
+		}
+		r.Set("forloop", f)
+		r.Set("x", v)
+	}
+	r.Text(28)
+	r.At(29)
+	if it := tags.NewLoopIterator(r.Interface(r.Get("numbers")), false, 0, nil); it != nil {
+		v, f := r.GetDirect("x"), r.GetDirect("forloop")
+		for i := 0; i < it.Len(); i++ {
+			r.LoopIteration(29)
+			r.Set("x", it.Index(i))
+			r.Set("forloop", it.Forloop(i))
+			r.Push()
+			r.Text(30)
+			r.At(31)
+			if r.Equal(r.Get("x"), 2) {
+				r.Push()
+				r.Tag(32, false)
+				r.Discard(2)
+				continue
+			}
+			r.Text(33)
+			r.At(34)
+			if r.Greater(r.Get("x"), 3) {
+				r.Push()
+				r.Tag(35, false)
+				r.Discard(2)
+				break
+			}
+			r.Text(36)
+			r.Tag(37, false)
+			r.Write(r.Get("x"))
+			r.Text(38)
+			r.Pop(29)
+		}
+		r.Set("forloop", f)
+		r.Set("x", v)
+	}
+	r.Text(39)
+	r.At(40)
+	if it := tags.NewLoopIterator(r.Interface(r.Get("products")), false, 0, nil); it != nil {
+		v, f := r.GetDirect("p"), r.GetDirect("forloop")
+		for i := 0; i < it.Len(); i++ {
+			r.LoopIteration(40)
+			r.Set("p", it.Index(i))
+			r.Set("forloop", it.Forloop(i))
+			r.Push()
+			r.At(41)
+			if it := tags.NewLoopIterator(r.Interface(r.Property(r.Get("p"), loopsKeys[5])), false, 0, nil); it != nil {
+				v, f := r.GetDirect("t"), r.GetDirect("forloop")
+				for i := 0; i < it.Len(); i++ {
+					r.LoopIteration(41)
+					r.Set("t", it.Index(i))
+					r.Set("forloop", it.Forloop(i))
+					r.Push()
+					r.Tag(42, false)
+					r.Write(r.Property(r.Get("p"), loopsKeys[2]))
+					r.Text(43)
+					r.Tag(44, false)
+					r.Write(r.Get("t"))
+					r.At(45)
+					if r.Equal(r.Get("t"), "b") {
+						r.Push()
+						r.Tag(46, false)
+						r.Discard(2)
+						break
+					}
+					r.Text(47)
+					r.Pop(41)
+				}
+				r.Set("forloop", f)
+				r.Set("t", v)
+			}
+			r.Text(48)
+			r.Pop(40)
+		}
+		r.Set("forloop", f)
+		r.Set("p", v)
+	}
+	r.Text(49)
+	r.At(50)
+	if it := tags.NewLoopIterator(r.Interface(r.Get("numbers")), false, 0, nil); it != nil {
+		v, f := r.GetDirect("x"), r.GetDirect("forloop")
+		for i := 0; i < it.Len(); i++ {
+			r.LoopIteration(50)
+			r.Set("x", it.Index(i))
+			r.Set("forloop", it.Forloop(i))
+			r.Push()
+			r.Fragment(51)
+			r.Pop(50)
+		}
+		r.Set("forloop", f)
+		r.Set("x", v)
+	}
+	r.Text(52)
+	r.At(53)
+	if it := tags.NewLoopIterator(r.Interface(r.Get("numbers")), false, 0, nil); it != nil {
+		v, f := r.GetDirect("x"), r.GetDirect("forloop")
+		for i := 0; i < it.Len(); i++ {
+			r.LoopIteration(53)
+			r.Set("x", it.Index(i))
+			r.Set("forloop", it.Forloop(i))
+			r.Push()
+			r.At(54)
+			{
+				sel := r.Interface(r.Get("x"))
+				if values.Equal(sel, r.Interface(1)) {
+					r.Push()
+					r.Tag(56, false)
+					r.Discard(2)
+					continue
+				} else if values.Equal(sel, r.Interface(2)) {
+					r.Push()
+					r.Text(58)
+					r.Pop(54)
+				} else {
+					r.Push()
+					r.Tag(60, false)
+					r.Discard(2)
+					break
+				}
+			}
+			r.Tag(61, false)
+			r.Write(r.Get("x"))
+			r.Pop(53)
+		}
+		r.Set("forloop", f)
+		r.Set("x", v)
+	}
+	r.Text(62)
+	r.At(63)
+	if it := tags.NewLoopIterator(r.Interface(r.Get("numbers")), false, 0, nil); it != nil {
+		v, f := r.GetDirect("x"), r.GetDirect("forloop")
+		for i := 0; i < it.Len(); i++ {
+			r.LoopIteration(63)
+			r.Set("x", it.Index(i))
+			r.Set("forloop", it.Forloop(i))
+			r.Push()
+			r.At(64)
+			r.BeginCapture()
+			r.Text(65)
+			r.Tag(66, false)
+			r.Write(r.Get("x"))
+			r.Text(67)
+			r.At(68)
+			if r.Equal(r.Get("x"), 2) {
+				r.Push()
+				r.Tag(69, false)
+				r.Discard(3)
+				break
+			}
+			r.EndCapture(64, "c")
+			r.Tag(70, false)
+			r.Write(r.Get("c"))
+			r.Pop(63)
+		}
+		r.Set("forloop", f)
+		r.Set("x", v)
+	}
+	r.Tag(71, false)
+	r.Write(r.Get("c"))
+	r.Text(72)
+	r.Tag(73, false)
+	r.Assign("x", "outer")
+	r.At(74)
+	if it := tags.NewLoopIterator(r.Interface(r.Get("numbers")), false, 0, &loopsLimit74); it != nil {
+		v, f := r.GetDirect("x"), r.GetDirect("forloop")
+		for i := 0; i < it.Len(); i++ {
+			r.LoopIteration(74)
+			r.Set("x", it.Index(i))
+			r.Set("forloop", it.Forloop(i))
+			r.Push()
+			r.Tag(75, false)
+			r.Write(r.Get("x"))
+			r.Pop(74)
+		}
+		r.Set("forloop", f)
+		r.Set("x", v)
+	}
+	r.Tag(76, false)
+	r.Write(r.Get("x"))
+	r.Text(77)
+	r.Tag(78, false)
+	r.Write(r.Get("forloop"))
+	r.Text(79)
+	r.At(80)
+	if it := tags.NewLoopIterator(r.Interface(r.Get("numbers")), false, 0, nil); it != nil {
+		v, f := r.GetDirect("x"), r.GetDirect("forloop")
+		for i := 0; i < it.Len(); i++ {
+			r.LoopIteration(80)
+			r.Set("x", it.Index(i))
+			r.Set("forloop", it.Forloop(i))
+			r.Push()
+			r.At(81)
+			if r.Equal(r.Get("x"), 1) {
+				r.Push()
+				r.Text(82)
+				r.Tag(83, false)
+				r.Discard(2)
+				break
+			} else {
+				r.Push()
+				r.Text(85)
+				r.Tag(86, false)
+				r.Discard(2)
+				continue
+			}
+		}
+		r.Set("forloop", f)
+		r.Set("x", v)
+	}
+	r.Text(87)
+	r.At(88)
+	if it := tags.NewLoopIterator(r.Interface(r.Get("empty")), false, 0, nil); it != nil && it.Len() > 0 {
+		v, f := r.GetDirect("x"), r.GetDirect("forloop")
+		for i := 0; i < it.Len(); i++ {
+			r.LoopIteration(88)
+			r.Set("x", it.Index(i))
+			r.Set("forloop", it.Forloop(i))
+			r.Push()
+			r.Text(89)
+			r.Pop(88)
+		}
+		r.Set("forloop", f)
+		r.Set("x", v)
+	} else {
+		r.Push()
+		r.Text(91)
+		r.Pop(88)
+	}
+	r.Text(92)
+	r.At(93)
+	if it := tags.NewLoopIterator(r.Interface(r.Get("missing")), false, 0, nil); it != nil && it.Len() > 0 {
+		v, f := r.GetDirect("x"), r.GetDirect("forloop")
+		for i := 0; i < it.Len(); i++ {
+			r.LoopIteration(93)
+			r.Set("x", it.Index(i))
+			r.Set("forloop", it.Forloop(i))
+			r.Push()
+			r.Text(94)
+			r.Pop(93)
+		}
+		r.Set("forloop", f)
+		r.Set("x", v)
+	} else {
+		r.Push()
+		r.Text(96)
+		r.Pop(93)
+	}
+	r.Text(97)
+	r.At(98)
+	if it := tags.NewLoopIterator(r.Interface(r.Get("numbers")), false, 5, nil); it != nil && it.Len() > 0 {
+		v, f := r.GetDirect("x"), r.GetDirect("forloop")
+		for i := 0; i < it.Len(); i++ {
+			r.LoopIteration(98)
+			r.Set("x", it.Index(i))
+			r.Set("forloop", it.Forloop(i))
+			r.Push()
+			r.Text(99)
+			r.Pop(98)
+		}
+		r.Set("forloop", f)
+		r.Set("x", v)
+	} else {
+		r.Push()
+		r.Text(101)
+		r.Pop(98)
+	}
+	r.Text(102)
+	r.At(103)
+	if it := tags.NewLoopIterator(r.Interface(r.Get("numbers")), false, 0, &loopsLimit103); it != nil && it.Len() > 0 {
+		v, f := r.GetDirect("x"), r.GetDirect("forloop")
+		for i := 0; i < it.Len(); i++ {
+			r.LoopIteration(103)
+			r.Set("x", it.Index(i))
+			r.Set("forloop", it.Forloop(i))
+			r.Push()
+			r.Tag(104, false)
+			r.Write(r.Get("x"))
+			r.Pop(103)
+		}
+		r.Set("forloop", f)
+		r.Set("x", v)
+	} else {
+		r.Push()
+		r.Text(106)
+		r.Pop(103)
+	}
+	r.Text(107)
+	r.Fragment(108)
+	r.Text(115)
+}
+
+// Objects is the template "testdata/objects.liquid", compiled to Go.
+var Objects = &render.GoTemplate{
+	Path: "testdata/objects.liquid",
+	Source: `<h1>{{ page.title | upcase }}</h1>
+{{ "Hello, " | append: user.name | append: "!" }}
+{{ user.name | default: "anonymous" }} {{ missing | default: "none" }}
+{{ products[0].title }} {{ products.size }} {{ products.first.title }} {{ products[1]["price"] }}
+{{ 1 }} {{ 2.0 }} {{ 2.5 | times: 2 }} {{ true }} {{ nil }} {{ -3 | abs }}
+{{ "a,b,c" | split: "," | join: " + " }}
+{{ numbers | sort | reverse | join: ", " }}
+{{ html }} {{ html | escape }} {{ 'single "quoted"' }}
+{{ products | map: "title" | join: ", " }}
+{{ user.tags | first }}
+{{ list[0] }} {{ list[-1] }} {{ list[9] }} {{ list[1.5] }} {{ list.size }} {{ list.first }} {{ page["title"] }} {{ page[1] }} {{ page.missing }}
+{% if list contains "two" %}two{% endif %} {% if list[2] > 3 and list[0] == 1.0 %}compared{% endif %} {% if list[1] < 3 %}never{% endif %}
+`,
+	AST:         []byte("\x17testdata/objects.liquid\x01C\x02\x00\x00\x04\x00\x00\x00\x00\x01\x01\x03\x02\x04\x19\x00\x00\x03\x13\x01\x05\x16\x05\x06upcase\x00\x03\x05title\x02\x04page\x02\x00\x1d\x06\x00\x00\x00\x00\x01\x1e\x03\x02#1\x00\x00\x03+\x02\x01,\x05\x06append\x01\x05\x06append\x01\x01\x05\aHello, \x03\x04name\x02\x04user\x01\x05\x01!\x02\x00T\x01\x00\x00\x00\x00\x022\x03\x02U&\x00\x00\x03 \x03\x01\"\x05\adefault\x01\x03\x04name\x02\x04user\x01\x05\tanonymous\x02\x00{\x01\x00\x00\x00\x00\x03'\x03\x02|\x1f\x00\x00\x03\x19\x03(\x1a\x05\adefault\x01\x02\amissing\x01\x05\x04none\x02\x00\x9b\x01\x01\x00\x00\x00\x00\x03G\x03\x02\x9c\x01\x17\x00\x00\x03\x11\x04\x01\x15\x03\x05title\x04\x02\bproducts\x01\x03\x00\x02\x00\xb3\x01\x01\x00\x00\x00\x00\x04\x18\x03\x02\xb4\x01\x13\x00\x00\x03\r\x04\x19\x10\x03\x04size\x02\bproducts\x02\x00\xc7\x01\x01\x00\x00\x00\x00\x04,\x03\x02\xc8\x01\x1a\x00\x00\x03\x14\x04-\x18\x03\x05title\x03\x05first\x02\bproducts\x02\x00\xe2\x01\x01\x00\x00\x00\x00\x04G\x03\x02\xe3\x01\x1a\x00\x00\x03\x14\x04H\x17\x04\x04\x02\bproducts\x01\x03\x02\x01\x05\x05price\x02\x00\xfd\x01\x01\x00\x00\x00\x00\x04b\x03\x02\xfe\x01\a\x00\x00\x03\x01\x05\x01\x03\x01\x03\x02\x02\x00\x85\x02\x01\x00\x00\x00\x00\x05\b\x03\x02\x86\x02\t\x00\x00\x03\x03\x05\t\n\x01\x04@\x00\x00\x00\x00\x00\x00\x00\x02\x00\x8f\x02\x01\x00\x00\x00\x00\x05\x12\x03\x02\x90\x02\x14\x00\x00\x03\x0e\x05\x13\x15\x05\x05times\x01\x01\x04@\x04\x00\x00\x00\x00\x00\x00\x01\x03\x04\x02\x00\xa4\x02\x01\x00\x00\x00\x00\x05'\x03\x02\xa5\x02\n\x00\x00\x03\x04\x05(\x02\x01\x02\x02\x00\xaf\x02\x01\x00\x00\x00\x00\x052\x03\x02\xb0\x02\t\x00\x00\x03\x03\x053\x02\x01\x00\x02\x00\xb9\x02\x01\x00\x00\x00\x00\x05<\x03\x02\xba\x02\x0e\x00\x00\x03\b\x05=\t\x05\x03abs\x00\x01\x03\x05\x02\x00\xc8\x02\x01\x00\x00\x00\x00\x05K\x03\x02\xc9\x02(\x00\x00\x03\"\x06\x01!\x05\x04join\x01\x05\x05split\x01\x01\x05\x05a,b,c\x01\x05\x01,\x01\x05\x03 + \x02\x00\xf1\x02\x01\x00\x00\x00\x00\x06)\x03\x02\xf2\x02+\x00\x00\x03%\a\x01&\x05\x04join\x01\x05\areverse\x00\x05\x04sort\x00\x02\anumbers\x01\x05\x02, \x02\x00\x9d\x03\x01\x00\x00\x00\x00\a,\x03\x02\x9e\x03\n\x00\x00\x03\x04\b\x01\x06\x02\x04html\x02\x00\xa8\x03\x01\x00\x00\x00\x00\b\v\x03\x02\xa9\x03\x13\x00\x00\x03\r\b\f\x0f\x05\x06escape\x00\x02\x04html\x02\x00\xbc\x03\x01\x00\x00\x00\x00\b\x1f\x03\x02\xbd\x03\x17\x00\x00\x03\x11\b \x12\x01\x05\x0fsingle \"quoted\"\x02\x00\xd4\x03\x01\x00\x00\x00\x00\b7\x03\x02\xd5\x03*\x00\x00\x03$\t\x01$\x05\x04join\x01\x05\x03map\x01\x02\bproducts\x01\x05\x05title\x01\x05\x02, \x02\x00\xff\x03\x01\x00\x00\x00\x00\t+\x03\x02\x80\x04\x17\x00\x00\x03\x11\n\x01\x14\x05\x05first\x00\x03\x04tags\x02\x04user\x02\x00\x97\x04\x01\x00\x00\x00\x00\n\x18\x03\x02\x98\x04\r\x00\x00\x03\a\v\x01\n\x04\x02\x04list\x01\x03\x00\x02\x00\xa5\x04\x01\x00\x00\x00\x00\v\x0e\x03\x02\xa6\x04\x0e\x00\x00\x03\b\v\x0f\n\x04\x02\x04list\x01\x03\x01\x02\x00\xb4\x04\x01\x00\x00\x00\x00\v\x1d\x03\x02\xb5\x04\r\x00\x00\x03\a\v\x1e\n\x04\x02\x04list\x01\x03\x12\x02\x00\xc2\x04\x01\x00\x00\x00\x00\v+\x03\x02\xc3\x04\x0f\x00\x00\x03\t\v,\x11\x04\x02\x04list\x01\x04?\xf8\x00\x00\x00\x00\x00\x00\x02\x00\xd2\x04\x01\x00\x00\x00\x00\v;\x03\x02\xd3\x04\x0f\x00\x00\x03\t\v<\f\x03\x04size\x02\x04list\x02\x00\xe2\x04\x01\x00\x00\x00\x00\vK\x03\x02\xe3\x04\x10\x00\x00\x03\n\vL\r\x03\x05first\x02\x04list\x02\x00\xf3\x04\x01\x00\x00\x00\x00\v\\\x03\x02\xf4\x04\x13\x00\x00\x03\r\v]\x0f\x04\x02\x04page\x01\x05\x05title\x02\x00\x87\x05\x01\x00\x00\x00\x00\vp\x03\x02\x88\x05\r\x00\x00\x03\a\vq\n\x04\x02\x04page\x01\x03\x02\x02\x00\x95\x05\x01\x00\x00\x00\x00\v~\x03\x02\x96\x05\x12\x00\x00\x03\f\v\x7f\x0f\x03\amissing\x02\x04page\x02\x00\xa8\x05\x01\x00\x00\x00\x00\v\x91\x01\x05\x01\xa9\x05\x1c\x03\x02\x06\x13\f\x01\x01\x02\x00\xc5\x05\x03\x00\x00\x00\x00\f\x1d\x00\x01\x01\xc8\x05\v\x03\x05\b\x00\f \x02\x00\xd3\x05\x01\x00\x00\x00\x00\f+\x05\x01\xd4\x05'\x03\x02\x06\x1e\f,\x01\x02\x00\xfb\x05\b\x00\x00\x00\x00\fS\x00\x01\x01\x83\x06\v\x03\x05\b\x00\f[\x02\x00\x8e\x06\x01\x00\x00\x00\x00\ff\x05\x01\x8f\x06\x14\x03\x02\x06\v\fg\x01\x02\x00\xa3\x06\x05\x00\x00\x00\x00\f{\x00\x01\x01\xa8\x06\v\x03\x05\b\x00\f\x80\x01\x02\x00\xb3\x06\x01\x00\x00\x00\x00\f\x8b\x01"),
+	Fingerprint: "957d1759c343cb7786b04e2907c43e041e72240ebf11b616db43c25513a8c931",
+	Checksum:    0x1766bd6c47623283,
+	Render:      renderObjects,
+}
+
+var objectsKeys = [...]values.Value{
+	values.ValueOf("title"),
+	values.ValueOf("name"),
+	values.ValueOf("size"),
+	values.ValueOf("first"),
+	values.ValueOf("tags"),
+	values.ValueOf("missing"),
+}
+
+func renderObjects(r *render.Runtime) {
+	r.Text(1)
+	r.Tag(2, false)
+	r.Write(r.Apply(r.Filter("upcase"), r.Property(r.Get("page"), objectsKeys[0])))
+	r.Text(3)
+	r.Tag(4, false)
+	r.Write(r.Apply(r.Filter("append"), r.Apply(r.Filter("append"), "Hello, ", r.Property(r.Get("user"), objectsKeys[1])), "!"))
+	r.Text(5)
+	r.Tag(6, false)
+	r.Write(r.Apply(r.Filter("default"), r.Property(r.Get("user"), objectsKeys[1]), "anonymous"))
+	r.Text(7)
+	r.Tag(8, false)
+	r.Write(r.Apply(r.Filter("default"), r.Get("missing"), "none"))
+	r.Text(9)
+	r.Tag(10, false)
+	r.Write(r.Property(r.Index(r.Get("products"), 0), objectsKeys[0]))
+	r.Text(11)
+	r.Tag(12, false)
+	r.Write(r.Property(r.Get("products"), objectsKeys[2]))
+	r.Text(13)
+	r.Tag(14, false)
+	r.Write(r.Property(r.Property(r.Get("products"), objectsKeys[3]), objectsKeys[0]))
+	r.Text(15)
+	r.Tag(16, false)
+	r.Write(r.Index(r.Index(r.Get("products"), 1), "price"))
+	r.Text(17)
+	r.Tag(18, false)
+	r.Write(`1`)
+	r.Text(19)
+	r.Tag(20, false)
+	r.Write(`2`)
+	r.Text(21)
+	r.Tag(22, false)
+	r.Write(`5`)
+	r.Text(23)
+	r.Tag(24, false)
+	r.Write(`true`)
+	r.Text(25)
+	r.Tag(26, false)
+	r.Text(27)
+	r.Tag(28, false)
+	r.Write(r.Apply(r.Filter("abs"), -3))
+	r.Text(29)
+	r.Tag(30, false)
+	r.Write(`a + b + c`)
+	r.Text(31)
+	r.Tag(32, false)
+	r.Write(r.Apply(r.Filter("join"), r.Apply(r.Filter("reverse"), r.Apply(r.Filter("sort"), r.Get("numbers"))), ", "))
+	r.Text(33)
+	r.Tag(34, false)
+	r.Write(r.Get("html"))
+	r.Text(35)
+	r.Tag(36, false)
+	r.Write(r.Apply(r.Filter("escape"), r.Get("html")))
+	r.Text(37)
+	r.Tag(38, false)
+	r.Write(`single "quoted"`)
+	r.Text(39)
+	r.Tag(40, false)
+	r.Write(r.Apply(r.Filter("join"), r.Apply(r.Filter("map"), r.Get("products"), "title"), ", "))
+	r.Text(41)
+	r.Tag(42, false)
+	r.Write(r.Apply(r.Filter("first"), r.Property(r.Get("user"), objectsKeys[4])))
+	r.Text(43)
+	r.Tag(44, false)
+	r.Write(r.Index(r.Get("list"), 0))
+	r.Text(45)
+	r.Tag(46, false)
+	r.Write(r.Index(r.Get("list"), -1))
+	r.Text(47)
+	r.Tag(48, false)
+	r.Write(r.Index(r.Get("list"), 9))
+	r.Text(49)
+	r.Tag(50, false)
+	r.Write(r.Index(r.Get("list"), 1.5))
+	r.Text(51)
+	r.Tag(52, false)
+	r.Write(r.Property(r.Get("list"), objectsKeys[2]))
+	r.Text(53)
+	r.Tag(54, false)
+	r.Write(r.Property(r.Get("list"), objectsKeys[3]))
+	r.Text(55)
+	r.Tag(56, false)
+	r.Write(r.Index(r.Get("page"), "title"))
+	r.Text(57)
+	r.Tag(58, false)
+	r.Write(r.Index(r.Get("page"), 1))
+	r.Text(59)
+	r.Tag(60, false)
+	r.Write(r.Property(r.Get("page"), objectsKeys[5]))
+	r.Text(61)
+	r.At(62)
+	if r.Contains(r.Get("list"), "two") {
+		r.Push()
+		r.Text(63)
+		r.Pop(62)
+	}
+	r.Text(64)
+	r.At(65)
+	if (r.Greater(r.Index(r.Get("list"), 2), 3)) && (r.Equal(r.Index(r.Get("list"), 0), 1.0)) {
+		r.Push()
+		r.Text(66)
+		r.Pop(65)
+	}
+	r.Text(67)
+	r.At(68)
+	if r.Less(r.Index(r.Get("list"), 1), 3) {
+		r.Push()
+		r.Text(69)
+		r.Pop(68)
+	}
+	r.Text(70)
+}
+
+// Whitespace is the template "testdata/whitespace.liquid", compiled to Go.
+var Whitespace = &render.GoTemplate{
+	Path: "testdata/whitespace.liquid",
+	Source: `<ul>
+  {%- for p in products %}
+    <li>{{- p.title -}}</li>
+  {%- endfor %}
+</ul>
+{%- if user.admin -%}
+  admin
+{%- else -%}
+  user
+{%- endif -%}
+  {{- "  trimmed  " -}}  
+{% assign a = 1 -%}    after assign
+   {%- assign b = 2 %}
+{{ n -}} {% raw %} {{ raw }} {% endraw %}
+{{ n -}}
+{% comment %} ignored {{ x }} {% endcomment %}
+text  {%- comment %}c{% endcomment -%}  text
+`,
+	AST:         []byte("\x1atestdata/whitespace.liquid\x01\x15\x02\x00\x00\a\x00\x00\x00\x00\x01\x01\x05\x05\a\x18\x04\x03\b\r\x02\x03\x03\x02\x00\x1f\t\x00\x00\x00\x00\x02\x1b\x03\x0e(\x0f\x00\x00\x04\a\x03\t\n\x03\x05title\x02\x01p\x02\x007\b\x00\x00\x00\x00\x03\x18\x00\x01\x05?\r\x04\x06\n\x00\x04\x03\x02\x00L\a\x00\x00\x00\x00\x04\x10\x05\rS\x15\x04\x02\a\n\x06\x01\x01\x02\x00h\t\x00\x00\x00\x00\x06\x16\x01\x05\rq\f\x04\x04\b\x00\b\x01\x01\x02\x00}\b\x00\x00\x00\x00\b\r\x00\x00\x01\r\x85\x01\r\x04\x05\t\x00\n\x01\x02\x00\x92\x01\x03\x00\x00\x00\x00\n\x0e\x03\x0e\x95\x01\x15\x00\x00\x04\r\v\x03\x0e\x01\x05\v  trimmed  \x02\x00\xaa\x01\x03\x00\x00\x00\x00\v\x18\x04\t\xad\x01\x13\x03\x06\n\x05\f\x01\x02\x00\xc0\x01\x14\x00\x00\x00\x00\f\x14\x04\x05\xd4\x01\x13\x04\x06\v\x05\r\x04\x02\x00\xe7\x01\x01\x00\x00\x00\x00\r\x17\x03\n\xe8\x01\b\x00\x00\x03\x01\x0e\x01\x03\x02\x01n\x02\x00\xf0\x01\x01\x00\x00\x00\x00\x0e\t\x06\x01\xf1\x01\t\x03\x03\x06\x00\x0e\n\x03\xfa\x01\x01\xfb\x01\t\x84\x02\x01\x01\x01\x85\x02\f\x03\x06\t\x00\x0e\x1e\x02\x00\x91\x02\x01\x00\x00\x00\x00\x0e*\x03\n\x92\x02\b\x00\x00\x03\x01\x0f\x01\x03\x02\x01n\x02\x00\x9a\x02\x01\x00\x00\x00\x00\x0f\t\a\x01\x9b\x02\r\x03\a\n\x00\x10\x01\x03\xa8\x02\t\xb1\x02\a\xb8\x02\x01\x01\x01\xb9\x02\x10\x03\n\r\x00\x10\x1f\x02\x00\xc9\x02\a\x00\x00\x00\x00\x10/\a\x05\xd0\x02\x0e\x04\a\v\x00\x11\a\x01\xde\x02\x01\x01\t\xdf\x02\x11\x03\n\r\x00\x11\x16\x02\x00\xf0\x02\a\x00\x00\x00\x00\x11'"),
+	Fingerprint: "957d1759c343cb7786b04e2907c43e041e72240ebf11b616db43c25513a8c931",
+	Checksum:    0x7f2dae3b567f86c9,
+	Render:      renderWhitespace,
+}
+
+var whitespaceKeys = [...]values.Value{
+	values.ValueOf("title"),
+	values.ValueOf("admin"),
+}
+
+func renderWhitespace(r *render.Runtime) {
+	r.Text(1)
+	r.At(2)
+	if it := tags.NewLoopIterator(r.Interface(r.Get("products")), false, 0, nil); it != nil {
+		v, f := r.GetDirect("p"), r.GetDirect("forloop")
+		for i := 0; i < it.Len(); i++ {
+			r.LoopIteration(2)
+			r.Set("p", it.Index(i))
+			r.Set("forloop", it.Forloop(i))
+			r.Push()
+			r.Text(3)
+			r.Tag(4, true)
+			r.Write(r.Property(r.Get("p"), whitespaceKeys[0]))
+			r.TrimRight()
+			r.Text(5)
+			r.Pop(2)
+		}
+		r.Set("forloop", f)
+		r.Set("p", v)
+	}
+	r.Text(6)
+	r.At(7)
+	if r.Truthy(r.Property(r.Get("user"), whitespaceKeys[1])) {
+		r.Push()
+		r.Text(8)
+		r.Pop(7)
+	} else {
+		r.Push()
+		r.Text(10)
+		r.Pop(7)
+	}
+	r.Text(11)
+	r.Tag(12, true)
+	r.Write(`  trimmed  `)
+	r.TrimRight()
+	r.Text(13)
+	r.Tag(14, false)
+	r.Assign("a", 1)
+	r.TrimRight()
+	r.Text(15)
+	r.Tag(16, true)
+	r.Assign("b", 2)
+	r.Text(17)
+	r.Tag(18, false)
+	r.Write(r.Get("n"))
+	r.TrimRight()
+	r.Text(19)
+	r.Raw(20)
+	r.Text(21)
+	r.Tag(22, false)
+	r.Write(r.Get("n"))
+	r.TrimRight()
+	r.Text(23)
+	r.Text(25)
+	r.Text(27)
+}
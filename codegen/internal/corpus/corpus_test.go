@@ -0,0 +1,195 @@
+package corpus_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/etecs-ru/liquid/v2"
+	"github.com/etecs-ru/liquid/v2/codegen"
+	"github.com/etecs-ru/liquid/v2/codegen/internal/corpus"
+	"github.com/etecs-ru/liquid/v2/render"
+	"github.com/stretchr/testify/require"
+)
+
+func corpusBindings() map[string]interface{} {
+	return map[string]interface{}{
+		"page": map[string]interface{}{"title": "Corpus"},
+		"user": map[string]interface{}{
+			"name":  "Ann",
+			"admin": false,
+			"tags":  []string{"a", "b"},
+		},
+		"products": []map[string]interface{}{
+			{"title": "Apple", "price": 3, "tags": []string{"fruit"}},
+			{"title": "Banana", "price": 1.5, "tags": []string{}},
+			{"title": "Cherry", "price": 10},
+		},
+		"numbers": []int{3, 1, 4, 2, 5},
+		"list":    []interface{}{1, "two", 3.5},
+		"empty":   []int{},
+		"n":       3,
+		"html":    "<b>&</b>",
+	}
+}
+
+func corpusTemplates(t testing.TB) []codegen.Template {
+	paths, err := filepath.Glob("testdata/*.liquid")
+	require.NoError(t, err)
+	require.NotEmpty(t, paths)
+	var templates []codegen.Template
+	for _, path := range paths {
+		source, err := ioutil.ReadFile(path)
+		require.NoError(t, err)
+		templates = append(templates, codegen.Template{Name: codegen.VarName(path), Path: path, Source: string(source)})
+	}
+	return templates
+}
+
+func TestCorpus_generated(t *testing.T) {
+	out, err := liquid.NewEngine().GenerateGo(codegen.Options{Package: "corpus", Map: "Templates"}, corpusTemplates(t)...)
+	require.NoError(t, err)
+	generated, err := ioutil.ReadFile("corpus.go")
+	require.NoError(t, err)
+	require.Equal(t, string(out), string(generated), "corpus.go is stale; run go generate")
+}
+
+func TestCorpus(t *testing.T) {
+	engines := map[string]func() *liquid.Engine{
+		"default":    liquid.NewEngine,
+		"autoescape": func() *liquid.Engine { return liquid.NewEngine().AutoEscape() },
+	}
+	for name, newEngine := range engines {
+		engine := newEngine()
+		for _, ct := range corpusTemplates(t) {
+			interpreted, err := engine.ParseTemplateLocation([]byte(ct.Source), ct.Path, 1)
+			require.NoError(t, err, ct.Path)
+			compiled, err := engine.LoadGoTemplate(corpus.Templates[ct.Path])
+			require.NoError(t, err, ct.Path)
+
+			expected, experr := interpreted.Render(corpusBindings())
+			actual, err := compiled.Render(corpusBindings())
+			require.Equal(t, experr, err, "%s %s", name, ct.Path)
+			require.Equal(t, string(expected), string(actual), "%s %s", name, ct.Path)
+		}
+	}
+}
+
+func TestCorpus_errors(t *testing.T) {
+	engine := liquid.NewEngine().StrictVariables()
+	errors := 0
+	for _, ct := range corpusTemplates(t) {
+		interpreted, err := engine.ParseTemplateLocation([]byte(ct.Source), ct.Path, 1)
+		require.NoError(t, err, ct.Path)
+		compiled, err := engine.LoadGoTemplate(corpus.Templates[ct.Path])
+		require.NoError(t, err, ct.Path)
+
+		_, experr := interpreted.Render(corpusBindings())
+		_, err = compiled.Render(corpusBindings())
+		if experr == nil {
+			require.NoError(t, err, ct.Path)
+			continue
+		}
+		errors++
+		require.Error(t, err, ct.Path)
+		require.Equal(t, experr.Error(), err.Error(), ct.Path)
+		require.Equal(t, experr.LineNumber(), err.LineNumber(), ct.Path)
+	}
+	require.NotZero(t, errors)
+}
+
+func TestCorpus_limits(t *testing.T) {
+	engine := liquid.NewEngine()
+	limits := render.Limits{MaxCaptureBytes: 3}
+	errors := 0
+	for _, ct := range corpusTemplates(t) {
+		interpreted, err := engine.ParseTemplateLocation([]byte(ct.Source), ct.Path, 1)
+		require.NoError(t, err, ct.Path)
+		compiled, err := engine.LoadGoTemplate(corpus.Templates[ct.Path])
+		require.NoError(t, err, ct.Path)
+
+		_, experr := interpreted.WithLimits(limits).Render(corpusBindings())
+		_, err = compiled.WithLimits(limits).Render(corpusBindings())
+		if experr == nil {
+			require.NoError(t, err, ct.Path)
+			continue
+		}
+		errors++
+		require.Error(t, err, ct.Path)
+		require.Equal(t, experr.Error(), err.Error(), ct.Path)
+		require.IsType(t, &render.LimitError{}, err.Cause(), ct.Path)
+	}
+	require.NotZero(t, errors)
+}
+
+// BenchmarkCorpus_load compares parsing the corpus with loading it from Template.MarshalBinary,
+// and from its generated code.
+func BenchmarkCorpus_load(b *testing.B) {
+	engine := liquid.NewEngine()
+	templates := corpusTemplates(b)
+	data := make([][]byte, len(templates))
+	for i, ct := range templates {
+		tpl, err := engine.ParseTemplateLocation([]byte(ct.Source), ct.Path, 1)
+		require.NoError(b, err, ct.Path)
+		var merr error
+		data[i], merr = tpl.MarshalBinary()
+		require.NoError(b, merr, ct.Path)
+	}
+	b.Run("parse", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			for _, ct := range templates {
+				if _, err := engine.ParseTemplateLocation([]byte(ct.Source), ct.Path, 1); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+	b.Run("compiled", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			for j, ct := range templates {
+				if _, err := engine.LoadCompiled(data[j], []byte(ct.Source)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+	b.Run("generated", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			for _, ct := range templates {
+				if _, err := engine.LoadGoTemplate(corpus.Templates[ct.Path]); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+}
+
+// BenchmarkCorpus_render compares rendering each template with the interpreter and with the
+// generated code.
+func BenchmarkCorpus_render(b *testing.B) {
+	engine := liquid.NewEngine()
+	bindings := corpusBindings()
+	for _, ct := range corpusTemplates(b) {
+		interpreted, err := engine.ParseTemplateLocation([]byte(ct.Source), ct.Path, 1)
+		require.NoError(b, err, ct.Path)
+		compiled, err := engine.LoadGoTemplate(corpus.Templates[ct.Path])
+		require.NoError(b, err, ct.Path)
+		for _, bm := range []struct {
+			name string
+			tpl  *liquid.Template
+		}{{"interpreted", interpreted}, {"generated", compiled}} {
+			tpl := bm.tpl
+			b.Run(filepath.Base(ct.Path)+"/"+bm.name, func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					if err := tpl.FRender(ioutil.Discard, bindings); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,5 @@
+// Package corpus is the code that is generated for the templates in testdata. Its test renders
+// each template with the interpreter and with the generated code, and compares the output.
+package corpus
+
+//go:generate go run ../../../cmd/liquid compile -pkg corpus -map Templates -o corpus.go testdata/assign.liquid testdata/control.liquid testdata/fallback.liquid testdata/loops.liquid testdata/objects.liquid testdata/whitespace.liquid
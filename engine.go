@@ -1,10 +1,16 @@
 package liquid
 
 import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
 	"io"
+	"sync"
 
+	"github.com/etecs-ru/liquid/v2/codegen"
 	"github.com/etecs-ru/liquid/v2/expressions"
 	"github.com/etecs-ru/liquid/v2/filters"
+	"github.com/etecs-ru/liquid/v2/parser"
 	"github.com/etecs-ru/liquid/v2/render"
 	"github.com/etecs-ru/liquid/v2/tags"
 )
@@ -12,25 +18,73 @@ import (
 // An Engine parses template source into renderable text.
 //
 // An engine can be configured with additional filters and tags.
-type Engine struct{ cfg render.Config }
+//
+// An Engine is safe for concurrent use, and so are the templates that it parses.
+// A template renders with the configuration that the engine had when the template was parsed.
+// Filters and tags that are registered later, and other changes to the configuration, apply only
+// to templates that are parsed afterwards. Use Freeze to reject such changes instead.
+type Engine struct {
+	mu     sync.Mutex
+	cfg    *render.Config
+	shared bool // templates refer to cfg, so a change must be made to a copy
+	frozen bool
+}
 
 // NewEngine returns a new Engine.
 func NewEngine() *Engine {
-	e := Engine{render.NewConfig()}
-	filters.AddStandardFilters(&e.cfg)
-	tags.AddStandardTags(e.cfg)
-	return &e
+	cfg := render.NewConfig()
+	filters.AddStandardFilters(&cfg)
+	tags.AddStandardTags(cfg)
+	return &Engine{cfg: &cfg}
+}
+
+// config returns the current configuration. The caller must not modify it.
+func (e *Engine) config() *render.Config {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if !e.shared {
+		// Once it's shared, the configuration doesn't change; update changes a copy.
+		e.cfg.CacheFingerprint()
+		e.shared = true
+	}
+	return e.cfg
+}
+
+// update applies fn to the configuration. If templates refer to the current configuration,
+// fn is applied to a copy, which replaces it.
+func (e *Engine) update(fn func(*render.Config)) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.frozen {
+		panic("liquid: the engine configuration is frozen")
+	}
+	if e.shared {
+		cfg := e.cfg.Clone()
+		e.cfg, e.shared = &cfg, false
+	}
+	fn(e.cfg)
+}
+
+// Freeze prevents further changes to the engine's configuration. After Freeze, the Register
+// methods and the methods that configure the engine panic.
+func (e *Engine) Freeze() *Engine {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.frozen = true
+	return e
 }
 
 // RegisterBlock defines a block e.g. {% tag %}…{% endtag %}.
 func (e *Engine) RegisterBlock(name string, td Renderer) {
-	e.cfg.AddBlock(name).Renderer(func(w io.Writer, ctx render.Context) error {
-		s, err := td(ctx)
-		if err != nil {
+	e.update(func(cfg *render.Config) {
+		cfg.AddBlock(name).Renderer(func(w io.Writer, ctx render.Context) error {
+			s, err := td(ctx)
+			if err != nil {
+				return err
+			}
+			_, err = io.WriteString(w, s)
 			return err
-		}
-		_, err = io.WriteString(w, s)
-		return err
+		})
 	})
 }
 
@@ -46,7 +100,14 @@ func (e *Engine) RegisterBlock(name string, td Renderer) {
 // * https://github.com/osteele/gojekyll/blob/master/filters/filters.go
 //
 func (e *Engine) RegisterFilter(name string, fn interface{}) {
-	e.cfg.AddFilter(name, fn)
+	e.update(func(cfg *render.Config) { cfg.AddFilter(name, fn) })
+}
+
+// RegisterPureFilter is the same as RegisterFilter, for a filter whose result depends only on its
+// arguments. An object or a control tag condition that applies only pure filters to literals,
+// such as {{ "title" | upcase }}, is evaluated once, when the template is parsed.
+func (e *Engine) RegisterPureFilter(name string, fn interface{}) {
+	e.update(func(cfg *render.Config) { cfg.AddPureFilter(name, fn) })
 }
 
 // RegisterTag defines a tag e.g. {% tag %}.
@@ -55,21 +116,48 @@ func (e *Engine) RegisterFilter(name string, fn interface{}) {
 func (e *Engine) RegisterTag(name string, td Renderer) {
 	// For simplicity, don't expose the two stage parsing/rendering process to clients.
 	// Client tags do everything at runtime.
-	e.cfg.AddTag(name, func(_ string) (func(io.Writer, render.Context) error, error) {
-		return func(w io.Writer, ctx render.Context) error {
-			s, err := td(ctx)
-			if err != nil {
+	e.update(func(cfg *render.Config) {
+		cfg.AddTag(name, func(_ string) (func(io.Writer, render.Context) error, error) {
+			return func(w io.Writer, ctx render.Context) error {
+				s, err := td(ctx)
+				if err != nil {
+					return err
+				}
+				_, err = io.WriteString(w, s)
 				return err
-			}
-			_, err = io.WriteString(w, s)
-			return err
-		}, nil
+			}, nil
+		})
 	})
 }
 
+// RegisterRewriter adds a function that transforms the syntax tree of each template after it is
+// parsed, and before it is compiled. This includes the templates that {% include %} reads.
+//
+// For example, this replaces a deprecated filter:
+//
+//	engine.RegisterRewriter(func(root parser.ASTNode) (parser.ASTNode, error) {
+//		parser.Inspect(root, func(n parser.ASTNode) bool {
+//			if obj, ok := n.(*parser.ASTObject); ok {
+//				expr := expressions.Syntax(obj.Expr)
+//				expressions.Inspect(expr, func(e expressions.Node) bool {
+//					if f, ok := e.(*expressions.Filter); ok && f.Name == "old" {
+//						f.Name = "new"
+//					}
+//					return true
+//				})
+//				obj.Expr = expressions.FromSyntax(expr)
+//			}
+//			return true
+//		})
+//		return root, nil
+//	})
+func (e *Engine) RegisterRewriter(fn render.Rewriter) {
+	e.update(func(cfg *render.Config) { cfg.AddRewriter(fn) })
+}
+
 // ParseTemplate creates a new Template using the engine configuration.
 func (e *Engine) ParseTemplate(source []byte) (*Template, SourceError) {
-	return newTemplate(&e.cfg, source, "", 0)
+	return newTemplate(e.config(), source, "", 0)
 }
 
 // ParseString creates a new Template using the engine configuration.
@@ -83,7 +171,142 @@ func (e *Engine) ParseString(source string) (*Template, SourceError) {
 // The path and line number are used for error reporting.
 // The path is also the reference for relative pathnames in the {% include %} tag.
 func (e *Engine) ParseTemplateLocation(source []byte, path string, line int) (*Template, SourceError) {
-	return newTemplate(&e.cfg, source, path, line)
+	return newTemplate(e.config(), source, path, line)
+}
+
+// ParseTemplateAll is the same as ParseTemplateLocation, except that it doesn't stop at the
+// first error. If the template has errors, ParseTemplateAll returns an ErrorList of every syntax
+// error, undefined tag, and unterminated block, in source order.
+func (e *Engine) ParseTemplateAll(source []byte, path string, line int) (*Template, SourceError) {
+	return newTemplateAll(e.config(), source, path, line)
+}
+
+// ParseAST parses a template into its syntax tree, without compiling it. The path is used for
+// error reporting.
+//
+// The nodes of the tree marshal to JSON. See the parser package for the format.
+func (e *Engine) ParseAST(source []byte, path string) (parser.ASTNode, SourceError) {
+	root, err := e.config().Parse(string(source), parser.SourceLoc{Pathname: path, LineNo: 1})
+	if err != nil {
+		return nil, err
+	}
+	return root, nil
+}
+
+// UnmarshalAST decodes the JSON representation of a syntax tree, such as json.Marshal produces
+// from the result of ParseAST.
+func (e *Engine) UnmarshalAST(data []byte) (parser.ASTNode, error) {
+	return e.config().UnmarshalAST(data)
+}
+
+// CompileAST creates a new Template from a syntax tree, such as ParseAST or UnmarshalAST returns.
+func (e *Engine) CompileAST(root parser.ASTNode) (*Template, SourceError) {
+	cfg := e.config()
+	node, err := cfg.CompileAST(root)
+	if err != nil {
+		return nil, err
+	}
+	return &Template{node, cfg, nil}, nil
+}
+
+// GenerateGo compiles templates to Go source code, and returns the source of a Go file that
+// defines a *render.GoTemplate variable for each of them. See the codegen package.
+//
+// The generated code should be loaded by LoadGoTemplate, with an engine that has the same
+// configuration.
+func (e *Engine) GenerateGo(opts codegen.Options, templates ...codegen.Template) ([]byte, error) {
+	return codegen.Generate(*e.config(), opts, templates)
+}
+
+// LoadGoTemplate creates a new Template from the code that GenerateGo generated for a template.
+// It renders with the same output as the Template that ParseTemplateLocation creates from the
+// template's source.
+//
+// LoadGoTemplate decodes the template's syntax tree, or parses the template if the engine's
+// configuration has changed, and compiles the tags that the generated code doesn't render; so a
+// program should call it once for each template, and keep the result.
+func (e *Engine) LoadGoTemplate(t *render.GoTemplate) (*Template, SourceError) {
+	cfg := e.config()
+	node, err := cfg.LoadGoTemplate(t)
+	if err != nil {
+		return nil, err
+	}
+	return &Template{node, cfg, nil}, nil
+}
+
+// LoadCompiled creates a Template from data that Template.MarshalBinary returned, and from the
+// template's source. It compiles the syntax tree in data, with the parses of its tags' arguments
+// that data holds, instead of parsing the source again.
+//
+// LoadCompiled returns an error if data was marshaled from a different source, or by an engine
+// whose delimiters, tags, blocks or filters are different; see render.Config.Fingerprint. The
+// caller can then parse the source, and marshal the new template:
+//
+//	tpl, err := engine.LoadCompiled(data, source)
+//	if err != nil {
+//		tpl, err = engine.ParseTemplateLocation(source, path, 1)
+//		…
+//		data, _ = tpl.MarshalBinary()
+//	}
+func (e *Engine) LoadCompiled(data, source []byte) (*Template, SourceError) {
+	cfg := e.config()
+	var ct compiledTemplate
+	ast, err := ct.decode(data)
+	if err != nil {
+		return nil, parser.WrapError(fmt.Errorf("the compiled template can't be read: %s", err), parser.Token{})
+	}
+	loc := parser.SourceLoc{Pathname: ct.Path, LineNo: ct.Line}
+	switch {
+	case ct.Version != compiledVersion:
+		return nil, parser.Errorf(parser.Token{SourceLoc: loc}, "the compiled template has version %d; parse it again", ct.Version)
+	case ct.Hash != sha256.Sum256(source):
+		return nil, parser.Errorf(parser.Token{SourceLoc: loc}, "the compiled template doesn't match its source; parse it again")
+	case ct.Fingerprint != cfg.Fingerprint():
+		return nil, parser.Errorf(parser.Token{SourceLoc: loc}, "the template was compiled by an engine with a different configuration; parse it again")
+	}
+	size, n := binary.Uvarint(ast)
+	if n <= 0 || size > uint64(len(ast)-n) {
+		return nil, parser.Errorf(parser.Token{SourceLoc: loc}, "the compiled template is truncated")
+	}
+	text := string(source)
+	root, err := cfg.UnmarshalASTBinary(ast[n:n+int(size)], text)
+	if err != nil {
+		return nil, parser.WrapError(err, parser.Token{SourceLoc: loc})
+	}
+	parses, err := expressions.UnmarshalParseTableBinary(ast[n+int(size):])
+	if err != nil {
+		return nil, parser.WrapError(err, parser.Token{SourceLoc: loc})
+	}
+	compiling := *cfg
+	compiling.ParseTable = parses
+	node, perr := compiling.CompileAST(root)
+	if perr != nil {
+		return nil, perr
+	}
+	return &Template{node, cfg, &templateSource{text, loc}}, nil
+}
+
+// FormatTemplate returns the source of a template in canonical form, with nested tags indented
+// by indent. See parser.Config.Format for the details. The path is used for error reporting.
+//
+// The template is parsed but not compiled, so its tags aren't checked.
+func (e *Engine) FormatTemplate(source []byte, path, indent string) ([]byte, SourceError) {
+	root, err := e.ParseAST(source, path)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(e.config().Format(root, indent)), nil
+}
+
+// HasFilter returns true if the engine defines the named filter.
+func (e *Engine) HasFilter(name string) bool {
+	return e.config().HasFilter(name)
+}
+
+// FindTemplate returns the path of the template that {% include name %} renders, from a template
+// at the path from. If there is no such template, the error satisfies os.IsNotExist.
+func (e *Engine) FindTemplate(name, from string) (string, error) {
+	return e.config().FindTemplate(name, from)
 }
 
 // ParseAndRender parses and then renders the template.
@@ -99,6 +322,17 @@ func (e *Engine) ParseAndRenderWithState(source []byte, b Bindings, state Bindin
 	return tpl.RenderWithState(b, state)
 }
 
+// ParseAndFRender parses and then renders the template, writing the output to w.
+//
+// See Template.FRender for how the output is streamed to w.
+func (e *Engine) ParseAndFRender(w io.Writer, source []byte, b Bindings) SourceError {
+	tpl, err := e.ParseTemplate(source)
+	if err != nil {
+		return err
+	}
+	return tpl.FRender(w, b)
+}
+
 // ParseAndRenderString is a convenience wrapper for ParseAndRender, that takes string input and returns a string.
 func (e *Engine) ParseAndRenderString(source string, b Bindings) (string, SourceError) {
 	return e.ParseAndRenderStringWithState(source, b, map[string]interface{}{})
@@ -106,17 +340,95 @@ func (e *Engine) ParseAndRenderString(source string, b Bindings) (string, Source
 
 func (e *Engine) ParseAndRenderStringWithState(source string, b Bindings, state Bindings) (string, SourceError) {
 	bs, err := e.ParseAndRenderWithState([]byte(source), b, state)
-	if err != nil {
-		return "", err
-	}
-	return string(bs), nil
+	return string(bs), err
 }
 
 // Delims sets the action delimiters to the specified strings, to be used in subsequent calls to
 // ParseTemplate, ParseTemplateLocation, ParseAndRender, or ParseAndRenderString. An empty delimiter
 // stands for the corresponding default: objectLeft = {{, objectRight = }}, tagLeft = {% , tagRight = %}
 func (e *Engine) Delims(objectLeft, objectRight, tagLeft, tagRight string) *Engine {
-	e.cfg.Delims = []string{objectLeft, objectRight, tagLeft, tagRight}
+	e.update(func(cfg *render.Config) {
+		cfg.Delims = []string{objectLeft, objectRight, tagLeft, tagRight}
+	})
+	return e
+}
+
+// LaxDelims makes subsequent parses scan an unterminated object or tag, such as "Hello {{ name",
+// as text, as earlier versions did. By default this is an error, as is a {{ or {% nested inside
+// an object or tag outside of a string.
+func (e *Engine) LaxDelims() *Engine {
+	e.update(func(cfg *render.Config) { cfg.LaxDelims = true })
+	return e
+}
+
+// TemplateLoader sets the loader that the {% include %} tag uses to read templates.
+// The default loader reads templates from the file system.
+//
+// The render package provides loaders that read from a directory, from a map, and from an http.FileSystem.
+func (e *Engine) TemplateLoader(loader render.TemplateLoader) *Engine {
+	e.update(func(cfg *render.Config) { cfg.TemplateLoader = loader })
+	return e
+}
+
+// SearchPaths sets the directories that the {% include %} tag searches, in order, for a template
+// that isn't found relative to the including template.
+func (e *Engine) SearchPaths(paths ...string) *Engine {
+	e.update(func(cfg *render.Config) { cfg.SearchPaths = paths })
+	return e
+}
+
+// IncludeCache enables a cache of the compiled templates that are read by the {% include %} tag,
+// so that a template that is included repeatedly is only read and compiled once.
+// If maxSize is positive, the cache holds at most this many templates.
+// Registering a tag, block or filter afterward starts an empty cache for the templates
+// that are parsed after that, since the cached templates were compiled without it.
+func (e *Engine) IncludeCache(maxSize int) *Engine {
+	e.update(func(cfg *render.Config) { cfg.TemplateCache = render.NewTemplateCache(maxSize) })
+	return e
+}
+
+// InvalidateIncludeCache removes the named paths from the include cache, so that they are read
+// again the next time that they are included. With no arguments, it empties the cache.
+func (e *Engine) InvalidateIncludeCache(paths ...string) {
+	e.config().TemplateCache.Invalidate(paths...)
+}
+
+// Limits sets the resource limits of the templates that the engine parses.
+// A render that exceeds a limit stops with an error whose Cause is a *render.LimitError.
+//
+// Use Template.WithLimits to set different limits for an individual render.
+func (e *Engine) Limits(limits render.Limits) *Engine {
+	e.update(func(cfg *render.Config) { cfg.Limits = limits })
+	return e
+}
+
+// AutoEscape makes the engine HTML-escape the output of {{ objects }}, unless the value is a SafeString.
+//
+// The escape, escape_once and newline_to_br filters return safe strings, and the raw and safe
+// filters mark a trusted string as safe. A custom filter or drop can return a SafeString too.
+func (e *Engine) AutoEscape() *Engine {
+	e.update(func(cfg *render.Config) {
+		cfg.AutoEscape = true
+		filters.AddSafeHTMLFilters(cfg)
+	})
+	return e
+}
+
+// ContinueOnError makes the engine's templates render past a failing object, tag or block.
+// The output of the node that fails is replaced by placeholder(err), and Render returns the output
+// together with an ErrorList of the errors. If placeholder is nil, the placeholder is the error
+// message, e.g. "Liquid error (line 3): divided by 0".
+//
+// Cancellation, and exceeding one of the engine's Limits, still stop the render.
+func (e *Engine) ContinueOnError(placeholder func(SourceError) string) *Engine {
+	e.update(func(cfg *render.Config) {
+		cfg.ErrorPlaceholder = func(err render.Error) string {
+			if placeholder == nil {
+				return err.Error()
+			}
+			return placeholder(err)
+		}
+	})
 	return e
 }
 
@@ -137,11 +449,11 @@ func (e *Engine) LaxFilters() *Engine {
 }
 
 func (e *Engine) UndefinedVariablesMode(handler expressions.UndefinedVariableHandler) *Engine {
-	e.cfg.VariableErrorMode = handler
+	e.update(func(cfg *render.Config) { cfg.VariableErrorMode = handler })
 	return e
 }
 
 func (e *Engine) UndefinedFiltersMode(handler expressions.UndefinedFilterHandler) *Engine {
-	e.cfg.FilterErrorMode = handler
+	e.update(func(cfg *render.Config) { cfg.FilterErrorMode = handler })
 	return e
 }
@@ -5,8 +5,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"strings"
+	"sync"
 	"testing"
 
+	"github.com/etecs-ru/liquid/v2/expressions"
+	"github.com/etecs-ru/liquid/v2/parser"
+	"github.com/etecs-ru/liquid/v2/render"
 	"github.com/stretchr/testify/require"
 )
 
@@ -100,3 +105,388 @@ func BenchmarkEngine_Parse(b *testing.B) {
 		engine.ParseTemplate(s) // nolint: errcheck
 	}
 }
+
+func TestEngine_TemplateLoader(t *testing.T) {
+	engine := NewEngine().
+		TemplateLoader(render.MapLoader{"includes/greeting.html": `Hello, {{ name }}!`}).
+		SearchPaths("includes")
+	out, err := engine.ParseAndRenderString(`{% include "greeting.html" %}`, Bindings{"name": "World"})
+	require.NoError(t, err)
+	require.Equal(t, "Hello, World!", out)
+}
+
+func TestEngine_RegisterRewriter(t *testing.T) {
+	engine := NewEngine().TemplateLoader(render.MapLoader{"greeting.html": `Hello, {{ name | shout }}!`})
+	engine.RegisterRewriter(func(root parser.ASTNode) (parser.ASTNode, error) {
+		parser.Inspect(root, func(n parser.ASTNode) bool {
+			if obj, ok := n.(*parser.ASTObject); ok {
+				expr := expressions.Syntax(obj.Expr)
+				expressions.Inspect(expr, func(e expressions.Node) bool {
+					if f, ok := e.(*expressions.Filter); ok && f.Name == "shout" {
+						f.Name = "upcase"
+					}
+					return true
+				})
+				obj.Expr = expressions.FromSyntax(expr)
+			}
+			return true
+		})
+		return root, nil
+	})
+	tpl, err := engine.ParseString(`{{ "hi" | shout }} {% include "greeting.html" %}`)
+	require.NoError(t, err)
+	out, err := tpl.RenderString(Bindings{"name": "World"})
+	require.NoError(t, err)
+	require.Equal(t, "HI Hello, WORLD!", out)
+
+	var includes []string
+	tpl.Inspect(func(n render.Node) bool {
+		if tag, ok := n.(*render.TagNode); ok && tag.Name == "include" {
+			includes = append(includes, tag.Args)
+		}
+		return true
+	})
+	require.Equal(t, []string{`"greeting.html"`}, includes)
+}
+
+func TestEngine_IncludeCache(t *testing.T) {
+	loader := render.MapLoader{"item.html": `{{ i }}`}
+	engine := NewEngine().TemplateLoader(loader).IncludeCache(10)
+	tpl, err := engine.ParseString(`{% for i in (1..3) %}{% include "item.html" %}{% endfor %}`)
+	require.NoError(t, err)
+	out, err := tpl.RenderString(emptyBindings)
+	require.NoError(t, err)
+	require.Equal(t, "123", out)
+
+	// the cached template is used until it is invalidated
+	loader["item.html"] = `[{{ i }}]`
+	out, err = tpl.RenderString(emptyBindings)
+	require.NoError(t, err)
+	require.Equal(t, "123", out)
+	engine.InvalidateIncludeCache("item.html")
+	out, err = tpl.RenderString(emptyBindings)
+	require.NoError(t, err)
+	require.Equal(t, "[1][2][3]", out)
+
+	// registering a tag recompiles the includes of the templates that are parsed afterward
+	engine.RegisterTag("t", func(render.Context) (string, error) { return "old", nil })
+	tpl, err = engine.ParseString(`{% include "tag.html" %}`)
+	require.NoError(t, err)
+	loader["tag.html"] = `{% t %}`
+	out, err = tpl.RenderString(emptyBindings)
+	require.NoError(t, err)
+	require.Equal(t, "old", out)
+	engine.RegisterTag("t", func(render.Context) (string, error) { return "new", nil })
+	changed, err := engine.ParseString(`{% include "tag.html" %}`)
+	require.NoError(t, err)
+	out, err = changed.RenderString(emptyBindings)
+	require.NoError(t, err)
+	require.Equal(t, "new", out)
+	out, err = tpl.RenderString(emptyBindings)
+	require.NoError(t, err)
+	require.Equal(t, "old", out)
+}
+
+func TestEngine_Limits(t *testing.T) {
+	engine := NewEngine().Limits(render.Limits{MaxLoopIterations: 100})
+	tpl, err := engine.ParseTemplateLocation([]byte("\n{% for i in (1..100000000) %}{% endfor %}"), "page.html", 1)
+	require.NoError(t, err)
+	_, err = tpl.Render(emptyBindings)
+	require.Error(t, err)
+	require.IsType(t, &render.LimitError{}, err.Cause())
+	require.Equal(t, "page.html", err.Path())
+	require.Equal(t, 2, err.LineNumber())
+
+	tpl, err = engine.ParseString(`{% for i in (1..10) %}{{ i }}{% endfor %}`)
+	require.NoError(t, err)
+	out, err := tpl.RenderString(emptyBindings)
+	require.NoError(t, err)
+	require.Equal(t, "12345678910", out)
+	_, err = tpl.WithLimits(render.Limits{MaxOutputBytes: 10}).Render(emptyBindings)
+	require.Error(t, err)
+	require.IsType(t, &render.LimitError{}, err.Cause())
+}
+
+func TestEngine_concurrent_registration(t *testing.T) {
+	engine := NewEngine()
+	tpl, err := engine.ParseString(`{{ "a" | upcase }}{% for i in (1..3) %}{{ i }}{% endfor %}`)
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				out, err := tpl.RenderString(emptyBindings)
+				require.NoError(t, err)
+				require.Equal(t, "A123", out)
+			}
+		}()
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				name := fmt.Sprintf("f%d_%d", i, j)
+				engine.RegisterFilter(name, strings.ToUpper)
+				engine.RegisterTag(name, func(render.Context) (string, error) { return "", nil })
+				_, err := engine.ParseString(fmt.Sprintf(`{{ "b" | %s }}{%% %s %%}`, name, name))
+				require.NoError(t, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	// the template renders with the configuration that it was parsed with
+	engine.RegisterFilter("later", strings.ToUpper)
+	_, err = engine.ParseAndRenderString(`{{ "x" | later }}`, emptyBindings)
+	require.NoError(t, err)
+	tpl2, err := engine.ParseString(`{{ "x" | later }}`)
+	require.NoError(t, err)
+	engine.RegisterFilter("later", strings.ToLower)
+	out, err := tpl2.RenderString(emptyBindings)
+	require.NoError(t, err)
+	require.Equal(t, "X", out)
+}
+
+func TestEngine_Freeze(t *testing.T) {
+	engine := NewEngine()
+	engine.RegisterFilter("shout", strings.ToUpper)
+	engine.Freeze()
+	out, err := engine.ParseAndRenderString(`{{ "x" | shout }}`, emptyBindings)
+	require.NoError(t, err)
+	require.Equal(t, "X", out)
+	require.Panics(t, func() { engine.RegisterFilter("whisper", strings.ToLower) })
+	require.Panics(t, func() { engine.RegisterTag("t", func(render.Context) (string, error) { return "", nil }) })
+	require.Panics(t, func() { engine.StrictVariables() })
+}
+
+func TestEngine_RegisterPureFilter(t *testing.T) {
+	engine := NewEngine()
+	calls := 0
+	engine.RegisterPureFilter("shout", func(s string) string {
+		calls++
+		return strings.ToUpper(s)
+	})
+	tpl, err := engine.ParseString(`{{ "x" | shout }}{% if "y" | shout %}!{% endif %}{{ z | shout }}`)
+	require.NoError(t, err)
+	require.Equal(t, 2, calls)
+	for i := 0; i < 2; i++ {
+		out, err := tpl.RenderString(map[string]interface{}{"z": "z"})
+		require.NoError(t, err)
+		require.Equal(t, "X!Z", out)
+	}
+	require.Equal(t, 4, calls)
+
+	// a filter that is registered again with RegisterFilter isn't pure
+	engine.RegisterFilter("shout", func(s string) string {
+		calls++
+		return strings.ToUpper(s)
+	})
+	calls = 0
+	tpl, err = engine.ParseString(`{{ "x" | shout }}`)
+	require.NoError(t, err)
+	require.Zero(t, calls)
+	out, err := tpl.RenderString(emptyBindings)
+	require.NoError(t, err)
+	require.Equal(t, "X", out)
+	require.Equal(t, 1, calls)
+}
+
+func TestEngine_AutoEscape(t *testing.T) {
+	engine := NewEngine().AutoEscape()
+	engine.RegisterFilter("bold", func(s string) SafeString { return SafeString("<b>" + s + "</b>") })
+	bindings := map[string]interface{}{"name": "<script>", "bio": "a\nb"}
+	tests := []struct{ in, expected string }{
+		{`{{ name }}`, "&lt;script&gt;"},
+		{`{{ name | escape }}`, "&lt;script&gt;"},
+		{`{{ name | raw }}`, "<script>"},
+		{`{{ name | safe | upcase }}`, "&lt;SCRIPT&gt;"},
+		{`{{ bio | newline_to_br }}`, "a<br />b"},
+		{`{{ "x" | bold }}`, "<b>x</b>"},
+		{`<{% if name %}p{% endif %}>`, "<p>"},
+	}
+	for _, test := range tests {
+		out, err := engine.ParseAndRenderString(test.in, bindings)
+		require.NoError(t, err, test.in)
+		require.Equal(t, test.expected, out, test.in)
+	}
+
+	out, err := NewEngine().ParseAndRenderString(`{{ name }}`, bindings)
+	require.NoError(t, err)
+	require.Equal(t, "<script>", out)
+}
+
+func TestEngine_ContinueOnError(t *testing.T) {
+	engine := NewEngine().ContinueOnError(nil)
+	source := `{% for p in products %}<li>{{ p.name }}: {{ p.price | divided_by: p.count }}</li>{% if forloop.index == 2 %}{% break %}{% endif %}{% endfor %}`
+	bindings := map[string]interface{}{
+		"products": []map[string]interface{}{
+			{"name": "a", "price": 4, "count": 2},
+			{"name": "b", "price": 4, "count": 0},
+			{"name": "c", "price": 4, "count": 1},
+		},
+	}
+	out, err := engine.ParseAndRenderString(source, bindings)
+	require.Error(t, err)
+	require.IsType(t, ErrorList{}, err)
+	require.Len(t, err.(ErrorList), 1)
+	require.Equal(t, `<li>a: 2</li><li>b: Liquid error: error applying filter "divided_by" ("divided by 0") in {{ p.price | divided_by: p.count }}</li>`, out)
+
+	engine = NewEngine().ContinueOnError(func(SourceError) string { return "<!-- error -->" }).AutoEscape()
+	out, err = engine.ParseAndRenderString(`{{ p | divided_by: 0 }}`, Bindings{"p": 1})
+	require.Error(t, err)
+	require.Equal(t, "&lt;!-- error --&gt;", out)
+}
+
+func TestEngine_ParseTemplateAll(t *testing.T) {
+	engine := NewEngine()
+	source := "{% if a %}\n{{ b | }}\n{% for x in c %}{% endif %}\n{% undefined %}"
+	_, err := engine.ParseTemplateAll([]byte(source), "page.html", 1)
+	require.Error(t, err)
+	errs := err.(ErrorList)
+	require.Len(t, errs, 3)
+	require.Contains(t, errs[0].Error(), "syntax error")
+	require.Equal(t, 2, errs[0].LineNumber())
+	require.Contains(t, errs[1].Error(), `unterminated "for" block`)
+	require.Equal(t, 3, errs[1].LineNumber())
+	require.Contains(t, errs[2].Error(), `undefined tag "undefined"`)
+	require.Equal(t, "page.html", errs[2].Path())
+
+	tpl, err := engine.ParseTemplateAll([]byte(`{{ "x" }}`), "page.html", 1)
+	require.NoError(t, err)
+	out, err := tpl.RenderString(emptyBindings)
+	require.NoError(t, err)
+	require.Equal(t, "x", out)
+}
+
+func TestEngine_ParseAST(t *testing.T) {
+	engine := NewEngine()
+	root, err := engine.ParseAST([]byte(`{% for x in xs %}{{ x | upcase }}{% endfor %}{% assign y = 1 %}`), "page.html")
+	require.NoError(t, err)
+	b, jerr := json.Marshal(root)
+	require.NoError(t, jerr)
+	require.Contains(t, string(b), `"expr":{"type":"filter","receiver":{"type":"variable","name":"x"},"name":"upcase","args":[]}`)
+
+	root, jerr = engine.UnmarshalAST(b)
+	require.NoError(t, jerr)
+	tpl, err := engine.CompileAST(root)
+	require.NoError(t, err)
+	out, err := tpl.RenderString(Bindings{"xs": []string{"a", "b"}})
+	require.NoError(t, err)
+	require.Equal(t, "AB", out)
+
+	_, err = engine.ParseAST([]byte(`{% if a %}`), "page.html")
+	require.Error(t, err)
+	require.Equal(t, "page.html", err.Path())
+
+	root, jerr = engine.UnmarshalAST([]byte(`{"type": "tag", "name": "undefined_tag"}`))
+	require.NoError(t, jerr)
+	_, err = engine.CompileAST(root)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "undefined tag")
+}
+
+func TestEngine_FormatTemplate(t *testing.T) {
+	engine := NewEngine()
+	source := "{%if a%}\n{%- for x in  xs%}\n{{-x|upcase}}\n{%endfor%}\n{% else %}{%raw%} {{ x }}{%endraw%}{% endif %}"
+	out, err := engine.FormatTemplate([]byte(source), "page.html", "\t")
+	require.NoError(t, err)
+	require.Equal(t, "{% if a %}\n{%- for x in xs %}\n\t{{- x | upcase }}\n{% endfor %}\n{% else %}{% raw %} {{ x }}{% endraw %}{% endif %}", string(out))
+
+	_, err = engine.FormatTemplate([]byte("line 1\n{% if a %}"), "page.html", "\t")
+	require.Error(t, err)
+	require.Equal(t, "page.html", err.Path())
+	require.Equal(t, 2, err.LineNumber())
+}
+
+func TestEngine_FormatTemplate_render(t *testing.T) {
+	engine := NewEngine()
+	bindings := Bindings{"a": true, "xs": []string{"p", "q"}}
+	sources := []string{
+		"<pre>\n{% if a %}\n    {{ xs | join: ',' }}\n  {% endif %}\n</pre>",
+		"<ul>\n{% for x in xs -%}\n{% if x == 'q' -%}\n<li>{{x}}</li>\n{%- else -%}\n      {{ x }}\n{%- endif %}\n{%- endfor %}\n</ul>\n",
+		"{% for x in xs %}\n  {%- if x %}\n\t\t{{- x -}}\n   {% endif -%}\n{% endfor %}",
+		"{% if a %}\n{% comment %} c {% endcomment %}\n  {% raw %} {{ x }}\n{% endraw %}\n{% endif %}",
+		"<p>\n{% for x in xs %}\n{%- assign y = x | upcase -%}\n      {{ y -}}\n  {% cycle 'a', 'b' %}\n{% endfor %}\n</p>",
+	}
+	for _, source := range sources {
+		expected, err := engine.ParseAndRenderString(source, bindings)
+		require.NoError(t, err, source)
+		for _, indent := range []string{"  ", "\t"} {
+			out, err := engine.FormatTemplate([]byte(source), "page.html", indent)
+			require.NoError(t, err, source)
+			actual, err := engine.ParseAndRenderString(string(out), bindings)
+			require.NoError(t, err, string(out))
+			require.Equal(t, expected, actual, string(out))
+		}
+	}
+}
+
+func TestEngine_LaxDelims(t *testing.T) {
+	_, err := NewEngine().ParseString(`Hello {{ name`)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "unterminated object")
+	require.Equal(t, 7, err.ColumnNumber())
+
+	out, err := NewEngine().LaxDelims().ParseAndRenderString(`Hello {{ name`, emptyBindings)
+	require.NoError(t, err)
+	require.Equal(t, `Hello {{ name`, out)
+}
+
+func TestEngine_LoadGoTemplate(t *testing.T) {
+	engine := NewEngine()
+	source := "Hello, {{ name | upcase }}!"
+	root, err := engine.ParseAST([]byte(source), "hello.html")
+	require.NoError(t, err)
+	// the code that the codegen package generates for the template
+	gt := &render.GoTemplate{
+		Path:     "hello.html",
+		Source:   source,
+		Checksum: parser.Checksum(root),
+		Render: func(r *render.Runtime) {
+			r.Text(1)
+			r.Tag(2, false)
+			r.Write(r.Apply(r.Filter("upcase"), r.Get("name")))
+			r.Text(3)
+		},
+	}
+	tpl, err := engine.LoadGoTemplate(gt)
+	require.NoError(t, err)
+	out, err := tpl.RenderString(map[string]interface{}{"name": "world"})
+	require.NoError(t, err)
+	require.Equal(t, "Hello, WORLD!", out)
+
+	tpl, err = engine.StrictVariables().LoadGoTemplate(gt)
+	require.NoError(t, err)
+	_, err = tpl.RenderString(emptyBindings)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "undefined variable")
+	require.Equal(t, 8, err.ColumnNumber())
+
+	// the syntax tree is decoded, if the configuration is the same
+	ast, merr := parser.MarshalASTBinary(root, source)
+	require.NoError(t, merr)
+	gt.AST, gt.Fingerprint = ast, engine.config().Fingerprint()
+	tpl, err = engine.LoadGoTemplate(gt)
+	require.NoError(t, err)
+	out, err = tpl.RenderString(map[string]interface{}{"name": "world"})
+	require.NoError(t, err)
+	require.Equal(t, "Hello, WORLD!", out)
+	gt.AST = ast[:len(ast)-1]
+	_, err = engine.LoadGoTemplate(gt)
+	require.Error(t, err)
+	// and the source is parsed otherwise
+	changed := NewEngine()
+	changed.RegisterFilter("shout", strings.ToUpper)
+	tpl, err = changed.LoadGoTemplate(gt)
+	require.NoError(t, err)
+	out, err = tpl.RenderString(map[string]interface{}{"name": "world"})
+	require.NoError(t, err)
+	require.Equal(t, "Hello, WORLD!", out)
+
+	gt.AST = nil
+	gt.Source = "Hello, {{ name }}!"
+	_, err = engine.LoadGoTemplate(gt)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "compile it to Go again")
+}
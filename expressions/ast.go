@@ -0,0 +1,193 @@
+package expressions
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// A Node is a node of an expression's syntax tree.
+//
+// Parentheses don't appear in the tree; they are reflected in its shape.
+// A Node's String method returns its source, in canonical form.
+type Node interface {
+	node()
+	String() string
+}
+
+// A Literal is a string, number, boolean, or nil literal, e.g. "s", 1, 2.5, true, nil.
+type Literal struct {
+	Value interface{}
+}
+
+// A Variable is a reference to a variable, e.g. a.
+type Variable struct {
+	Name string
+}
+
+// A Property is a property access, e.g. a.b.
+type Property struct {
+	Object Node
+	Name   string
+}
+
+// An Index is an index or key access, e.g. a[0] or a["b"].
+type Index struct {
+	Object Node
+	Index  Node
+}
+
+// A Filter is a filter application, e.g. a | join: ", ".
+type Filter struct {
+	Receiver Node
+	Name     string
+	Args     []Node
+}
+
+// A Binary is a comparison or logical operation, e.g. a == b or a and b.
+// Op is one of ==, !=, <, >, <=, >=, contains, and, or.
+type Binary struct {
+	Op    string
+	Left  Node
+	Right Node
+}
+
+// A Range is a range expression, e.g. (1..n).
+type Range struct {
+	Start Node
+	End   Node
+}
+
+func (*Literal) node()  {}
+func (*Variable) node() {}
+func (*Property) node() {}
+func (*Index) node()    {}
+func (*Filter) node()   {}
+func (*Binary) node()   {}
+func (*Range) node()    {}
+
+// The precedence levels of the grammar, from loosest to tightest.
+const (
+	condLevel     = iota // a and b, a or b
+	relLevel             // a == b, a contains b
+	filteredLevel        // a | f
+	exprLevel            // literals, variables, a.b, a[b], and parenthesized expressions
+)
+
+func level(n Node) int {
+	switch n := n.(type) {
+	case *Binary:
+		if n.Op == "and" || n.Op == "or" {
+			return condLevel
+		}
+		return relLevel
+	case *Filter:
+		return filteredLevel
+	default:
+		return exprLevel
+	}
+}
+
+// format returns the source of n, parenthesized if it binds more loosely than min.
+func format(n Node, min int) string {
+	if level(n) < min {
+		return "(" + n.String() + ")"
+	}
+	return n.String()
+}
+
+func (n *Literal) String() string {
+	switch v := n.Value.(type) {
+	case nil:
+		return "nil"
+	case string:
+		if strings.Contains(v, `"`) {
+			return "'" + v + "'"
+		}
+		return `"` + v + `"`
+	case float64:
+		s := strconv.FormatFloat(v, 'f', -1, 64)
+		if !strings.Contains(s, ".") {
+			s += ".0"
+		}
+		return s
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func (n *Variable) String() string { return n.Name }
+func (n *Property) String() string { return format(n.Object, exprLevel) + "." + n.Name }
+func (n *Index) String() string {
+	return format(n.Object, exprLevel) + "[" + format(n.Index, exprLevel) + "]"
+}
+
+func (n *Filter) String() string {
+	s := format(n.Receiver, filteredLevel) + " | " + n.Name
+	for i, arg := range n.Args {
+		sep := ", "
+		if i == 0 {
+			sep = ": "
+		}
+		s += sep + format(arg, exprLevel)
+	}
+	return s
+}
+
+func (n *Binary) String() string {
+	if level(n) == condLevel {
+		return format(n.Left, condLevel) + " " + n.Op + " " + format(n.Right, relLevel)
+	}
+	return format(n.Left, exprLevel) + " " + n.Op + " " + format(n.Right, exprLevel)
+}
+
+func (n *Range) String() string {
+	return "(" + format(n.Start, exprLevel) + ".." + format(n.End, exprLevel) + ")"
+}
+
+// Syntax returns the syntax tree of an expression that was created by Parse or ParseStatement.
+// It returns nil for other expressions, such as those that are created by Constant and Not.
+func Syntax(e Expression) Node {
+	if e, ok := e.(*expression); ok {
+		return e.node
+	}
+	return nil
+}
+
+// FromSyntax creates an Expression from a syntax tree. It is the inverse of Syntax.
+func FromSyntax(n Node) Expression {
+	return newExpression(n)
+}
+
+// newExpression compiles a syntax tree into an Expression.
+func newExpression(n Node) *expression {
+	return &expression{n, compileProgram(n)}
+}
+
+// Inspect traverses a syntax tree in depth-first order. It starts by calling f(n). If f returns
+// true, Inspect invokes f for each of the children of n, followed by a call of f(nil).
+//
+// A Node is compiled when its Expression is created, so changes to a tree don't affect
+// the Expression. Use FromSyntax to create an Expression from a changed tree.
+func Inspect(n Node, f func(Node) bool) {
+	if !f(n) {
+		return
+	}
+	var children []Node
+	switch n := n.(type) {
+	case *Property:
+		children = []Node{n.Object}
+	case *Index:
+		children = []Node{n.Object, n.Index}
+	case *Filter:
+		children = append([]Node{n.Receiver}, n.Args...)
+	case *Binary:
+		children = []Node{n.Left, n.Right}
+	case *Range:
+		children = []Node{n.Start, n.End}
+	}
+	for _, c := range children {
+		Inspect(c, f)
+	}
+	f(nil)
+}
@@ -0,0 +1,80 @@
+package expressions
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyntax(t *testing.T) {
+	expr, err := Parse(`a.b[0] == "x" and (c or 1 < 2)`)
+	require.NoError(t, err)
+	require.Equal(t, &Binary{"and",
+		&Binary{"==", &Index{&Property{&Variable{"a"}, "b"}, &Literal{0}}, &Literal{"x"}},
+		&Binary{"or", &Variable{"c"}, &Binary{"<", &Literal{1}, &Literal{2}}},
+	}, Syntax(expr))
+
+	expr, err = Parse(`a | join: ", " | upcase`)
+	require.NoError(t, err)
+	require.Equal(t, &Filter{&Filter{&Variable{"a"}, "join", []Node{&Literal{", "}}}, "upcase", nil}, Syntax(expr))
+
+	stmt, err := ParseStatement(LoopStatementSelector, "i in (1..n) reversed")
+	require.NoError(t, err)
+	require.Equal(t, &Range{&Literal{1}, &Variable{"n"}}, Syntax(stmt.Loop.Expr))
+
+	require.Nil(t, Syntax(Constant(1)))
+}
+
+func TestInspect(t *testing.T) {
+	expr, err := Parse(`a.b | f: c[d], 1`)
+	require.NoError(t, err)
+	var visited []string
+	Inspect(Syntax(expr), func(n Node) bool {
+		if n == nil {
+			visited = append(visited, ")")
+			return false
+		}
+		visited = append(visited, n.String())
+		_, ok := n.(*Index)
+		return !ok
+	})
+	require.Equal(t, []string{
+		`a.b | f: c[d], 1`,
+		`a.b`, `a`, `)`, `)`,
+		`c[d]`,
+		`1`, `)`,
+		`)`,
+	}, visited)
+}
+
+var nodeStringTests = []struct{ in, expected string }{
+	{`a`, `a`},
+	{`a.b[ 0 ]["c d"]`, `a.b[0]["c d"]`},
+	{`'it"s'`, `'it"s'`},
+	{`'s'`, `"s"`},
+	{`1.50`, `1.5`},
+	{`-2`, `-2`},
+	{`nil`, `nil`},
+	{`a|f`, `a | f`},
+	{`a|f:1,b|g`, `a | f: 1, b | g`},
+	{`a  ==b`, `a == b`},
+	{`a and b or c`, `a and b or c`},
+	{`a and (b or c)`, `a and (b or c)`},
+	{`(a | f) == b`, `(a | f) == b`},
+	{`a[(b or c)]`, `a[(b or c)]`},
+	{`x contains "y"`, `x contains "y"`},
+}
+
+func TestNode_String(t *testing.T) {
+	for _, test := range nodeStringTests {
+		expr, err := Parse(test.in)
+		require.NoError(t, err, test.in)
+		s := Syntax(expr).String()
+		require.Equal(t, test.expected, s, test.in)
+
+		// the canonical form parses to the same tree
+		expr2, err := Parse(s)
+		require.NoError(t, err, s)
+		require.Equal(t, Syntax(expr), Syntax(expr2), s)
+	}
+}
@@ -0,0 +1,463 @@
+package expressions
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// The binary representation of a Node is a compact encoding, for a cache of parsed templates, that
+// UnmarshalNodeBinary decodes faster than Parse parses the source. A node is a byte that is its
+// type, followed by its fields. An integer is a varint, and a string or a list is its length
+// followed by its contents. A literal is a byte that is the type of its value, followed by the
+// value; a float is the bits of its IEEE 754 representation.
+
+// The binary representation of a ParseTable is the number of its entries, followed by the
+// entries, in sorted order. An entry is the index of its selector in parseSelectors, its source,
+// a byte of flags that name the parts of its result, and those parts.
+
+// The selectors of the keys of a ParseTable, in the order of their indices.
+var parseSelectors = []string{"", AssignStatementSelector, CycleStatementSelector, LoopStatementSelector, WhenStatementSelector}
+
+// The flags of the parts of the result of a parse.
+const (
+	parseVal byte = 1 << iota
+	parseAssignment
+	parseCycle
+	parseLoop
+	parseWhen
+	parseLoopLimit
+	parseLoopReversed
+)
+
+// The types of the nodes.
+const (
+	binaryLiteral byte = iota + 1
+	binaryVariable
+	binaryProperty
+	binaryIndex
+	binaryFilter
+	binaryBinary
+	binaryRange
+)
+
+// The types of the values of literals.
+const (
+	binaryNil byte = iota
+	binaryFalse
+	binaryTrue
+	binaryInt
+	binaryFloat
+	binaryString
+)
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+func (n *Literal) MarshalBinary() ([]byte, error) { return marshalBinary(n) }
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+func (n *Variable) MarshalBinary() ([]byte, error) { return marshalBinary(n) }
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+func (n *Property) MarshalBinary() ([]byte, error) { return marshalBinary(n) }
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+func (n *Index) MarshalBinary() ([]byte, error) { return marshalBinary(n) }
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+func (n *Filter) MarshalBinary() ([]byte, error) { return marshalBinary(n) }
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+func (n *Binary) MarshalBinary() ([]byte, error) { return marshalBinary(n) }
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+func (n *Range) MarshalBinary() ([]byte, error) { return marshalBinary(n) }
+
+func marshalBinary(n Node) ([]byte, error) {
+	return appendNode(nil, n)
+}
+
+func appendNode(b []byte, n Node) ([]byte, error) { // nolint: gocyclo
+	var err error
+	children := func(nodes ...Node) {
+		for _, c := range nodes {
+			if err == nil {
+				b, err = appendNode(b, c)
+			}
+		}
+	}
+	switch n := n.(type) {
+	case *Literal:
+		b = append(b, binaryLiteral)
+		switch v := n.Value.(type) {
+		case nil:
+			b = append(b, binaryNil)
+		case bool:
+			if v {
+				b = append(b, binaryTrue)
+			} else {
+				b = append(b, binaryFalse)
+			}
+		case int:
+			b = appendVarint(append(b, binaryInt), int64(v))
+		case float64:
+			var bits [8]byte
+			binary.BigEndian.PutUint64(bits[:], math.Float64bits(v))
+			b = append(append(b, binaryFloat), bits[:]...)
+		case string:
+			b = appendString(append(b, binaryString), v)
+		default:
+			return nil, fmt.Errorf("invalid literal %#v", v)
+		}
+	case *Variable:
+		b = appendString(append(b, binaryVariable), n.Name)
+	case *Property:
+		b = appendString(append(b, binaryProperty), n.Name)
+		children(n.Object)
+	case *Index:
+		b = append(b, binaryIndex)
+		children(n.Object, n.Index)
+	case *Filter:
+		b = appendString(append(b, binaryFilter), n.Name)
+		b = appendUvarint(b, uint64(len(n.Args)))
+		children(n.Receiver)
+		children(n.Args...)
+	case *Binary:
+		b = appendString(append(b, binaryBinary), n.Op)
+		children(n.Left, n.Right)
+	case *Range:
+		b = append(b, binaryRange)
+		children(n.Start, n.End)
+	default:
+		return nil, fmt.Errorf("unknown expression node %T", n)
+	}
+	return b, err
+}
+
+func appendString(b []byte, s string) []byte {
+	return append(appendUvarint(b, uint64(len(s))), s...)
+}
+
+func appendUvarint(b []byte, v uint64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	return append(b, buf[:binary.PutUvarint(buf[:], v)]...)
+}
+
+func appendVarint(b []byte, v int64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	return append(b, buf[:binary.PutVarint(buf[:], v)]...)
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+func (t *ParseTable) MarshalBinary() ([]byte, error) {
+	b := appendUvarint(nil, uint64(len(t.entries)))
+	for _, k := range t.keys() {
+		sel := -1
+		for i, s := range parseSelectors {
+			if k.sel == s {
+				sel = i
+			}
+		}
+		if sel < 0 {
+			return nil, fmt.Errorf("unknown statement selector %q", k.sel)
+		}
+		b = appendString(append(b, byte(sel)), k.source)
+		var err error
+		if b, err = appendParseValue(b, t.entries[k]); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+func appendParseValue(b []byte, p *parseValue) ([]byte, error) { // nolint: gocyclo
+	var (
+		flags byte
+		nodes []Node
+		err   error
+	)
+	expr := func(e Expression) {
+		n := Syntax(e)
+		if n == nil && err == nil {
+			err = fmt.Errorf("the expression %v has no syntax tree", e)
+		}
+		nodes = append(nodes, n)
+	}
+	if p.val != nil {
+		flags |= parseVal
+		nodes = append(nodes, p.val)
+	}
+	if p.Assignment.ValueFn != nil {
+		flags |= parseAssignment
+		expr(p.Assignment.ValueFn)
+	}
+	if p.Cycle.Values != nil {
+		flags |= parseCycle
+	}
+	if p.Loop.Expr != nil {
+		flags |= parseLoop
+		expr(p.Loop.Expr)
+		if p.Loop.Limit != nil {
+			flags |= parseLoopLimit
+		}
+		if p.Loop.Reversed {
+			flags |= parseLoopReversed
+		}
+	}
+	if p.When.Exprs != nil {
+		flags |= parseWhen
+		for _, e := range p.When.Exprs {
+			expr(e)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	b = append(b, flags)
+	if flags&parseAssignment != 0 {
+		b = appendString(b, p.Assignment.Variable)
+	}
+	if flags&parseCycle != 0 {
+		b = appendString(b, p.Cycle.Group)
+		b = appendUvarint(b, uint64(len(p.Cycle.Values)))
+		for _, v := range p.Cycle.Values {
+			b = appendString(b, v)
+		}
+	}
+	if flags&parseLoop != 0 {
+		b = appendString(b, p.Loop.Variable)
+		if p.Loop.Limit != nil {
+			b = appendVarint(b, int64(*p.Loop.Limit))
+		}
+		b = appendVarint(b, int64(p.Loop.Offset))
+		b = appendVarint(b, int64(p.Loop.Cols))
+	}
+	if flags&parseWhen != 0 {
+		b = appendUvarint(b, uint64(len(p.When.Exprs)))
+	}
+	for _, n := range nodes {
+		if b, err = appendNode(b, n); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+// UnmarshalParseTableBinary decodes the binary representation of a ParseTable.
+func UnmarshalParseTableBinary(data []byte) (*ParseTable, error) {
+	d := nodeDecoder{data: data}
+	n := d.count()
+	t := &ParseTable{make(map[parseKey]*parseValue, n)}
+	for i := 0; i < n && d.err == nil; i++ {
+		sel := int(d.byte())
+		if sel >= len(parseSelectors) {
+			d.fail("unknown statement selector %d", sel)
+			break
+		}
+		key := parseKey{parseSelectors[sel], d.string()}
+		t.entries[key] = d.parseValue()
+	}
+	if d.err == nil && len(d.data) > 0 {
+		d.err = fmt.Errorf("extra data after the parse table")
+	}
+	if d.err != nil {
+		return nil, d.err
+	}
+	return t, nil
+}
+
+func (d *nodeDecoder) parseValue() *parseValue {
+	p := &parseValue{}
+	flags := d.byte()
+	if flags&parseAssignment != 0 {
+		p.Assignment.Variable = d.string()
+	}
+	if flags&parseCycle != 0 {
+		p.Cycle.Group = d.string()
+		p.Cycle.Values = make([]string, d.count())
+		for i := range p.Cycle.Values {
+			p.Cycle.Values[i] = d.string()
+		}
+	}
+	if flags&parseLoop != 0 {
+		p.Loop.Variable = d.string()
+		if flags&parseLoopLimit != 0 {
+			limit := d.varint()
+			p.Loop.Limit = &limit
+		}
+		p.Loop.Reversed = flags&parseLoopReversed != 0
+		p.Loop.Offset = d.varint()
+		p.Loop.Cols = d.varint()
+	}
+	var nexprs int
+	if flags&parseWhen != 0 {
+		nexprs = d.count()
+	}
+	if flags&parseVal != 0 {
+		p.val = d.node()
+	}
+	if flags&parseAssignment != 0 {
+		p.Assignment.ValueFn = d.expression()
+	}
+	if flags&parseLoop != 0 {
+		p.Loop.Expr = d.expression()
+	}
+	if flags&parseWhen != 0 {
+		p.When.Exprs = make([]Expression, nexprs)
+		for i := range p.When.Exprs {
+			p.When.Exprs[i] = d.expression()
+		}
+	}
+	return p
+}
+
+func (d *nodeDecoder) expression() Expression {
+	n := d.node()
+	if d.err != nil {
+		return nil
+	}
+	return newExpression(n)
+}
+
+// UnmarshalNodeBinary decodes the binary representation of a syntax tree.
+func UnmarshalNodeBinary(data []byte) (Node, error) {
+	d := nodeDecoder{data: data}
+	n := d.node()
+	if d.err == nil && len(d.data) > 0 {
+		d.err = fmt.Errorf("extra data after the expression")
+	}
+	if d.err != nil {
+		return nil, d.err
+	}
+	return n, nil
+}
+
+// A nodeDecoder decodes a node from the front of data. It records the first error, after which
+// its methods return zero values.
+type nodeDecoder struct {
+	data []byte
+	err  error
+}
+
+func (d *nodeDecoder) fail(format string, a ...interface{}) {
+	if d.err == nil {
+		d.err = fmt.Errorf(format, a...)
+	}
+	d.data = nil
+}
+
+func (d *nodeDecoder) byte() byte {
+	if len(d.data) == 0 {
+		d.fail("truncated expression")
+		return 0
+	}
+	c := d.data[0]
+	d.data = d.data[1:]
+	return c
+}
+
+func (d *nodeDecoder) uvarint() uint64 {
+	v, n := binary.Uvarint(d.data)
+	if n <= 0 {
+		d.fail("truncated expression")
+		return 0
+	}
+	d.data = d.data[n:]
+	return v
+}
+
+func (d *nodeDecoder) varint() int {
+	v, n := binary.Varint(d.data)
+	if n <= 0 {
+		d.fail("truncated expression")
+		return 0
+	}
+	d.data = d.data[n:]
+	return int(v)
+}
+
+// count decodes the length of a list, each of whose elements takes at least one byte.
+func (d *nodeDecoder) count() int {
+	n := d.uvarint()
+	if n > uint64(len(d.data)) {
+		d.fail("truncated expression")
+		return 0
+	}
+	return int(n)
+}
+
+func (d *nodeDecoder) string() string {
+	n := d.uvarint()
+	if n > uint64(len(d.data)) {
+		d.fail("truncated expression")
+		return ""
+	}
+	s := string(d.data[:n])
+	d.data = d.data[n:]
+	return s
+}
+
+func (d *nodeDecoder) node() Node { // nolint: gocyclo
+	switch typ := d.byte(); typ {
+	case binaryLiteral:
+		return &Literal{d.literal()}
+	case binaryVariable:
+		return &Variable{d.string()}
+	case binaryProperty:
+		name := d.string()
+		return &Property{d.node(), name}
+	case binaryIndex:
+		object := d.node()
+		return &Index{object, d.node()}
+	case binaryFilter:
+		name := d.string()
+		nargs := d.count()
+		n := &Filter{Receiver: d.node(), Name: name}
+		if nargs > 0 {
+			n.Args = make([]Node, nargs)
+			for i := range n.Args {
+				n.Args[i] = d.node()
+			}
+		}
+		return n
+	case binaryBinary:
+		op := d.string()
+		if _, ok := binaryOps[op]; !ok && op != "and" && op != "or" {
+			d.fail("unknown operator %q", op)
+			return nil
+		}
+		left := d.node()
+		return &Binary{op, left, d.node()}
+	case binaryRange:
+		start := d.node()
+		return &Range{start, d.node()}
+	default:
+		d.fail("unknown expression type %d", typ)
+		return nil
+	}
+}
+
+func (d *nodeDecoder) literal() interface{} {
+	switch typ := d.byte(); typ {
+	case binaryNil:
+		return nil
+	case binaryFalse:
+		return false
+	case binaryTrue:
+		return true
+	case binaryInt:
+		return d.varint()
+	case binaryFloat:
+		if len(d.data) < 8 {
+			d.fail("truncated expression")
+			return nil
+		}
+		v := math.Float64frombits(binary.BigEndian.Uint64(d.data))
+		d.data = d.data[8:]
+		return v
+	case binaryString:
+		return d.string()
+	default:
+		d.fail("invalid literal type %d", typ)
+		return nil
+	}
+}
@@ -0,0 +1,103 @@
+package expressions
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNode_MarshalBinary(t *testing.T) {
+	sources := []string{`1 < 2.0 and "s" contains x or true`, `a | upcase`, `a.b[0] | f: 2.0, nil, -3`, `false`}
+	for _, test := range nodeStringTests {
+		sources = append(sources, test.in)
+	}
+	for _, source := range sources {
+		expr, err := Parse(source)
+		require.NoError(t, err, source)
+		b, err := Syntax(expr).(interface{ MarshalBinary() ([]byte, error) }).MarshalBinary()
+		require.NoError(t, err, source)
+		n, err := UnmarshalNodeBinary(b)
+		require.NoError(t, err, source)
+		require.Equal(t, Syntax(expr), n, source)
+	}
+	rng := &Range{&Literal{1}, &Variable{"n"}}
+	b, err := rng.MarshalBinary()
+	require.NoError(t, err)
+	n, err := UnmarshalNodeBinary(b)
+	require.NoError(t, err)
+	require.Equal(t, rng, n)
+
+	_, err = (&Literal{[]int{1}}).MarshalBinary()
+	require.Error(t, err)
+	_, err = UnmarshalNodeBinary(b[:len(b)-1])
+	require.Error(t, err)
+	_, err = UnmarshalNodeBinary(append(b, 0))
+	require.Error(t, err)
+	_, err = UnmarshalNodeBinary([]byte{0xff})
+	require.Error(t, err)
+}
+
+func TestParseTable(t *testing.T) {
+	statements := []struct{ sel, source string }{
+		{AssignStatementSelector, `x = a | plus: 1`},
+		{CycleStatementSelector, `"g": "a", "b"`},
+		{LoopStatementSelector, `i in xs limit: 2 offset: 1 reversed`},
+		{WhenStatementSelector, `1, 2`},
+	}
+	parseAll := func(table *ParseTable) (stmts []*Statement, expr Expression) {
+		for _, s := range statements {
+			stmt, err := table.ParseStatement(s.sel, s.source)
+			require.NoError(t, err, s.source)
+			stmts = append(stmts, stmt)
+		}
+		expr, err := table.Parse(`x > 1`)
+		require.NoError(t, err)
+		_, err = table.Parse(`x >`)
+		require.Error(t, err)
+		return
+	}
+	table := NewParseTable()
+	stmts, expr := parseAll(table)
+	require.Equal(t, len(statements)+1, table.Len())
+
+	// parses outside the table aren't recorded
+	_, err := Parse(`zz`)
+	require.NoError(t, err)
+	require.Equal(t, len(statements)+1, table.Len())
+
+	b, err := table.MarshalBinary()
+	require.NoError(t, err)
+	loaded, err := UnmarshalParseTableBinary(b)
+	require.NoError(t, err)
+	require.Equal(t, table.Len(), loaded.Len())
+	again, err := loaded.MarshalBinary()
+	require.NoError(t, err)
+	require.Equal(t, b, again)
+
+	loadedStmts, loadedExpr := parseAll(loaded)
+	require.Equal(t, table.Len(), loaded.Len())
+	for i, stmt := range stmts {
+		require.Equal(t, stmt.Assignment.Variable, loadedStmts[i].Assignment.Variable)
+		require.Equal(t, stmt.Cycle, loadedStmts[i].Cycle)
+		require.Equal(t, stmt.loopModifiers, loadedStmts[i].loopModifiers)
+		require.Equal(t, stmt.Loop.Variable, loadedStmts[i].Loop.Variable)
+		require.Equal(t, Syntax(stmt.Loop.Expr), Syntax(loadedStmts[i].Loop.Expr))
+		require.Equal(t, Syntax(stmt.Assignment.ValueFn), Syntax(loadedStmts[i].Assignment.ValueFn))
+		require.Equal(t, len(stmt.When.Exprs), len(loadedStmts[i].When.Exprs))
+	}
+	require.Equal(t, Syntax(expr), Syntax(loadedExpr))
+	value, err := loadedExpr.Evaluate(NewContext(map[string]interface{}{"x": 2}, NewConfig()))
+	require.NoError(t, err)
+	require.Equal(t, true, value)
+
+	// a nil table parses each source
+	var none *ParseTable
+	_, noneExpr := parseAll(none)
+	require.Equal(t, Syntax(expr), Syntax(noneExpr))
+	require.Equal(t, 0, none.Len())
+
+	_, err = UnmarshalParseTableBinary(b[:len(b)-1])
+	require.Error(t, err)
+	_, err = UnmarshalParseTableBinary(append(b, 0))
+	require.Error(t, err)
+}
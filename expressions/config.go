@@ -1,8 +1,11 @@
 package expressions
 
+import "sort"
+
 // Config holds configuration information for expression interpretation.
 type Config struct {
 	filters map[string]interface{}
+	pure    map[string]bool // the filters that AddPureFilter added
 
 	FilterErrorMode   UndefinedFilterHandler
 	VariableErrorMode UndefinedVariableHandler
@@ -16,6 +19,21 @@ func NewConfig() Config {
 	}
 }
 
+// Clone returns a copy of the configuration, that filters can be added to without affecting the original.
+func (c Config) Clone() Config {
+	filters := make(map[string]interface{}, len(c.filters))
+	for k, v := range c.filters {
+		filters[k] = v
+	}
+	c.filters = filters
+	pure := make(map[string]bool, len(c.pure))
+	for k, v := range c.pure {
+		pure[k] = v
+	}
+	c.pure = pure
+	return c
+}
+
 func (c *Config) GetFilter(name string) interface{} {
 	if val, ok := c.filters[name]; ok {
 		return val
@@ -23,6 +41,27 @@ func (c *Config) GetFilter(name string) interface{} {
 	return c.FilterErrorMode.OnUndefinedFilter(name)
 }
 
+// HasFilter returns true if the named filter has been added.
+func (c *Config) HasFilter(name string) bool {
+	_, ok := c.filters[name]
+	return ok
+}
+
+// FilterNames returns the names of the filters that have been added, in sorted order.
+func (c *Config) FilterNames() []string {
+	names := make([]string, 0, len(c.filters))
+	for name := range c.filters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// IsPureFilter returns true if the named filter was added by AddPureFilter.
+func (c *Config) IsPureFilter(name string) bool {
+	return c.pure[name]
+}
+
 func (c *Config) GetVariable(bindings map[string]interface{}, name string) interface{} {
 	if val, ok := bindings[name]; ok {
 		return val
@@ -1,19 +1,21 @@
 package expressions
 
 import (
-	"reflect"
-
 	"github.com/etecs-ru/liquid/v2/values"
 )
 
 // Context is the expression evaluation context. It maps variables names to values.
 type Context interface {
-	ApplyFilter(string, valueFn, []valueFn) (interface{}, error)
+	// ApplyFilter applies the named filter to a receiver and arguments.
+	ApplyFilter(name string, receiver values.Value, args []values.Value) (interface{}, error)
 	// Clone returns a copy with a new variable binding map
 	// (so that copy.Set does effect the source context.)
 	Clone() Context
 	Get(string) interface{}
 	Set(string, interface{})
+	// filter looks up the named filter. An expression looks up a filter before it evaluates the
+	// filter's receiver and arguments.
+	filter(name string) interface{}
 }
 
 type context struct {
@@ -84,35 +86,14 @@ func (c *varsContext) Get(name string) interface{} {
 func (c *varsContext) Set(name string, value interface{}) {
 }
 
-func (ctx *varsContext) ApplyFilter(name string, receiver valueFn, params []valueFn) (interface{}, error) {
+func (ctx *varsContext) filter(name string) interface{} {
 	filter, ok := ctx.filters[name]
 	if !ok {
 		panic(UndefinedFilter(name))
 	}
-	fr := reflect.ValueOf(filter)
-	args := []interface{}{receiver(ctx).Interface()}
-	for i, param := range params {
-		if i+1 < fr.Type().NumIn() && isClosureInterfaceType(fr.Type().In(i+1)) {
-			expr, err := Parse(param(ctx).Interface().(string))
-			if err != nil {
-				panic(err)
-			}
-			args = append(args, closure{expr, ctx})
-		} else {
-			args = append(args, param(ctx).Interface())
-		}
-	}
-	out, err := values.Call(fr, args)
-	if err != nil {
-		if e, ok := err.(*values.CallParityError); ok {
-			err = &values.CallParityError{NumArgs: e.NumArgs - 1, NumParams: e.NumParams - 1}
-		}
-		return nil, err
-	}
-	switch out := out.(type) {
-	case []byte:
-		return string(out), nil
-	default:
-		return out, nil
-	}
+	return filter
+}
+
+func (ctx *varsContext) ApplyFilter(name string, receiver values.Value, args []values.Value) (interface{}, error) {
+	return callFilter(ctx, ctx.filter(name), filterArgs(receiver, args))
 }
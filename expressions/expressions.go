@@ -43,7 +43,8 @@ func (c closure) Evaluate() (interface{}, error) {
 }
 
 type expression struct {
-	evaluator func(Context) values.Value
+	node    Node
+	program *program
 }
 
 func (e expression) Evaluate(ctx Context) (out interface{}, err error) {
@@ -67,7 +68,7 @@ func (e expression) Evaluate(ctx Context) (out interface{}, err error) {
 			}
 		}
 	}()
-	return e.evaluator(ctx).Interface(), nil
+	return e.program.run(ctx), nil
 }
 
 // rethrownError is for use in a re-thrown error from panic recovery.
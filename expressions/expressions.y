@@ -3,7 +3,6 @@ package expressions
 import (
 	"fmt"
 	"math"
-	"github.com/etecs-ru/liquid/v2/values"
 )
 
 func init() {
@@ -16,7 +15,7 @@ func init() {
 %union {
    name     string
    val      interface{}
-   f        func(Context) values.Value
+   node     Node
    s        string
    ss       []string
    exprs    []Expression
@@ -24,9 +23,9 @@ func init() {
    cyclefn  func(string) Cycle
    loop     Loop
    loopmods loopModifiers
-   filter_params []valueFn
+   filter_params []Node
 }
-%type <f> expr rel filtered cond int_or_var loop_expr
+%type <node> expr rel filtered cond int_or_var loop_expr
 %type<filter_params> filter_params
 %type<exprs> exprs expr2
 %type<cycle> cycle
@@ -45,7 +44,7 @@ func init() {
 start:
   cond ';' { yylex.(*lexer).val = $1 }
 | ASSIGN IDENTIFIER '=' filtered ';' {
-	yylex.(*lexer).Assignment = Assignment{$2, &expression{$4}}
+	yylex.(*lexer).Assignment = Assignment{$2, newExpression($4)}
 }
 | CYCLE cycle ';' { yylex.(*lexer).Cycle = $2 }
 | LOOP loop ';'   { yylex.(*lexer).Loop = $2 }
@@ -70,10 +69,10 @@ cycle3:
 | ',' string cycle3 { $$ = append([]string{$2}, $3...) }
 ;
 
-exprs: expr expr2 { $$ = append([]Expression{&expression{$1}}, $2...) } ;
+exprs: expr expr2 { $$ = append([]Expression{newExpression($1)}, $2...) } ;
 expr2:
   /* empty */    { $$ = []Expression{} }
-| ',' expr expr2 { $$ = append([]Expression{&expression{$2}}, $3...) }
+| ',' expr expr2 { $$ = append([]Expression{newExpression($2)}, $3...) }
 ;
 
 string: LITERAL {
@@ -86,20 +85,20 @@ string: LITERAL {
 
 loop: IDENTIFIER IN loop_expr loop_modifiers {
 	name, expr, mods := $1, $3, $4
-	$$ = Loop{name, &expression{expr}, mods}
+	$$ = Loop{name, newExpression(expr), mods}
 }
 ;
 
 loop_expr : '(' int_or_var DOTDOT int_or_var ')' {
-  $$ = makeRangeExpr($2, $4)
+  $$ = &Range{$2, $4}
 }
 | filtered
 ;
 
 // TODO DRY w/ expr
 int_or_var:
-  LITERAL { val := $1; $$ = func(Context) values.Value { return values.ValueOf(val) } }
-| IDENTIFIER { name := $1; $$ = func(ctx Context) values.Value { return values.ValueOf(ctx.Get(name)) } }
+  LITERAL { $$ = &Literal{$1} }
+| IDENTIFIER { $$ = &Variable{$1} }
 ;
 
 loop_modifiers: /* empty */ { $$ = loopModifiers{Cols: math.MaxUint32} }
@@ -140,83 +139,37 @@ loop_modifiers: /* empty */ { $$ = loopModifiers{Cols: math.MaxUint32} }
 ;
 
 expr:
-  LITERAL { val := $1; $$ = func(Context) values.Value { return values.ValueOf(val) } }
-| IDENTIFIER { name := $1; $$ = func(ctx Context) values.Value { return values.ValueOf(ctx.Get(name)) } }
-| expr PROPERTY { $$ = makeObjectPropertyExpr($1, $2) }
-| expr '[' expr ']' { $$ = makeIndexExpr($1, $3) }
+  LITERAL { $$ = &Literal{$1} }
+| IDENTIFIER { $$ = &Variable{$1} }
+| expr PROPERTY { $$ = &Property{$1, $2} }
+| expr '[' expr ']' { $$ = &Index{$1, $3} }
 | '(' cond ')' { $$ = $2 }
 ;
 
 filtered:
   expr
-| filtered '|' IDENTIFIER { $$ = makeFilter($1, $3, nil) }
-| filtered '|' KEYWORD filter_params { $$ = makeFilter($1, $3, $4) }
+| filtered '|' IDENTIFIER { $$ = &Filter{$1, $3, nil} }
+| filtered '|' KEYWORD filter_params { $$ = &Filter{$1, $3, $4} }
 ;
 
 filter_params:
-  expr { $$ = []valueFn{$1} }
+  expr { $$ = []Node{$1} }
 | filter_params ',' expr
   { $$ = append($1, $3) }
 
 rel:
   filtered
-| expr EQ expr {
-	fa, fb := $1, $3
-	$$ = func(ctx Context) values.Value {
-		a, b := fa(ctx), fb(ctx)
-		return values.ValueOf(a.Equal(b))
-	}
-}
-| expr NEQ expr {
-	fa, fb := $1, $3
-	$$ = func(ctx Context) values.Value {
-		a, b := fa(ctx), fb(ctx)
-		return values.ValueOf(!a.Equal(b))
-	}
-}
-| expr '>' expr {
-	fa, fb := $1, $3
-	$$ = func(ctx Context) values.Value {
-		a, b := fa(ctx), fb(ctx)
-		return values.ValueOf(b.Less(a))
-	}
-}
-| expr '<' expr {
-	fa, fb := $1, $3
-	$$ = func(ctx Context) values.Value {
-		a, b := fa(ctx), fb(ctx)
-		return values.ValueOf(a.Less(b))
-	}
-}
-| expr GE expr {
-	fa, fb := $1, $3
-	$$ = func(ctx Context) values.Value {
-		a, b := fa(ctx), fb(ctx)
-		return values.ValueOf(b.Less(a) || a.Equal(b))
-	}
-}
-| expr LE expr {
-	fa, fb := $1, $3
-	$$ = func(ctx Context) values.Value {
-		a, b := fa(ctx), fb(ctx)
-		return values.ValueOf(a.Less(b) || a.Equal(b))
-	}
-}
-| expr CONTAINS expr { $$ = makeContainsExpr($1, $3) }
+| expr EQ expr { $$ = &Binary{"==", $1, $3} }
+| expr NEQ expr { $$ = &Binary{"!=", $1, $3} }
+| expr '>' expr { $$ = &Binary{">", $1, $3} }
+| expr '<' expr { $$ = &Binary{"<", $1, $3} }
+| expr GE expr { $$ = &Binary{">=", $1, $3} }
+| expr LE expr { $$ = &Binary{"<=", $1, $3} }
+| expr CONTAINS expr { $$ = &Binary{"contains", $1, $3} }
 ;
 
 cond:
   rel
-| cond AND rel {
-	fa, fb := $1, $3
-	$$ = func(ctx Context) values.Value {
-		return values.ValueOf(fa(ctx).Test() && fb(ctx).Test())
-	}
-}
-| cond OR rel {
-	fa, fb := $1, $3
-	$$ = func(ctx Context) values.Value {
-		return values.ValueOf(fa(ctx).Test() || fb(ctx).Test())
-	}
-}
+| cond AND rel { $$ = &Binary{"and", $1, $3} }
+| cond OR rel { $$ = &Binary{"or", $1, $3} }
 ;
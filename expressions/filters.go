@@ -31,8 +31,6 @@ func (e FilterError) Error() string {
 	return fmt.Sprintf("error applying filter %q (%q)", e.FilterName, e.Err)
 }
 
-type valueFn func(Context) values.Value
-
 // AddFilter adds a filter to the filter dictionary.
 func (c *Config) AddFilter(name string, fn interface{}) {
 	rf := reflect.ValueOf(fn)
@@ -50,6 +48,19 @@ func (c *Config) AddFilter(name string, fn interface{}) {
 		c.filters = make(map[string]interface{})
 	}
 	c.filters[name] = fn
+	delete(c.pure, name)
+}
+
+// AddPureFilter adds a filter whose result depends only on its arguments. The compiler can
+// evaluate an expression that applies only pure filters to literals once, when the template
+// is compiled, instead of each time that it is rendered. A filter that reads the clock, the
+// environment, or a closure parameter isn't pure.
+func (c *Config) AddPureFilter(name string, fn interface{}) {
+	c.AddFilter(name, fn)
+	if c.pure == nil {
+		c.pure = make(map[string]bool)
+	}
+	c.pure[name] = true
 }
 
 var (
@@ -61,18 +72,56 @@ func isClosureInterfaceType(t reflect.Type) bool {
 	return closureType.ConvertibleTo(t) && !interfaceType.ConvertibleTo(t)
 }
 
-func (ctx *context) ApplyFilter(name string, receiver valueFn, params []valueFn) (interface{}, error) {
-	fr := reflect.ValueOf(ctx.GetFilter(name))
-	args := []interface{}{receiver(ctx).Interface()}
+func (ctx *context) filter(name string) interface{} {
+	return ctx.GetFilter(name)
+}
+
+func (ctx *context) ApplyFilter(name string, receiver values.Value, args []values.Value) (interface{}, error) {
+	return callFilter(ctx, ctx.filter(name), filterArgs(receiver, args))
+}
+
+// ApplyFilter applies a filter, that Config.GetFilter returned, to args: the Go values of a
+// receiver and arguments that have been evaluated in ctx. It handles an error from the filter as
+// a filter expression does: it panics with a FilterError, that Expression.Evaluate recovers,
+// unless ctx was created with a FilterErrorMode that handles it.
+//
+// It's used by templates that are compiled to Go.
+func ApplyFilter(ctx Context, name string, filter interface{}, args []interface{}) interface{} {
+	result, err := callFilter(ctx, filter, args)
+	return filterResult(ctx, name, result, err)
+}
+
+// filterArgs returns the Go values of a filter's receiver and arguments, for callFilter.
+func filterArgs(receiver values.Value, params []values.Value) []interface{} {
+	args := make([]interface{}, len(params)+1)
+	args[0] = receiver.Interface()
 	for i, param := range params {
-		if i+1 < fr.Type().NumIn() && isClosureInterfaceType(fr.Type().In(i+1)) {
-			expr, err := Parse(param(ctx).Interface().(string))
+		args[i+1] = param.Interface()
+	}
+	return args
+}
+
+// callFilter applies a filter to args, whose first element is the filter's receiver. It replaces
+// the arguments of the filter's closure parameters by closures, in place. An argument that can't
+// be converted to its parameter's type is an error of the filter, as is an error that it returns.
+func callFilter(ctx Context, filter interface{}, args []interface{}) (result interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			e, ok := r.(values.TypeError)
+			if !ok {
+				panic(r)
+			}
+			result, err = nil, e
+		}
+	}()
+	fr := reflect.ValueOf(filter)
+	for i := 1; i < len(args) && i < fr.Type().NumIn(); i++ {
+		if isClosureInterfaceType(fr.Type().In(i)) {
+			expr, err := Parse(args[i].(string))
 			if err != nil {
 				panic(err)
 			}
-			args = append(args, closure{expr, ctx})
-		} else {
-			args = append(args, param(ctx).Interface())
+			args[i] = closure{expr, ctx}
 		}
 	}
 	out, err := values.Call(fr, args)
@@ -89,3 +138,23 @@ func (ctx *context) ApplyFilter(name string, receiver valueFn, params []valueFn)
 		return out, nil
 	}
 }
+
+// filterResult returns the result of a filter expression, whose filter returned result and err.
+func filterResult(ctx Context, name string, result interface{}, err error) interface{} {
+	if err != nil {
+		err := FilterError{
+			FilterName: name,
+			Err:        err,
+		}
+		c, ok := ctx.(*context)
+		if !ok {
+			panic(err)
+		}
+		h, ok := c.FilterErrorMode.(FilterErrorHandler)
+		if !ok {
+			panic(err)
+		}
+		result = h.OnFilterError(err)
+	}
+	return result
+}
@@ -19,11 +19,24 @@ func TestContext_AddFilter(t *testing.T) {
 	require.Panics(t, func() { cfg.AddFilter("f", 10) })
 }
 
+func TestConfig_AddPureFilter(t *testing.T) {
+	cfg := NewConfig()
+	cfg.AddPureFilter("f", func(int) int { return 0 })
+	cfg.AddFilter("g", func(int) int { return 0 })
+	require.True(t, cfg.HasFilter("f"))
+	require.True(t, cfg.IsPureFilter("f"))
+	require.False(t, cfg.IsPureFilter("g"))
+	require.False(t, cfg.IsPureFilter("undefined"))
+
+	clone := cfg.Clone()
+	clone.AddFilter("f", func(int) int { return 1 })
+	require.False(t, clone.IsPureFilter("f"))
+	require.True(t, cfg.IsPureFilter("f"))
+}
+
 func TestContext_runFilter(t *testing.T) {
 	cfg := NewConfig()
-	constant := func(value interface{}) valueFn {
-		return func(Context) values.Value { return values.ValueOf(value) }
-	}
+	constant := values.ValueOf
 	receiver := constant("self")
 
 	// basic
@@ -31,7 +44,7 @@ func TestContext_runFilter(t *testing.T) {
 		return "<" + s + ">"
 	})
 	ctx := NewContext(map[string]interface{}{"x": 10}, cfg)
-	out, err := ctx.ApplyFilter("f1", receiver, []valueFn{})
+	out, err := ctx.ApplyFilter("f1", receiver, nil)
 	require.NoError(t, err)
 	require.Equal(t, "<self>", out)
 
@@ -40,7 +53,7 @@ func TestContext_runFilter(t *testing.T) {
 		return fmt.Sprintf("(%s, %s)", a, b)
 	})
 	ctx = NewContext(map[string]interface{}{"x": 10}, cfg)
-	out, err = ctx.ApplyFilter("with_arg", receiver, []valueFn{constant("arg")})
+	out, err = ctx.ApplyFilter("with_arg", receiver, []values.Value{constant("arg")})
 	require.NoError(t, err)
 	require.Equal(t, "(self, arg)", out)
 
@@ -48,7 +61,7 @@ func TestContext_runFilter(t *testing.T) {
 	// TODO error return
 
 	// extra argument
-	_, err = ctx.ApplyFilter("with_arg", receiver, []valueFn{constant(1), constant(2)})
+	_, err = ctx.ApplyFilter("with_arg", receiver, []values.Value{constant(1), constant(2)})
 	require.Error(t, err)
 	require.Contains(t, err.Error(), "wrong number of arguments")
 	require.Contains(t, err.Error(), "given 2")
@@ -66,7 +79,7 @@ func TestContext_runFilter(t *testing.T) {
 		return fmt.Sprintf("(%v, %v)", a, value), nil
 	})
 	ctx = NewContext(map[string]interface{}{"x": 10}, cfg)
-	out, err = ctx.ApplyFilter("closure", receiver, []valueFn{constant("x |add: y")})
+	out, err = ctx.ApplyFilter("closure", receiver, []values.Value{constant("x |add: y")})
 	require.NoError(t, err)
 	require.Equal(t, "(self, 11)", out)
 }
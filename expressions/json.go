@@ -0,0 +1,193 @@
+package expressions
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// The JSON representation of a Node is an object whose "type" is the node type in lower case,
+// e.g. {"type": "property", "object": {"type": "variable", "name": "a"}, "name": "b"}.
+// The other fields are the node's fields, in lower case. A literal's "value" is a JSON string,
+// number, boolean, or null. A number with a decimal point or an exponent is a float; other
+// numbers are integers.
+
+// MarshalJSON implements json.Marshaler.
+func (n *Literal) MarshalJSON() ([]byte, error) {
+	var value json.RawMessage
+	switch n.Value.(type) {
+	case int, float64:
+		value = json.RawMessage(n.String())
+	default:
+		b, err := json.Marshal(n.Value)
+		if err != nil {
+			return nil, err
+		}
+		value = b
+	}
+	return json.Marshal(struct {
+		Type  string          `json:"type"`
+		Value json.RawMessage `json:"value"`
+	}{"literal", value})
+}
+
+// MarshalJSON implements json.Marshaler.
+func (n *Variable) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		Name string `json:"name"`
+	}{"variable", n.Name})
+}
+
+// MarshalJSON implements json.Marshaler.
+func (n *Property) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type   string `json:"type"`
+		Object Node   `json:"object"`
+		Name   string `json:"name"`
+	}{"property", n.Object, n.Name})
+}
+
+// MarshalJSON implements json.Marshaler.
+func (n *Index) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type   string `json:"type"`
+		Object Node   `json:"object"`
+		Index  Node   `json:"index"`
+	}{"index", n.Object, n.Index})
+}
+
+// MarshalJSON implements json.Marshaler.
+func (n *Filter) MarshalJSON() ([]byte, error) {
+	args := n.Args
+	if args == nil {
+		args = []Node{}
+	}
+	return json.Marshal(struct {
+		Type     string `json:"type"`
+		Receiver Node   `json:"receiver"`
+		Name     string `json:"name"`
+		Args     []Node `json:"args"`
+	}{"filter", n.Receiver, n.Name, args})
+}
+
+// MarshalJSON implements json.Marshaler.
+func (n *Binary) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type  string `json:"type"`
+		Op    string `json:"op"`
+		Left  Node   `json:"left"`
+		Right Node   `json:"right"`
+	}{"binary", n.Op, n.Left, n.Right})
+}
+
+// MarshalJSON implements json.Marshaler.
+func (n *Range) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type  string `json:"type"`
+		Start Node   `json:"start"`
+		End   Node   `json:"end"`
+	}{"range", n.Start, n.End})
+}
+
+// UnmarshalNode decodes the JSON representation of a syntax tree.
+func UnmarshalNode(data []byte) (Node, error) { // nolint: gocyclo
+	var v struct {
+		Type     string            `json:"type"`
+		Value    json.RawMessage   `json:"value"`
+		Name     string            `json:"name"`
+		Op       string            `json:"op"`
+		Object   json.RawMessage   `json:"object"`
+		Index    json.RawMessage   `json:"index"`
+		Receiver json.RawMessage   `json:"receiver"`
+		Args     []json.RawMessage `json:"args"`
+		Left     json.RawMessage   `json:"left"`
+		Right    json.RawMessage   `json:"right"`
+		Start    json.RawMessage   `json:"start"`
+		End      json.RawMessage   `json:"end"`
+	}
+	if len(data) == 0 || string(data) == "null" {
+		return nil, fmt.Errorf("missing expression")
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	var (
+		n    Node
+		errs []error
+	)
+	child := func(data json.RawMessage) Node {
+		c, err := UnmarshalNode(data)
+		errs = append(errs, err)
+		return c
+	}
+	switch v.Type {
+	case "literal":
+		value, err := unmarshalLiteral(v.Value)
+		errs = append(errs, err)
+		n = &Literal{value}
+	case "variable":
+		if v.Name == "" {
+			return nil, fmt.Errorf("variable requires a name")
+		}
+		n = &Variable{v.Name}
+	case "property":
+		if v.Name == "" {
+			return nil, fmt.Errorf("property requires a name")
+		}
+		n = &Property{child(v.Object), v.Name}
+	case "index":
+		n = &Index{child(v.Object), child(v.Index)}
+	case "filter":
+		if v.Name == "" {
+			return nil, fmt.Errorf("filter requires a name")
+		}
+		var args []Node
+		for _, arg := range v.Args {
+			args = append(args, child(arg))
+		}
+		n = &Filter{child(v.Receiver), v.Name, args}
+	case "binary":
+		if _, ok := binaryOps[v.Op]; !ok && v.Op != "and" && v.Op != "or" {
+			return nil, fmt.Errorf("unknown operator %q", v.Op)
+		}
+		n = &Binary{v.Op, child(v.Left), child(v.Right)}
+	case "range":
+		n = &Range{child(v.Start), child(v.End)}
+	default:
+		return nil, fmt.Errorf("unknown expression type %q", v.Type)
+	}
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return n, nil
+}
+
+func unmarshalLiteral(data json.RawMessage) (interface{}, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("literal requires a value")
+	}
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	var value interface{}
+	if err := d.Decode(&value); err != nil {
+		return nil, err
+	}
+	switch v := value.(type) {
+	case nil, bool, string:
+		return v, nil
+	case json.Number:
+		if !strings.ContainsAny(string(v), ".eE") {
+			if n, err := strconv.Atoi(string(v)); err == nil {
+				return n, nil
+			}
+		}
+		return v.Float64()
+	default:
+		return nil, fmt.Errorf("invalid literal %s", data)
+	}
+}
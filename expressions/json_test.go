@@ -0,0 +1,75 @@
+package expressions
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNode_MarshalJSON(t *testing.T) {
+	expr, err := Parse(`a.b[0] | f: 2.0, nil`)
+	require.NoError(t, err)
+	b, err := json.Marshal(Syntax(expr))
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"type": "filter",
+		"receiver": {
+			"type": "index",
+			"object": {"type": "property", "object": {"type": "variable", "name": "a"}, "name": "b"},
+			"index": {"type": "literal", "value": 0}
+		},
+		"name": "f",
+		"args": [{"type": "literal", "value": 2.0}, {"type": "literal", "value": null}]
+	}`, string(b))
+
+	// round trip
+	sources := []string{`1 < 2.0 and "s" contains x or true`, `a | upcase`}
+	for _, test := range nodeStringTests {
+		sources = append(sources, test.in)
+	}
+	for _, source := range sources {
+		expr, err := Parse(source)
+		require.NoError(t, err, source)
+		b, err := json.Marshal(Syntax(expr))
+		require.NoError(t, err, source)
+		n, err := UnmarshalNode(b)
+		require.NoError(t, err, source)
+		require.Equal(t, Syntax(expr), n, source)
+	}
+	rng := &Range{&Literal{1}, &Variable{"n"}}
+	b, err = json.Marshal(rng)
+	require.NoError(t, err)
+	n, err := UnmarshalNode(b)
+	require.NoError(t, err)
+	require.Equal(t, rng, n)
+}
+
+func TestUnmarshalNode(t *testing.T) {
+	n, err := UnmarshalNode([]byte(`{"type": "binary", "op": "+", "left": {"type": "literal", "value": 1}, "right": {"type": "literal", "value": 2}}`))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `unknown operator "+"`)
+	require.Nil(t, n)
+
+	errorTests := []struct{ in, expected string }{
+		{`{"type": "call"}`, `unknown expression type "call"`},
+		{`{"type": "variable"}`, `requires a name`},
+		{`{"type": "property", "name": "b"}`, `missing expression`},
+		{`{"type": "literal"}`, `requires a value`},
+		{`{"type": "literal", "value": [1]}`, `invalid literal`},
+		{`[]`, `cannot unmarshal`},
+	}
+	for _, test := range errorTests {
+		_, err := UnmarshalNode([]byte(test.in))
+		require.Error(t, err, test.in)
+		require.Contains(t, err.Error(), test.expected, test.in)
+	}
+
+	n, err = UnmarshalNode([]byte(`{"type": "binary", "op": ">", "left": {"type": "variable", "name": "x"}, "right": {"type": "literal", "value": 1e0}}`))
+	require.NoError(t, err)
+	require.Equal(t, &Binary{">", &Variable{"x"}, &Literal{1.0}}, n)
+	ctx := NewContext(map[string]interface{}{"x": 2}, NewConfig())
+	value, err := FromSyntax(n).Evaluate(ctx)
+	require.NoError(t, err)
+	require.Equal(t, true, value)
+}
@@ -6,8 +6,6 @@ package expressions
 
 import (
 	"fmt"
-
-	"github.com/etecs-ru/liquid/v2/values"
 )
 
 type parseValue struct {
@@ -15,7 +13,7 @@ type parseValue struct {
 	Cycle
 	Loop
 	When
-	val func(Context) values.Value
+	val Node
 }
 
 // SyntaxError represents a syntax error. The yacc-generated compiler
@@ -30,7 +28,7 @@ func Parse(source string) (expr Expression, err error) {
 	if err != nil {
 		return nil, err
 	}
-	return &expression{p.val}, nil
+	return newExpression(p.val), nil
 }
 
 func parse(source string) (p *parseValue, err error) {
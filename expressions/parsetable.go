@@ -0,0 +1,84 @@
+package expressions
+
+import (
+	"sort"
+)
+
+// A ParseTable holds the results of parsing expressions and statements, keyed by their source.
+// Its Parse and ParseStatement methods return the results that it holds, and parse and add the
+// sources that it doesn't hold. A cache of compiled templates stores the table that compiling a
+// template filled, so that compiling the template again doesn't parse the arguments of its tags
+// again; see render.Config.ParseTable.
+//
+// A nil *ParseTable parses each source. A ParseTable isn't safe for concurrent use.
+type ParseTable struct {
+	entries map[parseKey]*parseValue
+}
+
+// A parseKey is the selector and the source of a call to ParseStatement, or the source of a
+// call to Parse, whose selector is "".
+type parseKey struct{ sel, source string }
+
+// NewParseTable returns an empty table.
+func NewParseTable() *ParseTable {
+	return &ParseTable{map[parseKey]*parseValue{}}
+}
+
+// Len returns the number of entries in the table.
+func (t *ParseTable) Len() int {
+	if t == nil {
+		return 0
+	}
+	return len(t.entries)
+}
+
+// Parse is the package's Parse function, that looks up and records its result in the table.
+func (t *ParseTable) Parse(source string) (Expression, error) {
+	p, err := t.parse("", source)
+	if err != nil {
+		return nil, err
+	}
+	return newExpression(p.val), nil
+}
+
+// ParseStatement is the package's ParseStatement function, that looks up and records its result
+// in the table.
+func (t *ParseTable) ParseStatement(sel, source string) (*Statement, error) {
+	p, err := t.parse(sel, source)
+	if err != nil {
+		return nil, err
+	}
+	return &Statement{*p}, nil
+}
+
+// parse parses a selector and a source, unless the table holds the result.
+func (t *ParseTable) parse(sel, source string) (*parseValue, error) {
+	if t == nil {
+		return parse(sel + source)
+	}
+	key := parseKey{sel, source}
+	if p, ok := t.entries[key]; ok {
+		return p, nil
+	}
+	p, err := parse(sel + source)
+	if err != nil {
+		return nil, err
+	}
+	t.entries[key] = p
+	return p, nil
+}
+
+// keys returns the keys of the table, in sorted order.
+func (t *ParseTable) keys() []parseKey {
+	keys := make([]parseKey, 0, len(t.entries))
+	for k := range t.entries {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].sel != keys[j].sel {
+			return keys[i].sel < keys[j].sel
+		}
+		return keys[i].source < keys[j].source
+	})
+	return keys
+}
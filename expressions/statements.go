@@ -12,7 +12,7 @@ const (
 type Statement struct{ parseValue }
 
 // Expression returns a statement's expression function.
-// func (s *Statement) Expression() Expression { return &expression{s.val} }
+// func (s *Statement) Expression() Expression { return newExpression(s.val) }
 
 // An Assignment is a parse of an {% assign %} statement
 type Assignment struct {
@@ -8,9 +8,21 @@ type UndefinedFilterHandler interface {
 	OnUndefinedFilter(name string) interface{}
 }
 
+// A FilterErrorHandler is an UndefinedFilterHandler that also handles the errors that filters return.
+// OnFilterError returns the value to use in place of the filter's result.
+type FilterErrorHandler interface {
+	OnFilterError(err FilterError) interface{}
+}
+
 type StrictMode struct{}
 type LaxMode struct{}
 
+// WarnMode calls Warn with each undefined variable, undefined filter, and filter error, and
+// then carries on as LaxMode does. The result of a filter that fails is nil.
+type WarnMode struct {
+	Warn func(err error)
+}
+
 // Panics when a filter is missing.
 func (mode StrictMode) OnUndefinedFilter(name string) interface{} {
 	panic(UndefinedFilter(name))
@@ -34,3 +46,21 @@ func (mode LaxMode) OnUndefinedFilter(name string) interface{} {
 func (mode LaxMode) OnUndefinedVariable(name string) interface{} {
 	return nil
 }
+
+// Warns, and uses an identity function as a default, when a filter is missing.
+func (mode WarnMode) OnUndefinedFilter(name string) interface{} {
+	mode.Warn(UndefinedFilter(name))
+	return identityFilter
+}
+
+// Warns, and uses nil as a default value, when a variable is missing.
+func (mode WarnMode) OnUndefinedVariable(name string) interface{} {
+	mode.Warn(UndefinedVariable(name))
+	return nil
+}
+
+// Warns, and uses nil as the result, when a filter returns an error.
+func (mode WarnMode) OnFilterError(err FilterError) interface{} {
+	mode.Warn(err)
+	return nil
+}
@@ -0,0 +1,40 @@
+package expressions
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWarnMode(t *testing.T) {
+	var warnings []error
+	mode := WarnMode{Warn: func(err error) { warnings = append(warnings, err) }}
+	cfg := NewConfig()
+	cfg.VariableErrorMode, cfg.FilterErrorMode = mode, mode
+	cfg.AddFilter("fail", func(s string) (string, error) { return "", fmt.Errorf("failed") })
+	cfg.AddFilter("upcase", func(s string) string { return s + "!" })
+	ctx := NewContext(map[string]interface{}{"x": "x"}, cfg)
+
+	out, err := EvaluateString(`undefined`, ctx)
+	require.NoError(t, err)
+	require.Nil(t, out)
+
+	out, err = EvaluateString(`x | undefined_filter | upcase`, ctx)
+	require.NoError(t, err)
+	require.Equal(t, "x!", out)
+
+	out, err = EvaluateString(`x | fail`, ctx)
+	require.NoError(t, err)
+	require.Nil(t, out)
+
+	require.Len(t, warnings, 3)
+	require.Equal(t, UndefinedVariable("undefined"), warnings[0])
+	require.Equal(t, UndefinedFilter("undefined_filter"), warnings[1])
+	require.IsType(t, FilterError{}, warnings[2])
+
+	// a filter error is still an error in the other modes
+	cfg.FilterErrorMode = LaxMode{}
+	_, err = EvaluateString(`x | fail`, NewContext(map[string]interface{}{"x": "x"}, cfg))
+	require.Error(t, err)
+}
@@ -0,0 +1,301 @@
+package expressions
+
+import (
+	"sync"
+
+	"github.com/etecs-ru/liquid/v2/values"
+)
+
+// An opcode is an instruction of the expression VM. The VM is a stack machine: an instruction
+// pops its operands from the stack of values, and pushes its result.
+type opcode uint8
+
+const (
+	opConstant       opcode = iota // push constants[arg]
+	opVariable                     // push the variable names[arg]
+	opBuildVar                     // record the property names[arg] of the next variable, for FindVariables
+	opProperty                     // replace the top by its property constants[arg]
+	opIndex                        // pop the index, and replace the top by its element
+	opFilter                       // look up the filter names[arg], and push it onto the stack of filters
+	opApply                        // pop a filter, and arg arguments; replace the top by the filter's result
+	opInt                          // check that the top is an int
+	opRange                        // pop the end, and replace the top by the range from it to the end
+	opContains                     // pop the element, and replace the top by whether it contains it
+	opEqual                        // pop b, and replace a by a == b
+	opNotEqual                     // pop b, and replace a by a != b
+	opLess                         // pop b, and replace a by a < b
+	opGreater                      // pop b, and replace a by a > b
+	opLessOrEqual                  // pop b, and replace a by a <= b
+	opGreaterOrEqual               // pop b, and replace a by a >= b
+	opAnd                          // if the top tests false, replace it by false and jump to arg; else pop it
+	opOr                           // if the top tests true, replace it by true and jump to arg; else pop it
+	opTest                         // replace the top by whether it tests true
+)
+
+// An instruction is an opcode and its argument.
+type instruction struct {
+	op  opcode
+	arg int
+}
+
+// A program is the compiled form of an expression's syntax tree.
+type program struct {
+	code      []instruction
+	constants []values.Value
+	names     []string
+}
+
+// compileProgram compiles a syntax tree into a program.
+func compileProgram(n Node) *program {
+	p := &program{}
+	p.compile(n)
+	return p
+}
+
+func (p *program) emit(op opcode, arg int) int {
+	p.code = append(p.code, instruction{op, arg})
+	return len(p.code) - 1
+}
+
+func (p *program) constant(value interface{}) int {
+	p.constants = append(p.constants, values.ValueOf(value))
+	return len(p.constants) - 1
+}
+
+func (p *program) name(name string) int {
+	for i, s := range p.names {
+		if s == name {
+			return i
+		}
+	}
+	p.names = append(p.names, name)
+	return len(p.names) - 1
+}
+
+// compile appends the code that evaluates n. It evaluates the subexpressions in the order that
+// the tree-walking evaluator did, so that the first error and the variables that
+// NewVariablesContext records are the same.
+func (p *program) compile(n Node) { // nolint: gocyclo
+	switch n := n.(type) {
+	case *Literal:
+		p.emit(opConstant, p.constant(n.Value))
+	case *Variable:
+		p.emit(opVariable, p.name(n.Name))
+	case *Property:
+		p.emit(opBuildVar, p.name(n.Name))
+		p.compile(n.Object)
+		p.emit(opProperty, p.constant(n.Name))
+	case *Index:
+		p.compile(n.Object)
+		p.compile(n.Index)
+		p.emit(opIndex, 0)
+	case *Filter:
+		p.emit(opFilter, p.name(n.Name))
+		p.compile(n.Receiver)
+		for _, arg := range n.Args {
+			p.compile(arg)
+		}
+		p.emit(opApply, len(n.Args))
+	case *Range:
+		p.compile(n.Start)
+		p.emit(opInt, 0)
+		p.compile(n.End)
+		p.emit(opRange, 0)
+	case *Binary:
+		switch n.Op {
+		case "and", "or":
+			op := opAnd
+			if n.Op == "or" {
+				op = opOr
+			}
+			p.compile(n.Left)
+			jump := p.emit(op, 0)
+			p.compile(n.Right)
+			p.emit(opTest, 0)
+			p.code[jump].arg = len(p.code)
+			return
+		}
+		op, ok := binaryOps[n.Op]
+		if !ok {
+			panic(InterpreterError("unknown operator " + n.Op))
+		}
+		p.compile(n.Left)
+		p.compile(n.Right)
+		p.emit(op, 0)
+	default:
+		panic(InterpreterError("unknown expression node"))
+	}
+}
+
+var binaryOps = map[string]opcode{
+	"contains": opContains,
+	"==":       opEqual,
+	"!=":       opNotEqual,
+	"<":        opLess,
+	">":        opGreater,
+	"<=":       opLessOrEqual,
+	">=":       opGreaterOrEqual,
+}
+
+// A vm holds the stacks of an evaluation. The stacks are reused, through vmPool, so that
+// evaluation doesn't allocate them.
+type vm struct {
+	stack   []slot
+	filters []pendingFilter
+	args    []interface{}
+}
+
+// A slot is an entry of the stack of values. It holds a Value, or the Go value that the Value
+// would wrap. The VM creates the Value only if an instruction needs it, since values.ValueOf
+// allocates.
+type slot struct {
+	value values.Value // nil if it hasn't been created
+	raw   interface{}  // the Go value, if value is nil
+}
+
+func (s *slot) Value() values.Value {
+	if s.value == nil {
+		s.value = values.ValueOf(s.raw)
+	}
+	return s.value
+}
+
+// Interface returns s.Value().Interface(), without creating the Value for common types.
+func (s *slot) Interface() interface{} {
+	if s.value != nil {
+		return s.value.Interface()
+	}
+	switch s.raw.(type) {
+	case nil, bool, int, float64, string, []interface{}, map[string]interface{}:
+		return s.raw
+	}
+	return s.Value().Interface()
+}
+
+// property returns the slot of the named property. key is values.ValueOf(name).
+func (s *slot) property(name string, key values.Value) slot {
+	// This is mapValue.PropertyValue, for the common type of a map, without creating the Value.
+	if m, ok := s.raw.(map[string]interface{}); ok && s.value == nil {
+		if v, ok := m[name]; ok {
+			return slot{raw: v}
+		}
+		if name == "size" {
+			return slot{raw: len(m)}
+		}
+		return slot{raw: nil}
+	}
+	return slot{value: s.Value().PropertyValue(key)}
+}
+
+// A pendingFilter is a filter that has been looked up, and is waiting for its arguments.
+type pendingFilter struct {
+	name string
+	fn   interface{}
+}
+
+var vmPool = sync.Pool{New: func() interface{} { return new(vm) }}
+
+// run evaluates the program in ctx. It panics with the errors that Expression.Evaluate recovers.
+func (p *program) run(ctx Context) interface{} { // nolint: gocyclo
+	m := vmPool.Get().(*vm)
+	vars, _ := ctx.(*varsContext)
+	stack, filters := m.stack[:0], m.filters[:0]
+	for pc := 0; pc < len(p.code); pc++ {
+		in := p.code[pc]
+		top := len(stack) - 1
+		switch in.op {
+		case opConstant:
+			stack = append(stack, slot{value: p.constants[in.arg]})
+		case opVariable:
+			stack = append(stack, slot{raw: ctx.Get(p.names[in.arg])})
+		case opBuildVar:
+			if vars != nil {
+				vars.BuildVar(p.names[in.arg])
+			}
+		case opProperty:
+			stack[top] = stack[top].property(p.constants[in.arg].Interface().(string), p.constants[in.arg])
+		case opIndex:
+			stack[top-1] = slot{value: stack[top-1].Value().IndexValue(stack[top].Value())}
+			stack = stack[:top]
+		case opFilter:
+			name := p.names[in.arg]
+			filters = append(filters, pendingFilter{name, ctx.filter(name)})
+		case opApply:
+			f := filters[len(filters)-1]
+			filters = filters[:len(filters)-1]
+			recv := top - in.arg
+			args := m.args[:0]
+			for i := recv; i <= top; i++ {
+				args = append(args, stack[i].Interface())
+			}
+			result, err := callFilter(ctx, f.fn, args)
+			m.args = args
+			stack[recv] = slot{raw: filterResult(ctx, f.name, result, err)}
+			stack = stack[:recv+1]
+		case opInt:
+			stack[top].Value().Int()
+		case opRange:
+			r := values.NewRange(stack[top-1].Value().Int(), stack[top].Value().Int())
+			stack[top-1] = slot{value: values.ValueOf(r)}
+			stack = stack[:top]
+		case opAnd:
+			if !stack[top].Value().Test() {
+				stack[top] = slot{raw: false}
+				pc = in.arg - 1
+			} else {
+				stack = stack[:top]
+			}
+		case opOr:
+			if stack[top].Value().Test() {
+				stack[top] = slot{raw: true}
+				pc = in.arg - 1
+			} else {
+				stack = stack[:top]
+			}
+		case opTest:
+			stack[top] = slot{raw: stack[top].Value().Test()}
+		default:
+			a, b := stack[top-1].Value(), stack[top].Value()
+			stack[top-1] = slot{raw: compare(in.op, a, b)}
+			stack = stack[:top]
+		}
+	}
+	result := stack[0].Interface()
+	// Clear the stacks, so that the pool doesn't keep their values alive. If the evaluation
+	// panics, the vm isn't returned to the pool.
+	stack, filters, args := stack[:cap(stack)], filters[:cap(filters)], m.args[:cap(m.args)]
+	for i := range stack {
+		stack[i] = slot{}
+	}
+	for i := range filters {
+		filters[i] = pendingFilter{}
+	}
+	for i := range args {
+		args[i] = nil
+	}
+	m.stack, m.filters, m.args = stack[:0], filters[:0], args[:0]
+	vmPool.Put(m)
+	return result
+}
+
+// compare implements the binary operators other than and and or.
+func compare(op opcode, a, b values.Value) bool {
+	switch op {
+	case opContains:
+		return a.Contains(b)
+	case opEqual:
+		return a.Equal(b)
+	case opNotEqual:
+		return !a.Equal(b)
+	case opLess:
+		return a.Less(b)
+	case opGreater:
+		return b.Less(a)
+	case opLessOrEqual:
+		return a.Less(b) || a.Equal(b)
+	case opGreaterOrEqual:
+		return b.Less(a) || a.Equal(b)
+	default:
+		panic(InterpreterError("unknown opcode"))
+	}
+}
@@ -0,0 +1,121 @@
+package expressions
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/etecs-ru/liquid/v2/values"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProgram_run(t *testing.T) {
+	cfg := NewConfig()
+	cfg.VariableErrorMode = StrictMode{}
+	cfg.AddFilter("f", func(a, b interface{}) interface{} { return []interface{}{a, b} })
+	ctx := NewContext(map[string]interface{}{"a": map[string]interface{}{"b": 1}, "s": "str"}, cfg)
+	evaluate := func(source string) (interface{}, error) {
+		expr, err := Parse(source)
+		require.NoError(t, err, source)
+		return expr.Evaluate(ctx)
+	}
+
+	// and and or don't evaluate their right operand if their left operand decides them
+	for _, test := range []struct {
+		in       string
+		expected interface{}
+	}{
+		{`false and x`, false},
+		{`a.b and a.x`, false},
+		{`true or x`, true},
+		{`a.x or a.b == 1`, true},
+		{`a.x or a.b == 1 and s contains "t"`, true},
+		{`a.size`, 1},
+		{`a["b"] | f: s.size`, []interface{}{1, 3}},
+	} {
+		value, err := evaluate(test.in)
+		require.NoError(t, err, test.in)
+		require.Equal(t, test.expected, value, test.in)
+	}
+
+	// the first error is the one that the tree-walking evaluator reported
+	for _, test := range []struct {
+		in       string
+		expected error
+	}{
+		{`true and x`, UndefinedVariable("x")},
+		{`x | nope`, UndefinedFilter("nope")},
+		{`x | f: y`, UndefinedVariable("x")},
+		{`a.b | f: y`, UndefinedVariable("y")},
+		{`a[x.y]`, UndefinedVariable("x")},
+	} {
+		_, err := evaluate(test.in)
+		require.Equal(t, test.expected, err, test.in)
+	}
+
+	stmt, err := ParseStatement(LoopStatementSelector, "i in (s..x)")
+	require.NoError(t, err)
+	_, err = stmt.Loop.Expr.Evaluate(ctx)
+	require.IsType(t, values.TypeError(""), err)
+}
+
+func TestProgram_run_filterErrors(t *testing.T) {
+	var warnings []error
+	cfg := NewConfig()
+	cfg.FilterErrorMode = WarnMode{func(err error) { warnings = append(warnings, err) }}
+	cfg.AddFilter("fail", func(interface{}) (interface{}, error) { return nil, errors.New("failed") })
+	ctx := NewContext(map[string]interface{}{}, cfg)
+	value, err := EvaluateString(`1 | fail | nope`, ctx)
+	require.NoError(t, err)
+	require.Nil(t, value)
+	// a filter is looked up before its receiver is evaluated
+	require.Equal(t, []error{
+		UndefinedFilter("nope"),
+		FilterError{FilterName: "fail", Err: errors.New("failed")},
+	}, warnings)
+}
+
+func TestProgram_run_variables(t *testing.T) {
+	cfg := NewConfig()
+	cfg.AddFilter("f", func(a, b interface{}) interface{} { return a })
+	variables := map[string]interface{}{}
+	expr, err := Parse(`a.b[c.d] | f: e.g.h`)
+	require.NoError(t, err)
+	_, err = expr.Evaluate(NewVariablesContext(variables, cfg))
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"a.b": struct{}{}, "c.d": struct{}{}, "e.g.h": struct{}{}}, variables)
+}
+
+func BenchmarkEvaluate_filter(b *testing.B) {
+	cfg := NewConfig()
+	cfg.AddFilter("append", func(s, suffix string) string { return s + suffix })
+	ctx := NewContext(map[string]interface{}{
+		"a": map[string]interface{}{"b": "x"},
+		"c": "y",
+	}, cfg)
+	expr, err := Parse(`a.b | append: c`)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := expr.Evaluate(ctx); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkEvaluate_comparison(b *testing.B) {
+	ctx := NewContext(map[string]interface{}{"a": map[string]interface{}{"b": 3}, "c": 2}, NewConfig())
+	expr, err := Parse(`a.b > c and a.b < 10`)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := expr.Evaluate(ctx); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -1,3 +1,5 @@
+// Code generated by goyacc expressions.y. DO NOT EDIT.
+
 //line expressions.y:2
 package expressions
 
@@ -6,7 +8,6 @@ import __yyfmt__ "fmt"
 //line expressions.y:2
 import (
 	"fmt"
-	"github.com/etecs-ru/liquid/v2/values"
 	"math"
 )
 
@@ -16,12 +17,12 @@ func init() {
 	_ = fmt.Sprint("")
 }
 
-//line expressions.y:16
+//line expressions.y:15
 type yySymType struct {
 	yys           int
 	name          string
 	val           interface{}
-	f             func(Context) values.Value
+	node          Node
 	s             string
 	ss            []string
 	exprs         []Expression
@@ -29,7 +30,7 @@ type yySymType struct {
 	cyclefn       func(string) Cycle
 	loop          Loop
 	loopmods      loopModifiers
-	filter_params []valueFn
+	filter_params []Node
 }
 
 const LITERAL = 57346
@@ -84,6 +85,7 @@ var yyToknames = [...]string{
 	"'['",
 	"']'",
 }
+
 var yyStatenames = [...]string{}
 
 const yyEofCode = 1
@@ -91,7 +93,7 @@ const yyErrCode = 2
 const yyInitialStackSize = 16
 
 //line yacctab:1
-var yyExca = [...]int{
+var yyExca = [...]int8{
 	-1, 1,
 	1, -1,
 	-2, 0,
@@ -107,8 +109,7 @@ const yyPrivate = 57344
 
 const yyLast = 104
 
-var yyAct = [...]int{
-
+var yyAct = [...]int8{
 	9, 74, 46, 41, 8, 87, 78, 23, 14, 15,
 	18, 10, 11, 25, 42, 3, 4, 5, 6, 25,
 	37, 58, 10, 11, 40, 42, 45, 50, 51, 52,
@@ -121,8 +122,8 @@ var yyAct = [...]int{
 	85, 86, 83, 19, 34, 2, 1, 73, 20, 39,
 	17, 22, 67, 63,
 }
-var yyPact = [...]int{
 
+var yyPact = [...]int16{
 	7, -1000, 60, 76, 89, 71, 18, -1000, 19, 49,
 	-1000, -1000, 18, -1000, 18, 18, -6, 14, -3, -1000,
 	10, 38, 1, 39, 83, -1000, 18, 18, 18, 18,
@@ -133,29 +134,29 @@ var yyPact = [...]int{
 	-1000, -1000, -1000, 69, 20, -1000, -1000, -1000, 18, -1000,
 	88, 86, 6, -1000, -25, -1000, -1000, -1000,
 }
-var yyPgo = [...]int{
 
+var yyPgo = [...]int8{
 	0, 0, 71, 4, 94, 1, 103, 102, 101, 2,
 	100, 99, 3, 98, 97, 10, 96,
 }
-var yyR1 = [...]int{
 
+var yyR1 = [...]int8{
 	0, 16, 16, 16, 16, 16, 10, 11, 11, 12,
 	12, 8, 9, 9, 15, 13, 6, 6, 5, 5,
 	14, 14, 14, 1, 1, 1, 1, 1, 3, 3,
 	3, 7, 7, 2, 2, 2, 2, 2, 2, 2,
 	2, 4, 4, 4,
 }
-var yyR2 = [...]int{
 
+var yyR2 = [...]int8{
 	0, 2, 5, 3, 3, 3, 2, 3, 1, 0,
 	3, 2, 0, 3, 1, 4, 5, 1, 1, 1,
 	0, 2, 3, 1, 1, 2, 4, 3, 1, 3,
 	4, 1, 3, 1, 3, 3, 3, 3, 3, 3,
 	3, 1, 3, 3,
 }
-var yyChk = [...]int{
 
+var yyChk = [...]int16{
 	-1000, -16, -4, 8, 9, 10, 11, -2, -3, -1,
 	4, 5, 29, 25, 17, 18, 5, -10, -15, 4,
 	-13, 5, -8, -1, 22, 7, 31, 12, 13, 24,
@@ -166,8 +167,8 @@ var yyChk = [...]int{
 	25, -12, -12, -14, -5, 4, 5, -9, 28, 5,
 	6, 20, -1, 4, -5, 4, 5, 30,
 }
-var yyDef = [...]int{
 
+var yyDef = [...]int8{
 	0, -2, 0, 0, 0, 0, 0, 41, 33, 28,
 	23, 24, 0, 1, 0, 0, 0, 0, 9, 14,
 	0, 0, 0, 12, 0, 25, 0, 0, 0, 0,
@@ -178,8 +179,8 @@ var yyDef = [...]int{
 	2, 7, 10, 15, 0, -2, -2, 13, 0, 21,
 	0, 0, 32, 22, 0, 18, 19, 16,
 }
-var yyTok1 = [...]int{
 
+var yyTok1 = [...]int8{
 	1, 3, 3, 3, 3, 3, 3, 3, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
@@ -194,12 +195,13 @@ var yyTok1 = [...]int{
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
 	3, 3, 3, 3, 22,
 }
-var yyTok2 = [...]int{
 
+var yyTok2 = [...]int8{
 	2, 3, 4, 5, 6, 7, 8, 9, 10, 11,
 	12, 13, 14, 15, 16, 17, 18, 19, 20,
 }
-var yyTok3 = [...]int{
+
+var yyTok3 = [...]int8{
 	0,
 }
 
@@ -281,9 +283,9 @@ func yyErrorMessage(state, lookAhead int) string {
 	expected := make([]int, 0, 4)
 
 	// Look for shiftable tokens.
-	base := yyPact[state]
+	base := int(yyPact[state])
 	for tok := TOKSTART; tok-1 < len(yyToknames); tok++ {
-		if n := base + tok; n >= 0 && n < yyLast && yyChk[yyAct[n]] == tok {
+		if n := base + tok; n >= 0 && n < yyLast && int(yyChk[int(yyAct[n])]) == tok {
 			if len(expected) == cap(expected) {
 				return res
 			}
@@ -293,13 +295,13 @@ func yyErrorMessage(state, lookAhead int) string {
 
 	if yyDef[state] == -2 {
 		i := 0
-		for yyExca[i] != -1 || yyExca[i+1] != state {
+		for yyExca[i] != -1 || int(yyExca[i+1]) != state {
 			i += 2
 		}
 
 		// Look for tokens that we accept or reduce.
 		for i += 2; yyExca[i] >= 0; i += 2 {
-			tok := yyExca[i]
+			tok := int(yyExca[i])
 			if tok < TOKSTART || yyExca[i+1] == 0 {
 				continue
 			}
@@ -330,30 +332,30 @@ func yylex1(lex yyLexer, lval *yySymType) (char, token int) {
 	token = 0
 	char = lex.Lex(lval)
 	if char <= 0 {
-		token = yyTok1[0]
+		token = int(yyTok1[0])
 		goto out
 	}
 	if char < len(yyTok1) {
-		token = yyTok1[char]
+		token = int(yyTok1[char])
 		goto out
 	}
 	if char >= yyPrivate {
 		if char < yyPrivate+len(yyTok2) {
-			token = yyTok2[char-yyPrivate]
+			token = int(yyTok2[char-yyPrivate])
 			goto out
 		}
 	}
 	for i := 0; i < len(yyTok3); i += 2 {
-		token = yyTok3[i+0]
+		token = int(yyTok3[i+0])
 		if token == char {
-			token = yyTok3[i+1]
+			token = int(yyTok3[i+1])
 			goto out
 		}
 	}
 
 out:
 	if token == 0 {
-		token = yyTok2[1] /* unknown char */
+		token = int(yyTok2[1]) /* unknown char */
 	}
 	if yyDebug >= 3 {
 		__yyfmt__.Printf("lex %s(%d)\n", yyTokname(token), uint(char))
@@ -408,7 +410,7 @@ yystack:
 	yyS[yyp].yys = yystate
 
 yynewstate:
-	yyn = yyPact[yystate]
+	yyn = int(yyPact[yystate])
 	if yyn <= yyFlag {
 		goto yydefault /* simple state */
 	}
@@ -419,8 +421,8 @@ yynewstate:
 	if yyn < 0 || yyn >= yyLast {
 		goto yydefault
 	}
-	yyn = yyAct[yyn]
-	if yyChk[yyn] == yytoken { /* valid shift */
+	yyn = int(yyAct[yyn])
+	if int(yyChk[yyn]) == yytoken { /* valid shift */
 		yyrcvr.char = -1
 		yytoken = -1
 		yyVAL = yyrcvr.lval
@@ -433,7 +435,7 @@ yynewstate:
 
 yydefault:
 	/* default state action */
-	yyn = yyDef[yystate]
+	yyn = int(yyDef[yystate])
 	if yyn == -2 {
 		if yyrcvr.char < 0 {
 			yyrcvr.char, yytoken = yylex1(yylex, &yyrcvr.lval)
@@ -442,18 +444,18 @@ yydefault:
 		/* look through exception table */
 		xi := 0
 		for {
-			if yyExca[xi+0] == -1 && yyExca[xi+1] == yystate {
+			if yyExca[xi+0] == -1 && int(yyExca[xi+1]) == yystate {
 				break
 			}
 			xi += 2
 		}
 		for xi += 2; ; xi += 2 {
-			yyn = yyExca[xi+0]
+			yyn = int(yyExca[xi+0])
 			if yyn < 0 || yyn == yytoken {
 				break
 			}
 		}
-		yyn = yyExca[xi+1]
+		yyn = int(yyExca[xi+1])
 		if yyn < 0 {
 			goto ret0
 		}
@@ -475,10 +477,10 @@ yydefault:
 
 			/* find a state where "error" is a legal shift action */
 			for yyp >= 0 {
-				yyn = yyPact[yyS[yyp].yys] + yyErrCode
+				yyn = int(yyPact[yyS[yyp].yys]) + yyErrCode
 				if yyn >= 0 && yyn < yyLast {
-					yystate = yyAct[yyn] /* simulate a shift of "error" */
-					if yyChk[yystate] == yyErrCode {
+					yystate = int(yyAct[yyn]) /* simulate a shift of "error" */
+					if int(yyChk[yystate]) == yyErrCode {
 						goto yystack
 					}
 				}
@@ -514,7 +516,7 @@ yydefault:
 	yypt := yyp
 	_ = yypt // guard against "declared and not used"
 
-	yyp -= yyR2[yyn]
+	yyp -= int(yyR2[yyn])
 	// yyp is now the index of $0. Perform the default action. Iff the
 	// reduced production is ε, $1 is possibly out of range.
 	if yyp+1 >= len(yyS) {
@@ -525,16 +527,16 @@ yydefault:
 	yyVAL = yyS[yyp+1]
 
 	/* consult goto table to find next state */
-	yyn = yyR1[yyn]
-	yyg := yyPgo[yyn]
+	yyn = int(yyR1[yyn])
+	yyg := int(yyPgo[yyn])
 	yyj := yyg + yyS[yyp].yys + 1
 
 	if yyj >= yyLast {
-		yystate = yyAct[yyg]
+		yystate = int(yyAct[yyg])
 	} else {
-		yystate = yyAct[yyj]
-		if yyChk[yystate] != -yyn {
-			yystate = yyAct[yyg]
+		yystate = int(yyAct[yyj])
+		if int(yyChk[yystate]) != -yyn {
+			yystate = int(yyAct[yyg])
 		}
 	}
 	// dummy call; replaced with literal code
@@ -542,87 +544,87 @@ yydefault:
 
 	case 1:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line expressions.y:46
+//line expressions.y:45
 		{
-			yylex.(*lexer).val = yyDollar[1].f
+			yylex.(*lexer).val = yyDollar[1].node
 		}
 	case 2:
 		yyDollar = yyS[yypt-5 : yypt+1]
-		//line expressions.y:47
+//line expressions.y:46
 		{
-			yylex.(*lexer).Assignment = Assignment{yyDollar[2].name, &expression{yyDollar[4].f}}
+			yylex.(*lexer).Assignment = Assignment{yyDollar[2].name, newExpression(yyDollar[4].node)}
 		}
 	case 3:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line expressions.y:50
+//line expressions.y:49
 		{
 			yylex.(*lexer).Cycle = yyDollar[2].cycle
 		}
 	case 4:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line expressions.y:51
+//line expressions.y:50
 		{
 			yylex.(*lexer).Loop = yyDollar[2].loop
 		}
 	case 5:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line expressions.y:52
+//line expressions.y:51
 		{
 			yylex.(*lexer).When = When{yyDollar[2].exprs}
 		}
 	case 6:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line expressions.y:55
+//line expressions.y:54
 		{
 			yyVAL.cycle = yyDollar[2].cyclefn(yyDollar[1].s)
 		}
 	case 7:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line expressions.y:58
+//line expressions.y:57
 		{
 			h, t := yyDollar[2].s, yyDollar[3].ss
 			yyVAL.cyclefn = func(g string) Cycle { return Cycle{g, append([]string{h}, t...)} }
 		}
 	case 8:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line expressions.y:62
+//line expressions.y:61
 		{
 			vals := yyDollar[1].ss
 			yyVAL.cyclefn = func(h string) Cycle { return Cycle{Values: append([]string{h}, vals...)} }
 		}
 	case 9:
 		yyDollar = yyS[yypt-0 : yypt+1]
-		//line expressions.y:69
+//line expressions.y:68
 		{
 			yyVAL.ss = []string{}
 		}
 	case 10:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line expressions.y:70
+//line expressions.y:69
 		{
 			yyVAL.ss = append([]string{yyDollar[2].s}, yyDollar[3].ss...)
 		}
 	case 11:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line expressions.y:73
+//line expressions.y:72
 		{
-			yyVAL.exprs = append([]Expression{&expression{yyDollar[1].f}}, yyDollar[2].exprs...)
+			yyVAL.exprs = append([]Expression{newExpression(yyDollar[1].node)}, yyDollar[2].exprs...)
 		}
 	case 12:
 		yyDollar = yyS[yypt-0 : yypt+1]
-		//line expressions.y:75
+//line expressions.y:74
 		{
 			yyVAL.exprs = []Expression{}
 		}
 	case 13:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line expressions.y:76
+//line expressions.y:75
 		{
-			yyVAL.exprs = append([]Expression{&expression{yyDollar[2].f}}, yyDollar[3].exprs...)
+			yyVAL.exprs = append([]Expression{newExpression(yyDollar[2].node)}, yyDollar[3].exprs...)
 		}
 	case 14:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line expressions.y:79
+//line expressions.y:78
 		{
 			s, ok := yyDollar[1].val.(string)
 			if !ok {
@@ -632,40 +634,38 @@ yydefault:
 		}
 	case 15:
 		yyDollar = yyS[yypt-4 : yypt+1]
-		//line expressions.y:87
+//line expressions.y:86
 		{
-			name, expr, mods := yyDollar[1].name, yyDollar[3].f, yyDollar[4].loopmods
-			yyVAL.loop = Loop{name, &expression{expr}, mods}
+			name, expr, mods := yyDollar[1].name, yyDollar[3].node, yyDollar[4].loopmods
+			yyVAL.loop = Loop{name, newExpression(expr), mods}
 		}
 	case 16:
 		yyDollar = yyS[yypt-5 : yypt+1]
-		//line expressions.y:93
+//line expressions.y:92
 		{
-			yyVAL.f = makeRangeExpr(yyDollar[2].f, yyDollar[4].f)
+			yyVAL.node = &Range{yyDollar[2].node, yyDollar[4].node}
 		}
 	case 18:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line expressions.y:101
+//line expressions.y:100
 		{
-			val := yyDollar[1].val
-			yyVAL.f = func(Context) values.Value { return values.ValueOf(val) }
+			yyVAL.node = &Literal{yyDollar[1].val}
 		}
 	case 19:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line expressions.y:102
+//line expressions.y:101
 		{
-			name := yyDollar[1].name
-			yyVAL.f = func(ctx Context) values.Value { return values.ValueOf(ctx.Get(name)) }
+			yyVAL.node = &Variable{yyDollar[1].name}
 		}
 	case 20:
 		yyDollar = yyS[yypt-0 : yypt+1]
-		//line expressions.y:105
+//line expressions.y:104
 		{
 			yyVAL.loopmods = loopModifiers{Cols: math.MaxUint32}
 		}
 	case 21:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line expressions.y:106
+//line expressions.y:105
 		{
 			switch yyDollar[2].name {
 			case "reversed":
@@ -677,7 +677,7 @@ yydefault:
 		}
 	case 22:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line expressions.y:115
+//line expressions.y:114
 		{ // TODO can this be a variable?
 			switch yyDollar[2].name {
 			case "cols":
@@ -705,143 +705,111 @@ yydefault:
 		}
 	case 23:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line expressions.y:143
+//line expressions.y:142
 		{
-			val := yyDollar[1].val
-			yyVAL.f = func(Context) values.Value { return values.ValueOf(val) }
+			yyVAL.node = &Literal{yyDollar[1].val}
 		}
 	case 24:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line expressions.y:144
+//line expressions.y:143
 		{
-			name := yyDollar[1].name
-			yyVAL.f = func(ctx Context) values.Value { return values.ValueOf(ctx.Get(name)) }
+			yyVAL.node = &Variable{yyDollar[1].name}
 		}
 	case 25:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line expressions.y:145
+//line expressions.y:144
 		{
-			yyVAL.f = makeObjectPropertyExpr(yyDollar[1].f, yyDollar[2].name)
+			yyVAL.node = &Property{yyDollar[1].node, yyDollar[2].name}
 		}
 	case 26:
 		yyDollar = yyS[yypt-4 : yypt+1]
-		//line expressions.y:146
+//line expressions.y:145
 		{
-			yyVAL.f = makeIndexExpr(yyDollar[1].f, yyDollar[3].f)
+			yyVAL.node = &Index{yyDollar[1].node, yyDollar[3].node}
 		}
 	case 27:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line expressions.y:147
+//line expressions.y:146
 		{
-			yyVAL.f = yyDollar[2].f
+			yyVAL.node = yyDollar[2].node
 		}
 	case 29:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line expressions.y:152
+//line expressions.y:151
 		{
-			yyVAL.f = makeFilter(yyDollar[1].f, yyDollar[3].name, nil)
+			yyVAL.node = &Filter{yyDollar[1].node, yyDollar[3].name, nil}
 		}
 	case 30:
 		yyDollar = yyS[yypt-4 : yypt+1]
-		//line expressions.y:153
+//line expressions.y:152
 		{
-			yyVAL.f = makeFilter(yyDollar[1].f, yyDollar[3].name, yyDollar[4].filter_params)
+			yyVAL.node = &Filter{yyDollar[1].node, yyDollar[3].name, yyDollar[4].filter_params}
 		}
 	case 31:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line expressions.y:157
+//line expressions.y:156
 		{
-			yyVAL.filter_params = []valueFn{yyDollar[1].f}
+			yyVAL.filter_params = []Node{yyDollar[1].node}
 		}
 	case 32:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line expressions.y:159
+//line expressions.y:158
 		{
-			yyVAL.filter_params = append(yyDollar[1].filter_params, yyDollar[3].f)
+			yyVAL.filter_params = append(yyDollar[1].filter_params, yyDollar[3].node)
 		}
 	case 34:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line expressions.y:163
+//line expressions.y:162
 		{
-			fa, fb := yyDollar[1].f, yyDollar[3].f
-			yyVAL.f = func(ctx Context) values.Value {
-				a, b := fa(ctx), fb(ctx)
-				return values.ValueOf(a.Equal(b))
-			}
+			yyVAL.node = &Binary{"==", yyDollar[1].node, yyDollar[3].node}
 		}
 	case 35:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line expressions.y:170
+//line expressions.y:163
 		{
-			fa, fb := yyDollar[1].f, yyDollar[3].f
-			yyVAL.f = func(ctx Context) values.Value {
-				a, b := fa(ctx), fb(ctx)
-				return values.ValueOf(!a.Equal(b))
-			}
+			yyVAL.node = &Binary{"!=", yyDollar[1].node, yyDollar[3].node}
 		}
 	case 36:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line expressions.y:177
+//line expressions.y:164
 		{
-			fa, fb := yyDollar[1].f, yyDollar[3].f
-			yyVAL.f = func(ctx Context) values.Value {
-				a, b := fa(ctx), fb(ctx)
-				return values.ValueOf(b.Less(a))
-			}
+			yyVAL.node = &Binary{">", yyDollar[1].node, yyDollar[3].node}
 		}
 	case 37:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line expressions.y:184
+//line expressions.y:165
 		{
-			fa, fb := yyDollar[1].f, yyDollar[3].f
-			yyVAL.f = func(ctx Context) values.Value {
-				a, b := fa(ctx), fb(ctx)
-				return values.ValueOf(a.Less(b))
-			}
+			yyVAL.node = &Binary{"<", yyDollar[1].node, yyDollar[3].node}
 		}
 	case 38:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line expressions.y:191
+//line expressions.y:166
 		{
-			fa, fb := yyDollar[1].f, yyDollar[3].f
-			yyVAL.f = func(ctx Context) values.Value {
-				a, b := fa(ctx), fb(ctx)
-				return values.ValueOf(b.Less(a) || a.Equal(b))
-			}
+			yyVAL.node = &Binary{">=", yyDollar[1].node, yyDollar[3].node}
 		}
 	case 39:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line expressions.y:198
+//line expressions.y:167
 		{
-			fa, fb := yyDollar[1].f, yyDollar[3].f
-			yyVAL.f = func(ctx Context) values.Value {
-				a, b := fa(ctx), fb(ctx)
-				return values.ValueOf(a.Less(b) || a.Equal(b))
-			}
+			yyVAL.node = &Binary{"<=", yyDollar[1].node, yyDollar[3].node}
 		}
 	case 40:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line expressions.y:205
+//line expressions.y:168
 		{
-			yyVAL.f = makeContainsExpr(yyDollar[1].f, yyDollar[3].f)
+			yyVAL.node = &Binary{"contains", yyDollar[1].node, yyDollar[3].node}
 		}
 	case 42:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line expressions.y:210
+//line expressions.y:173
 		{
-			fa, fb := yyDollar[1].f, yyDollar[3].f
-			yyVAL.f = func(ctx Context) values.Value {
-				return values.ValueOf(fa(ctx).Test() && fb(ctx).Test())
-			}
+			yyVAL.node = &Binary{"and", yyDollar[1].node, yyDollar[3].node}
 		}
 	case 43:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line expressions.y:216
+//line expressions.y:174
 		{
-			fa, fb := yyDollar[1].f, yyDollar[3].f
-			yyVAL.f = func(ctx Context) values.Value {
-				return values.ValueOf(fa(ctx).Test() || fb(ctx).Test())
-			}
+			yyVAL.node = &Binary{"or", yyDollar[1].node, yyDollar[3].node}
 		}
 	}
 	goto yystack /* stack new state and value */
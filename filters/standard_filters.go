@@ -23,10 +23,28 @@ type FilterDictionary interface {
 	AddFilter(string, interface{})
 }
 
-// AddStandardFilters defines the standard Liquid filters.
+// A PureFilterDictionary is a FilterDictionary that records which filters are pure.
+// See expressions.Config.AddPureFilter.
+type PureFilterDictionary interface {
+	FilterDictionary
+	AddPureFilter(string, interface{})
+}
+
+// pureAdder returns the function that adds a pure filter to fd.
+func pureAdder(fd FilterDictionary) func(string, interface{}) {
+	if pd, ok := fd.(PureFilterDictionary); ok {
+		return pd.AddPureFilter
+	}
+	return fd.AddFilter
+}
+
+// AddStandardFilters defines the standard Liquid filters. The filters other than date, which
+// reads the clock for "now", are added as pure filters if fd records them.
 func AddStandardFilters(fd FilterDictionary) { // nolint: gocyclo
+	add := pureAdder(fd)
+
 	// value filters
-	fd.AddFilter("default", func(value, defaultValue interface{}) interface{} {
+	add("default", func(value, defaultValue interface{}) interface{} {
 		if value == nil || value == false || values.IsEmpty(value) {
 			value = defaultValue
 		}
@@ -34,13 +52,13 @@ func AddStandardFilters(fd FilterDictionary) { // nolint: gocyclo
 	})
 
 	// array filters
-	fd.AddFilter("concat", func(a, b []interface{}) []interface{} {
+	add("concat", func(a, b []interface{}) []interface{} {
 		result := make([]interface{}, len(a)+len(b))
 		copy(result, a)
 		return append(result, b...)
 	})
 
-	fd.AddFilter("compact", func(a interface{}) interface{} {
+	add("compact", func(a interface{}) interface{} {
 		arr, ok := values.IsArray(a)
 		if !ok {
 			return a
@@ -54,30 +72,30 @@ func AddStandardFilters(fd FilterDictionary) { // nolint: gocyclo
 		}
 		return result
 	})
-	fd.AddFilter("join", joinFilter)
-	fd.AddFilter("map", func(a []map[string]interface{}, key string) (result []interface{}) {
+	add("join", joinFilter)
+	add("map", func(a []map[string]interface{}, key string) (result []interface{}) {
 		for _, obj := range a {
 			result = append(result, obj[key])
 		}
 		return result
 	})
-	fd.AddFilter("reverse", reverseFilter)
-	fd.AddFilter("sort", sortFilter)
+	add("reverse", reverseFilter)
+	add("sort", sortFilter)
 	// https://shopify.github.io/liquid/ does not demonstrate first and last as filters,
 	// but https://help.shopify.com/themes/liquid/filters/array-filters does
-	fd.AddFilter("first", func(a []interface{}) interface{} {
+	add("first", func(a []interface{}) interface{} {
 		if len(a) == 0 {
 			return nil
 		}
 		return a[0]
 	})
-	fd.AddFilter("last", func(a []interface{}) interface{} {
+	add("last", func(a []interface{}) interface{} {
 		if len(a) == 0 {
 			return nil
 		}
 		return a[len(a)-1]
 	})
-	fd.AddFilter("uniq", uniqFilter)
+	add("uniq", uniqFilter)
 
 	// date filters
 	fd.AddFilter("date", func(t time.Time, format func(string) string) (string, error) {
@@ -86,17 +104,17 @@ func AddStandardFilters(fd FilterDictionary) { // nolint: gocyclo
 	})
 
 	// number filters
-	fd.AddFilter("abs", stdUnaryMathOperation(math.Abs).Call)
-	fd.AddFilter("ceil", func(a values.Number) int64 {
+	add("abs", stdUnaryMathOperation(math.Abs).Call)
+	add("ceil", func(a values.Number) int64 {
 		return int64(math.Ceil(a.AsFloat64()))
 	})
-	fd.AddFilter("floor", func(a values.Number) int64 {
+	add("floor", func(a values.Number) int64 {
 		return int64(math.Floor(a.AsFloat64()))
 	})
-	fd.AddFilter("at_least", atLeast)
-	fd.AddFilter("at_most", atMost)
-	fd.AddFilter("modulo", stdBinaryMathOperation(math.Mod).Call)
-	fd.AddFilter("minus", commonNumberOperation{
+	add("at_least", atLeast)
+	add("at_most", atMost)
+	add("modulo", stdBinaryMathOperation(math.Mod).Call)
+	add("minus", commonNumberOperation{
 		Int64: func(a, b int64) int64 {
 			return a - b
 		},
@@ -104,7 +122,7 @@ func AddStandardFilters(fd FilterDictionary) { // nolint: gocyclo
 			return a - b
 		},
 	}.Call)
-	fd.AddFilter("plus", commonNumberOperation{
+	add("plus", commonNumberOperation{
 		Int64: func(a, b int64) int64 {
 			return a + b
 		},
@@ -112,7 +130,7 @@ func AddStandardFilters(fd FilterDictionary) { // nolint: gocyclo
 			return a + b
 		},
 	}.Call)
-	fd.AddFilter("times", commonNumberOperation{
+	add("times", commonNumberOperation{
 		Int64: func(a, b int64) int64 {
 			return a * b
 		},
@@ -120,7 +138,7 @@ func AddStandardFilters(fd FilterDictionary) { // nolint: gocyclo
 			return a * b
 		},
 	}.Call)
-	fd.AddFilter("divided_by", func(a float64, b values.Number) (interface{}, error) {
+	add("divided_by", func(a float64, b values.Number) (interface{}, error) {
 		if b.IsFloat {
 			return a / b.AsFloat64(), nil
 		} else {
@@ -131,7 +149,7 @@ func AddStandardFilters(fd FilterDictionary) { // nolint: gocyclo
 			return int64(a) / i, nil
 		}
 	})
-	fd.AddFilter("round", func(n values.Number, places func(int) int) interface{} {
+	add("round", func(n values.Number, places func(int) int) interface{} {
 		pl := places(0)
 		exp := math.Pow10(pl)
 		result := math.Floor(n.AsFloat64()*exp+0.5) / exp
@@ -144,41 +162,47 @@ func AddStandardFilters(fd FilterDictionary) { // nolint: gocyclo
 	})
 
 	// sequence filters
-	fd.AddFilter("size", values.Length)
+	add("size", values.Length)
 
 	// string filters
-	fd.AddFilter("append", func(s, suffix string) string {
+	add("append", func(s, suffix string) string {
 		return s + suffix
 	})
-	fd.AddFilter("capitalize", func(s string) string {
+	add("capitalize", func(s string) string {
 		if len(s) == 0 {
 			return s
 		}
 		return strings.ToUpper(s[:1]) + s[1:]
 	})
-	fd.AddFilter("downcase", strings.ToLower)
-	fd.AddFilter("escape", html.EscapeString)
-	fd.AddFilter("escape_once", func(s string) string {
+	add("downcase", strings.ToLower)
+	add("escape", html.EscapeString)
+	add("escape_once", func(s string) string {
 		return html.EscapeString(html.UnescapeString(s))
 	})
-	fd.AddFilter("newline_to_br", func(s string) string {
+	add("newline_to_br", func(s string) string {
 		return strings.ReplaceAll(s, "\n", "<br />")
 	})
-	fd.AddFilter("prepend", func(s, prefix string) string {
+	add("raw", func(s string) values.SafeString {
+		return values.SafeString(s)
+	})
+	add("safe", func(s string) values.SafeString {
+		return values.SafeString(s)
+	})
+	add("prepend", func(s, prefix string) string {
 		return prefix + s
 	})
-	fd.AddFilter("remove", func(s, old string) string {
+	add("remove", func(s, old string) string {
 		return strings.ReplaceAll(s, old, "")
 	})
-	fd.AddFilter("remove_first", func(s, old string) string {
+	add("remove_first", func(s, old string) string {
 		return strings.Replace(s, old, "", 1)
 	})
-	fd.AddFilter("replace", strings.ReplaceAll)
-	fd.AddFilter("replace_first", func(s, old, new string) string {
+	add("replace", strings.ReplaceAll)
+	add("replace_first", func(s, old, new string) string {
 		return strings.Replace(s, old, new, 1)
 	})
-	fd.AddFilter("sort_natural", sortNaturalFilter)
-	fd.AddFilter("slice", func(s string, start int, length func(int) int) string {
+	add("sort_natural", sortNaturalFilter)
+	add("slice", func(s string, start int, length func(int) int) string {
 		// runes aren't bytes; don't use slice
 		n := length(1)
 		if start < 0 {
@@ -187,29 +211,29 @@ func AddStandardFilters(fd FilterDictionary) { // nolint: gocyclo
 		p := regexp.MustCompile(fmt.Sprintf(`^.{%d}(.{0,%d}).*$`, start, n))
 		return p.ReplaceAllString(s, "$1")
 	})
-	fd.AddFilter("split", splitFilter)
-	fd.AddFilter("strip_html", func(s string) string {
+	add("split", splitFilter)
+	add("strip_html", func(s string) string {
 		// TODO this probably isn't sufficient
 		return regexp.MustCompile(`<.*?>`).ReplaceAllString(s, "")
 	})
-	fd.AddFilter("strip_newlines", func(s string) string {
+	add("strip_newlines", func(s string) string {
 		return strings.ReplaceAll(s, "\n", "")
 	})
-	fd.AddFilter("strip", strings.TrimSpace)
-	fd.AddFilter("lstrip", func(s string) string {
+	add("strip", strings.TrimSpace)
+	add("lstrip", func(s string) string {
 		return strings.TrimLeftFunc(s, unicode.IsSpace)
 	})
-	fd.AddFilter("rstrip", func(s string) string {
+	add("rstrip", func(s string) string {
 		return strings.TrimRightFunc(s, unicode.IsSpace)
 	})
-	fd.AddFilter("truncate", func(s string, length func(int) int, ellipsis func(string) string) string {
+	add("truncate", func(s string, length func(int) int, ellipsis func(string) string) string {
 		n := length(50)
 		el := ellipsis("...")
 		// runes aren't bytes; don't use slice
 		re := regexp.MustCompile(fmt.Sprintf(`^(.{%d})..{%d,}`, n-len(el), len(el)))
 		return re.ReplaceAllString(s, `$1`+el)
 	})
-	fd.AddFilter("truncatewords", func(s string, length func(int) int, ellipsis func(string) string) string {
+	add("truncatewords", func(s string, length func(int) int, ellipsis func(string) string) string {
 		el := ellipsis("...")
 		n := length(15)
 		re := regexp.MustCompile(fmt.Sprintf(`^(?:\s*\S+){%d}`, n))
@@ -219,24 +243,44 @@ func AddStandardFilters(fd FilterDictionary) { // nolint: gocyclo
 		}
 		return m + el
 	})
-	fd.AddFilter("upcase", strings.ToUpper)
-	fd.AddFilter("url_encode", url.QueryEscape)
-	fd.AddFilter("url_decode", url.QueryUnescape)
+	add("upcase", strings.ToUpper)
+	add("url_encode", url.QueryEscape)
+	add("url_decode", url.QueryUnescape)
 
 	// debugging filters
 	// inspect is from Jekyll
-	fd.AddFilter("inspect", func(value interface{}) string {
+	add("inspect", func(value interface{}) string {
 		s, err := json.Marshal(value)
 		if err != nil {
 			return fmt.Sprintf("%#v", value)
 		}
 		return string(s)
 	})
-	fd.AddFilter("type", func(value interface{}) string {
+	add("type", func(value interface{}) string {
 		return fmt.Sprintf("%T", value)
 	})
 }
 
+// AddSafeHTMLFilters replaces the standard filters that produce HTML with versions that return
+// values.SafeString, so that their output isn't escaped again when auto-escaping is enabled.
+// The safe version of newline_to_br escapes its input, unless the input is already safe.
+func AddSafeHTMLFilters(fd FilterDictionary) {
+	add := pureAdder(fd)
+	add("escape", func(s string) values.SafeString {
+		return values.SafeString(html.EscapeString(s))
+	})
+	add("escape_once", func(s string) values.SafeString {
+		return values.SafeString(html.EscapeString(html.UnescapeString(s)))
+	})
+	add("newline_to_br", func(value interface{}) values.SafeString {
+		s, ok := value.(values.SafeString)
+		if !ok && value != nil {
+			s = values.SafeString(html.EscapeString(fmt.Sprint(values.ToLiquid(value))))
+		}
+		return values.SafeString(strings.ReplaceAll(string(s), "\n", "<br />"))
+	})
+}
+
 func joinFilter(a []interface{}, sep func(string) string) interface{} {
 	ss := make([]string, 0, len(a))
 	s := sep(" ")
@@ -10,6 +10,7 @@ import (
 	yaml "gopkg.in/yaml.v2"
 
 	"github.com/etecs-ru/liquid/v2/expressions"
+	"github.com/etecs-ru/liquid/v2/values"
 	"github.com/stretchr/testify/require"
 )
 
@@ -101,6 +102,8 @@ var filterTests = []struct {
 	{`"1 < 2 & 3" | escape_once`, "1 &lt; 2 &amp; 3"},
 	{`string_with_newlines | newline_to_br`, "<br />Hello<br />there<br />"},
 	{`"1 &lt; 2 &amp; 3" | escape_once`, "1 &lt; 2 &amp; 3"},
+	{`"<b>" | raw`, values.SafeString("<b>")},
+	{`"<b>" | safe`, values.SafeString("<b>")},
 	{`"apples, oranges, and bananas" | prepend: "Some fruit: "`, "Some fruit: apples, oranges, and bananas"},
 	{`"I strained to see the train through the rain" | remove: "rain"`, "I sted to see the t through the "},
 	{`"I strained to see the train through the rain" | remove_first: "rain"`, "I sted to see the train through the rain"},
@@ -296,6 +299,33 @@ func TestFilters(t *testing.T) {
 	}
 }
 
+func TestSafeHTMLFilters(t *testing.T) {
+	cfg := expressions.NewConfig()
+	AddStandardFilters(&cfg)
+	AddSafeHTMLFilters(&cfg)
+	context := expressions.NewContext(map[string]interface{}{
+		"text": "<b>\n",
+		"safe": values.SafeString("<b>\n"),
+	}, cfg)
+	tests := []struct {
+		in       string
+		expected interface{}
+	}{
+		{`"a < b" | escape`, values.SafeString("a &lt; b")},
+		{`"a &lt; b" | escape_once`, values.SafeString("a &lt; b")},
+		{`text | newline_to_br`, values.SafeString("&lt;b&gt;<br />")},
+		{`safe | newline_to_br`, values.SafeString("<b><br />")},
+		{`nil | newline_to_br`, values.SafeString("")},
+		{`text | escape | size`, 10},
+		{`"a" | escape | append: "b"`, "ab"},
+	}
+	for _, test := range tests {
+		actual, err := expressions.EvaluateString(test.in, context)
+		require.NoErrorf(t, err, test.in)
+		require.Equalf(t, test.expected, actual, test.in)
+	}
+}
+
 func timeMustParse(s string) time.Time {
 	t, err := time.Parse(time.RFC3339, s)
 	if err != nil {
@@ -9,8 +9,10 @@ The liquid package itself is versioned in gopkg.in. Subpackages have no compatib
 package liquid
 
 import (
+	"github.com/etecs-ru/liquid/v2/parser"
 	"github.com/etecs-ru/liquid/v2/render"
 	"github.com/etecs-ru/liquid/v2/tags"
+	"github.com/etecs-ru/liquid/v2/values"
 )
 
 // Bindings is a map of variable names to values.
@@ -24,6 +26,10 @@ type Bindings map[string]interface{}
 // See the examples at Engine.RegisterTag and Engine.RegisterBlock.
 type Renderer func(render.Context) (string, error)
 
+// SafeString is a string that is safe to include in HTML as-is. An engine with AutoEscape enabled
+// doesn't escape the output of a variable or filter whose value is a SafeString.
+type SafeString = values.SafeString
+
 // SourceError records an error with a source location and optional cause.
 //
 // SourceError does not depend on, but is compatible with, the causer interface of https://github.com/pkg/errors.
@@ -32,8 +38,31 @@ type SourceError interface {
 	Cause() error
 	Path() string
 	LineNumber() int
+	ColumnNumber() int
+	Offset() int
 }
 
+// FormatError returns the message of err, followed by the line of the template source where the
+// error occurred, with a caret under the start of the token that caused it:
+//
+//	Liquid error (line 2): undefined filter "nope" in page.html
+//	   2 | Hello {{ name | nope }}
+//	     |       ^
+//
+// path and source are the pathname and the text of the template. An error that was raised in
+// another template, such as one that the template includes, is formatted without a snippet.
+func FormatError(err SourceError, path string, source []byte) string {
+	return parser.FormatError(err, path, string(source))
+}
+
+// An Analysis is the result of Template.Analyze.
+type Analysis = render.Analysis
+
+// An ErrorList is the error from a render that continues past its errors, or from a parse that
+// reports all of a template's errors; see Engine.ContinueOnError and Engine.ParseTemplateAll.
+// Its Path, LineNumber and Cause are those of its first error.
+type ErrorList = parser.ErrorList
+
 // IterationKeyedMap returns a map whose {% for %} tag iteration values are its keys, instead of [key, value] pairs.
 // Use this to create a Go map with the semantics of a Ruby struct drop.
 func IterationKeyedMap(m map[string]interface{}) tags.IterationKeyedMap {
@@ -16,12 +16,22 @@ type ASTBlock struct {
 	syntax  BlockSyntax
 	Body    []ASTNode   // Body is the nodes before the first branch
 	Clauses []*ASTBlock // E.g. else and elseif w/in an if
+	End     Token       // the end tag, e.g. {% endif %}; the zero Token for a clause
 }
 
 // ASTRaw holds the text between the start and end of a raw tag.
 type ASTRaw struct {
+	Token  // the {% raw %} tag
 	Slices []string
-	sourcelessNode
+	End    Token // the {% endraw %} tag
+}
+
+// ASTComment holds the text between the start and end of a comment tag.
+// It isn't rendered, but it lets the AST reproduce the source.
+type ASTComment struct {
+	Token  // the {% comment %} tag
+	Slices []string
+	End    Token // the {% endcomment %} tag
 }
 
 // ASTTag is a tag {% tag %} that is not a block start or end.
@@ -0,0 +1,405 @@
+package parser
+
+import (
+	"encoding"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/etecs-ru/liquid/v2/expressions"
+)
+
+// The binary representation of an AST is a compact encoding, for a cache of parsed templates, that
+// UnmarshalASTBinary decodes much faster than Parse parses the source. It refers to the text of
+// the tokens by their offsets in the source, so it can only be decoded together with the source
+// that was parsed.
+//
+// It starts with the pathname of the tokens. A node is a byte that is its type, followed by its
+// fields. An integer is a varint, and a string or a list is its length followed by its contents.
+// A token is a byte that holds its type and trim flags, followed by its offset and length in the
+// source, the offsets and lengths of its name and arguments within its text, and its line and
+// column. An object's expression is its length followed by its expressions.MarshalBinary
+// representation; an empty expression is parsed from the object's arguments. A block's end tag
+// is preceded by a byte that is 0 for a clause, which has no end tag.
+
+// The types of the nodes.
+const (
+	binarySeq byte = iota + 1
+	binaryText
+	binaryObject
+	binaryTag
+	binaryBlock
+	binaryRaw
+	binaryComment
+)
+
+// The trim flags of a token, after the two bits of its type.
+const (
+	binaryTrimLeft  = 1 << 2
+	binaryTrimRight = 1 << 3
+)
+
+// MarshalASTBinary returns the binary representation of an AST that Parse returned from source.
+func MarshalASTBinary(n ASTNode, source string) ([]byte, error) {
+	e := astEncoder{source: source, path: astPath(n)}
+	e.string(e.path)
+	e.node(n)
+	if e.err != nil {
+		return nil, e.err
+	}
+	return e.buf, nil
+}
+
+// astPath returns the pathname of the first token of an AST.
+func astPath(n ASTNode) string {
+	if seq, ok := n.(*ASTSeq); ok {
+		if len(seq.Children) == 0 {
+			return ""
+		}
+		return astPath(seq.Children[0])
+	}
+	return n.SourceLocation().Pathname
+}
+
+// An astEncoder appends the binary representation of an AST to buf. It records the first error.
+type astEncoder struct {
+	buf          []byte
+	source, path string
+	err          error
+}
+
+func (e *astEncoder) fail(format string, a ...interface{}) {
+	if e.err == nil {
+		e.err = fmt.Errorf(format, a...)
+	}
+}
+
+func (e *astEncoder) uvarint(v int) {
+	if v < 0 {
+		e.fail("negative value %d", v)
+		return
+	}
+	var buf [binary.MaxVarintLen64]byte
+	e.buf = append(e.buf, buf[:binary.PutUvarint(buf[:], uint64(v))]...)
+}
+
+func (e *astEncoder) string(s string) {
+	e.uvarint(len(s))
+	e.buf = append(e.buf, s...)
+}
+
+func (e *astEncoder) nodes(nodes []ASTNode) {
+	e.uvarint(len(nodes))
+	for _, n := range nodes {
+		e.node(n)
+	}
+}
+
+func (e *astEncoder) node(node ASTNode) { // nolint: gocyclo
+	switch n := node.(type) {
+	case *ASTSeq:
+		e.buf = append(e.buf, binarySeq)
+		e.nodes(n.Children)
+	case *ASTText:
+		e.buf = append(e.buf, binaryText)
+		e.token(n.Token)
+	case *ASTObject:
+		e.buf = append(e.buf, binaryObject)
+		e.token(n.Token)
+		var expr []byte
+		if syntax, ok := expressions.Syntax(n.Expr).(encoding.BinaryMarshaler); ok {
+			b, err := syntax.MarshalBinary()
+			if err != nil {
+				e.fail("%s", err)
+			}
+			expr = b
+		}
+		e.uvarint(len(expr))
+		e.buf = append(e.buf, expr...)
+	case *ASTTag:
+		e.buf = append(e.buf, binaryTag)
+		e.token(n.Token)
+	case *ASTBlock:
+		e.buf = append(e.buf, binaryBlock)
+		e.token(n.Token)
+		e.nodes(n.Body)
+		e.uvarint(len(n.Clauses))
+		for _, clause := range n.Clauses {
+			e.node(clause)
+		}
+		e.endTag(n.End)
+	case *ASTRaw:
+		e.buf = append(e.buf, binaryRaw)
+		e.textBlock(n.Token, n.Slices, n.End)
+	case *ASTComment:
+		e.buf = append(e.buf, binaryComment)
+		e.textBlock(n.Token, n.Slices, n.End)
+	default:
+		e.fail("unknown AST node type %T", n)
+	}
+}
+
+func (e *astEncoder) token(tok Token) {
+	loc := tok.SourceLoc
+	end := loc.Offset + len(tok.Source)
+	if loc.Pathname != e.path || loc.Offset < 0 || end > len(e.source) || e.source[loc.Offset:end] != tok.Source {
+		e.fail("the token %s at %s isn't in the source", tok, loc)
+		return
+	}
+	name := strings.Index(tok.Source, tok.Name)
+	args := -1
+	if name >= 0 {
+		args = strings.Index(tok.Source[name+len(tok.Name):], tok.Args)
+	}
+	if args < 0 {
+		e.fail("the token %s at %s doesn't contain its name and arguments", tok, loc)
+		return
+	}
+	args += name + len(tok.Name)
+	flags := byte(tok.Type)
+	if tok.TrimLeft {
+		flags |= binaryTrimLeft
+	}
+	if tok.TrimRight {
+		flags |= binaryTrimRight
+	}
+	e.buf = append(e.buf, flags)
+	for _, v := range []int{loc.Offset, len(tok.Source), name, len(tok.Name), args, len(tok.Args), loc.LineNo, loc.ColNo} {
+		e.uvarint(v)
+	}
+}
+
+// endTag encodes a 0 byte for the zero Token, which is the end tag of a clause.
+func (e *astEncoder) endTag(tok Token) {
+	if tok.Source == "" && tok.Name == "" {
+		e.buf = append(e.buf, 0)
+		return
+	}
+	e.buf = append(e.buf, 1)
+	e.token(tok)
+}
+
+// textBlock encodes a raw or comment tag. Its slices are the text of the tokens between its
+// start and end tags, so each is encoded by its offset in the source.
+func (e *astEncoder) textBlock(tok Token, slices []string, end Token) {
+	e.token(tok)
+	e.uvarint(len(slices))
+	offset := tok.SourceLoc.Offset + len(tok.Source)
+	for _, s := range slices {
+		i := strings.Index(e.source[offset:], s)
+		if i < 0 {
+			e.fail("the text of %s at %s isn't in the source", tok, tok.SourceLoc)
+			return
+		}
+		offset += i
+		e.uvarint(offset)
+		e.uvarint(len(s))
+		offset += len(s)
+	}
+	e.endTag(end)
+}
+
+// UnmarshalASTBinary decodes the binary representation of an AST, that MarshalASTBinary returned
+// from source. It looks up the blocks in the config's grammar.
+func (c Config) UnmarshalASTBinary(data []byte, source string) (ASTNode, error) {
+	d := astDecoder{c: c, data: data, source: source}
+	d.path = d.string()
+	n := d.node(nil)
+	if d.err == nil && len(d.data) > 0 {
+		d.fail("extra data after the AST")
+	}
+	if d.err != nil {
+		return nil, d.err
+	}
+	return n, nil
+}
+
+// An astDecoder decodes an AST from the front of data. It records the first error, after which
+// its methods return zero values.
+type astDecoder struct {
+	c            Config
+	data         []byte
+	source, path string
+	err          error
+}
+
+func (d *astDecoder) fail(format string, a ...interface{}) {
+	if d.err == nil {
+		d.err = fmt.Errorf(format, a...)
+	}
+	d.data = nil
+}
+
+func (d *astDecoder) failed(err error) {
+	if d.err == nil {
+		d.err = err
+	}
+	d.data = nil
+}
+
+func (d *astDecoder) byte() byte {
+	if len(d.data) == 0 {
+		d.fail("truncated AST")
+		return 0
+	}
+	c := d.data[0]
+	d.data = d.data[1:]
+	return c
+}
+
+func (d *astDecoder) uvarint() int {
+	v, n := binary.Uvarint(d.data)
+	if n <= 0 || v > math.MaxInt32 {
+		d.fail("truncated AST")
+		return 0
+	}
+	d.data = d.data[n:]
+	return int(v)
+}
+
+// count decodes the length of a list, each of whose elements takes at least one byte.
+func (d *astDecoder) count() int {
+	n := d.uvarint()
+	if n > len(d.data) {
+		d.fail("truncated AST")
+		return 0
+	}
+	return n
+}
+
+func (d *astDecoder) string() string {
+	n := d.count()
+	s := string(d.data[:n])
+	d.data = d.data[n:]
+	return s
+}
+
+// slice returns the text at an offset and length within s.
+func (d *astDecoder) slice(s string) string {
+	offset := d.uvarint()
+	n := d.uvarint()
+	if offset > len(s) || n > len(s)-offset {
+		d.fail("the AST doesn't match its source")
+		return ""
+	}
+	return s[offset : offset+n]
+}
+
+func (d *astDecoder) nodes() []ASTNode {
+	n := d.count()
+	if n == 0 {
+		return nil
+	}
+	nodes := make([]ASTNode, n)
+	for i := range nodes {
+		nodes[i] = d.node(nil)
+	}
+	return nodes
+}
+
+// node decodes a node. The parent is the syntax of the block that the node is a clause of.
+func (d *astDecoder) node(parent BlockSyntax) ASTNode { // nolint: gocyclo
+	typ := d.byte()
+	if d.err != nil {
+		return nil
+	}
+	if parent != nil && typ != binaryBlock {
+		d.fail("a clause of %q isn't a block", parent.TagName())
+		return nil
+	}
+	switch typ {
+	case binarySeq:
+		return &ASTSeq{Children: d.nodes()}
+	case binaryText:
+		return &ASTText{d.token()}
+	case binaryObject:
+		tok := d.token()
+		n := d.count()
+		data := d.data[:n]
+		d.data = d.data[n:]
+		var (
+			expr expressions.Expression
+			err  error
+		)
+		if n > 0 {
+			var syntax expressions.Node
+			if syntax, err = expressions.UnmarshalNodeBinary(data); err == nil {
+				expr = expressions.FromSyntax(syntax)
+			}
+		} else if d.err == nil {
+			expr, err = expressions.Parse(tok.Args)
+		}
+		if err != nil {
+			d.failed(WrapError(err, tok))
+			return nil
+		}
+		return &ASTObject{tok, expr}
+	case binaryTag:
+		return &ASTTag{d.token()}
+	case binaryBlock:
+		tok := d.token()
+		if d.err != nil {
+			return nil
+		}
+		cs, err := d.c.checkBlock(tok, parent)
+		if err != nil {
+			d.failed(err)
+			return nil
+		}
+		n := &ASTBlock{Token: tok, syntax: cs, Body: d.nodes()}
+		if nclauses := d.count(); nclauses > 0 {
+			n.Clauses = make([]*ASTBlock, nclauses)
+			for i := range n.Clauses {
+				clause, _ := d.node(cs).(*ASTBlock)
+				n.Clauses[i] = clause
+			}
+		}
+		n.End = d.endTag()
+		return n
+	case binaryRaw, binaryComment:
+		tok := d.token()
+		var slices []string
+		if n := d.count(); n > 0 {
+			slices = make([]string, n)
+			for i := range slices {
+				slices[i] = d.slice(d.source)
+			}
+		}
+		end := d.endTag()
+		if typ == binaryRaw {
+			return &ASTRaw{tok, slices, end}
+		}
+		return &ASTComment{tok, slices, end}
+	default:
+		d.fail("unknown AST node type %d", typ)
+		return nil
+	}
+}
+
+func (d *astDecoder) token() Token {
+	flags := d.byte()
+	tok := Token{
+		Type:      TokenType(flags & 3),
+		TrimLeft:  flags&binaryTrimLeft != 0,
+		TrimRight: flags&binaryTrimRight != 0,
+	}
+	offset := d.uvarint()
+	tok.Source = d.source[:0]
+	if n := d.uvarint(); offset <= len(d.source) && n <= len(d.source)-offset {
+		tok.Source = d.source[offset : offset+n]
+	} else {
+		d.fail("the AST doesn't match its source")
+	}
+	tok.Name = d.slice(tok.Source)
+	tok.Args = d.slice(tok.Source)
+	tok.SourceLoc = SourceLoc{Pathname: d.path, LineNo: d.uvarint(), ColNo: d.uvarint(), Offset: offset}
+	return tok
+}
+
+func (d *astDecoder) endTag() Token {
+	if d.byte() == 0 {
+		return Token{}
+	}
+	return d.token()
+}
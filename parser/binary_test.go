@@ -0,0 +1,37 @@
+package parser
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_UnmarshalASTBinary(t *testing.T) {
+	cfg := Config{Grammar: grammarFake{}}
+	source := "{% for x in xs %}\n{%- if x.y -%}{{ x | f: 1 }}{% else %}{% comment %} c {% endcomment %}{% endif %}\n" +
+		"{% raw %}{{ r }}{% endraw %}text{% endfor %}"
+	parsed, perr := cfg.Parse(source, SourceLoc{Pathname: "t.html", LineNo: 1})
+	require.NoError(t, perr)
+	b, err := MarshalASTBinary(parsed, source)
+	require.NoError(t, err)
+	root, err := cfg.UnmarshalASTBinary(b, source)
+	require.NoError(t, err)
+	expected, err := json.Marshal(parsed)
+	require.NoError(t, err)
+	actual, err := json.Marshal(root)
+	require.NoError(t, err)
+	require.JSONEq(t, string(expected), string(actual))
+	require.Equal(t, parsed, root)
+
+	// the data only decodes against its source
+	_, err = MarshalASTBinary(parsed, source[1:])
+	require.Error(t, err)
+	_, err = cfg.UnmarshalASTBinary(b, source[:len(source)/2])
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "doesn't match its source")
+	_, err = cfg.UnmarshalASTBinary(b[:len(b)-1], source)
+	require.Error(t, err)
+	_, err = cfg.UnmarshalASTBinary(append(b, 0), source)
+	require.Error(t, err)
+}
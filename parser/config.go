@@ -7,6 +7,10 @@ type Config struct {
 	expressions.Config
 	Grammar Grammar
 	Delims  []string
+
+	// LaxDelims scans an unterminated object or tag, such as "Hello {{ name", as text, instead
+	// of reporting an error. It also allows a tag or object to contain a nested {{ or {%.
+	LaxDelims bool
 }
 
 // NewConfig creates a parser Config.
@@ -1,6 +1,9 @@
 package parser
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 // An Error is a syntax error during template parsing.
 type Error interface {
@@ -8,6 +11,67 @@ type Error interface {
 	Cause() error
 	Path() string
 	LineNumber() int
+	// ColumnNumber is the 1-based column, in characters, of the token where the error occurred;
+	// or 0 if it isn't known.
+	ColumnNumber() int
+	// Offset is the byte offset, from the start of the template source, of the token where the
+	// error occurred.
+	Offset() int
+}
+
+// An ErrorList is a list of errors, from an operation that continues past its errors.
+// Its Path, LineNumber and Cause are those of its first error.
+type ErrorList []Error
+
+func (el ErrorList) Error() string {
+	switch len(el) {
+	case 0:
+		return "no errors"
+	case 1:
+		return el[0].Error()
+	default:
+		return fmt.Sprintf("%s (and %d more errors)", el[0], len(el)-1)
+	}
+}
+
+// Cause is the cause of the first error.
+func (el ErrorList) Cause() error {
+	if len(el) == 0 {
+		return nil
+	}
+	return el[0].Cause()
+}
+
+// Path is the path of the first error.
+func (el ErrorList) Path() string {
+	if len(el) == 0 {
+		return ""
+	}
+	return el[0].Path()
+}
+
+// LineNumber is the line number of the first error.
+func (el ErrorList) LineNumber() int {
+	if len(el) == 0 {
+		return 0
+	}
+	return el[0].LineNumber()
+}
+
+// ColumnNumber is the column number of the first error.
+func (el ErrorList) ColumnNumber() int {
+	if len(el) == 0 {
+		return 0
+	}
+	return el[0].ColumnNumber()
+}
+
+// Offset is the offset of the first error.
+func (el ErrorList) Offset() int {
+	if len(el) == 0 {
+		return 0
+	}
+	return el[0].Offset()
 }
 
 // A Locatable provides source location information for error reporting.
@@ -29,7 +93,7 @@ func WrapError(err error, loc Locatable) Error {
 	if e, ok := err.(Error); ok {
 		// re-wrap the error, if the inner layer implemented the locatable interface
 		// but didn't actually provide any information
-		if e.Path() != "" || loc.SourceLocation().IsZero() {
+		if e.Path() != "" || e.LineNumber() > 0 || e.ColumnNumber() > 0 || loc.SourceLocation().IsZero() {
 			return e
 		}
 		if e.Cause() != nil {
@@ -60,14 +124,72 @@ func (e *sourceLocError) LineNumber() int {
 	return e.LineNo
 }
 
+func (e *sourceLocError) ColumnNumber() int {
+	return e.ColNo
+}
+
+func (e *sourceLocError) Offset() int {
+	return e.SourceLoc.Offset
+}
+
+// Message returns the error message, without its location.
+func (e *sourceLocError) Message() string {
+	return e.message
+}
+
 func (e *sourceLocError) Error() string {
 	line := ""
 	if e.LineNo > 0 {
 		line = fmt.Sprintf(" (line %d)", e.LineNo)
 	}
-	locative := " in " + e.context
-	if e.Pathname != "" {
+	locative := ""
+	switch {
+	case e.Pathname != "":
 		locative = " in " + e.Pathname
+	case e.context != "":
+		locative = " in " + e.context
 	}
 	return fmt.Sprintf("Liquid error%s: %s%s", line, e.message, locative)
 }
+
+// FormatError returns the message of err, followed by the line of source where the error occurred,
+// with a caret under the start of the token that caused it. path and source are the pathname and
+// the text of a template. If err is an ErrorList, each of its errors is formatted in turn.
+//
+// If err wasn't raised in the template at path, e.g. if it was raised in a template that the
+// template includes, or if it doesn't record a column, or its offset is outside source,
+// FormatError returns just the error message.
+func FormatError(err Error, path, source string) string {
+	if el, ok := err.(ErrorList); ok {
+		parts := make([]string, len(el))
+		for i, e := range el {
+			parts[i] = FormatError(e, path, source)
+		}
+		return strings.Join(parts, "\n")
+	}
+	offset := err.Offset()
+	if err.Path() != path || err.ColumnNumber() == 0 || offset < 0 || offset > len(source) {
+		return err.Error()
+	}
+	start := strings.LastIndexByte(source[:offset], '\n') + 1
+	end := strings.IndexByte(source[offset:], '\n')
+	if end < 0 {
+		end = len(source)
+	} else {
+		end += offset
+	}
+	line := strings.TrimSuffix(source[start:end], "\r")
+	lineNo := err.LineNumber()
+	if lineNo <= 0 {
+		lineNo = strings.Count(source[:start], "\n") + 1
+	}
+	// Copy the tabs before the error, so that the caret lines up with it.
+	indent := strings.Map(func(r rune) rune {
+		if r == '\t' {
+			return r
+		}
+		return ' '
+	}, source[start:offset])
+	gutter := fmt.Sprintf("%4d | ", lineNo)
+	return fmt.Sprintf("%s\n%s%s\n%*s| %s^", err.Error(), gutter, line, len(gutter)-2, "", indent)
+}
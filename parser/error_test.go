@@ -0,0 +1,62 @@
+package parser
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorList(t *testing.T) {
+	cause := fmt.Errorf("cause")
+	e1 := WrapError(cause, Token{SourceLoc: SourceLoc{Pathname: "a.html", LineNo: 2}})
+	e2 := Errorf(Token{SourceLoc: SourceLoc{Pathname: "a.html", LineNo: 3}}, "second")
+
+	el := ErrorList{e1}
+	require.Equal(t, "Liquid error (line 2): cause in a.html", el.Error())
+	el = append(el, e2)
+	require.Equal(t, "Liquid error (line 2): cause in a.html (and 1 more errors)", el.Error())
+	require.Equal(t, "a.html", el.Path())
+	require.Equal(t, 2, el.LineNumber())
+	require.Equal(t, cause, el.Cause())
+
+	var err Error = ErrorList{}
+	require.Equal(t, "", err.Path())
+	require.Nil(t, err.Cause())
+}
+
+func TestFormatError(t *testing.T) {
+	source := "first\n\t<p>{{ x | nope }}</p>\nlast"
+	tokens := Scan(source, SourceLoc{Pathname: "page.html", LineNo: 1}, nil)
+	err := Errorf(tokens[1], "undefined filter %q", "nope")
+	require.Equal(t, 2, err.LineNumber())
+	require.Equal(t, 5, err.ColumnNumber())
+	require.Equal(t, 10, err.Offset())
+	require.Equal(t, ""+
+		"Liquid error (line 2): undefined filter \"nope\" in page.html\n"+
+		"   2 | \t<p>{{ x | nope }}</p>\n"+
+		"     | \t   ^", FormatError(err, "page.html", source))
+
+	// an ErrorList formats each error
+	el := ErrorList{err, Errorf(tokens[2], "other")}
+	require.Equal(t, ""+
+		"Liquid error (line 2): undefined filter \"nope\" in page.html\n"+
+		"   2 | \t<p>{{ x | nope }}</p>\n"+
+		"     | \t   ^\n"+
+		"Liquid error (line 2): other in page.html\n"+
+		"   2 | \t<p>{{ x | nope }}</p>\n"+
+		"     | \t                 ^", FormatError(el, "page.html", source))
+
+	// an error in another template isn't drawn against source
+	other := Errorf(Scan("{{ y }}", SourceLoc{Pathname: "other.html", LineNo: 1}, nil)[0], "other")
+	require.Equal(t, other.Error(), FormatError(other, "page.html", source))
+	require.Equal(t, ""+
+		"Liquid error (line 2): undefined filter \"nope\" in page.html\n"+
+		"   2 | \t<p>{{ x | nope }}</p>\n"+
+		"     | \t   ^\n"+
+		"Liquid error (line 1): other in other.html", FormatError(ErrorList{err, other}, "page.html", source))
+
+	// without a column
+	err = Errorf(Token{SourceLoc: SourceLoc{LineNo: 1}}, "message")
+	require.Equal(t, "Liquid error (line 1): message", FormatError(err, "page.html", source))
+}
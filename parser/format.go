@@ -0,0 +1,171 @@
+package parser
+
+import (
+	"bytes"
+	"strings"
+	"unicode"
+
+	"github.com/etecs-ru/liquid/v2/expressions"
+)
+
+// Format returns the source of a template's AST, in canonical form:
+//
+//   - An object's expression is printed with a single space between its operands and operators,
+//     e.g. {{ a | append: "b" }}.
+//   - A tag's arguments have each run of whitespace, outside of strings, replaced by a single space.
+//   - A tag or object that begins a line inside a block is indented by indent, relative to the line
+//     of the block's start tag, if a trim marker removes the whitespace before it from the output:
+//     its own {%- or {{-, or the -%} or -}} of the tag or object before it, with only whitespace in
+//     between. The trim markers of blocks, and of {% raw %} and {% comment %} tags, don't remove
+//     whitespace, so the indentation of these tags, and of the text around them, is unchanged.
+//     If indent is empty, the indentation is left unchanged.
+//
+// Trim markers are kept. Text, and the text of {% raw %} and {% comment %} tags, is copied
+// unchanged, except for indentation that a trim marker removes; so the formatted template renders
+// the same output as the original.
+func (c Config) Format(root ASTNode, indent string) string {
+	f := formatter{delims: withDefaultDelims(c.Delims), indent: indent}
+	f.node(root, nil)
+	return f.buf.String()
+}
+
+type formatter struct {
+	buf     bytes.Buffer
+	delims  []string
+	indent  string
+	trimmed bool // true if a trim marker removes the whitespace at the end of buf
+}
+
+// node formats n. If prefix is non-nil, a tag or object that begins a line is indented by it.
+func (f *formatter) node(node ASTNode, prefix *string) {
+	switch n := node.(type) {
+	case *ASTSeq:
+		f.nodes(n.Children, prefix)
+	case *ASTText:
+		f.text(n.Source)
+	case *ASTObject:
+		f.startLine(n.Token, prefix)
+		f.token(n.Token, f.delims[0], objectSource(n), f.delims[1])
+		f.trimmed = n.TrimRight
+	case *ASTTag:
+		f.startLine(n.Token, prefix)
+		f.tag(n.Token)
+		f.trimmed = n.TrimRight
+	case *ASTRaw:
+		f.tag(n.Token)
+		f.text(strings.Join(n.Slices, ""))
+		f.tag(n.End)
+	case *ASTComment:
+		f.tag(n.Token)
+		f.text(strings.Join(n.Slices, ""))
+		f.tag(n.End)
+	case *ASTBlock:
+		f.tag(n.Token)
+		body := f.lineIndent() + f.indent
+		f.nodes(n.Body, &body)
+		for _, clause := range n.Clauses {
+			f.tag(clause.Token)
+			f.nodes(clause.Body, &body)
+		}
+		f.tag(n.End)
+	}
+}
+
+// text copies text. Its leading whitespace may be removed by a trim marker, but not the rest.
+func (f *formatter) text(s string) {
+	f.buf.WriteString(s)
+	if strings.TrimSpace(s) != "" {
+		f.trimmed = false
+	}
+}
+
+func (f *formatter) nodes(nodes []ASTNode, prefix *string) {
+	for _, n := range nodes {
+		f.node(n, prefix)
+	}
+}
+
+// tag writes a tag. Only the trim markers of a tag that isn't a block's remove whitespace; the
+// caller records these.
+func (f *formatter) tag(tok Token) {
+	f.trimmed = false
+	if tok.Source == "" {
+		// the end tag of a raw or comment block at the end of the source
+		return
+	}
+	source := tok.Name
+	if tok.Args != "" {
+		source += " " + collapseSpace(tok.Args)
+	}
+	f.token(tok, f.delims[2], source, f.delims[3])
+}
+
+func (f *formatter) token(tok Token, open, source, close string) {
+	f.buf.WriteString(open)
+	if tok.TrimLeft {
+		f.buf.WriteByte('-')
+	}
+	f.buf.WriteString(" " + source + " ")
+	if tok.TrimRight {
+		f.buf.WriteByte('-')
+	}
+	f.buf.WriteString(close)
+}
+
+// startLine replaces the whitespace before a tag or object that begins a line by prefix, if a
+// trim marker removes it from the output.
+func (f *formatter) startLine(tok Token, prefix *string) {
+	if prefix == nil || f.indent == "" || !(tok.TrimLeft || f.trimmed) {
+		return
+	}
+	b := f.buf.Bytes()
+	i := len(bytes.TrimRight(b, " \t"))
+	if i > 0 && b[i-1] != '\n' {
+		return
+	}
+	f.buf.Truncate(i)
+	f.buf.WriteString(*prefix)
+}
+
+// lineIndent returns the whitespace at the start of the last line of the output.
+func (f *formatter) lineIndent() string {
+	b := f.buf.Bytes()
+	line := b[bytes.LastIndexByte(b, '\n')+1:]
+	return string(line[:len(line)-len(bytes.TrimLeft(line, " \t"))])
+}
+
+func objectSource(n *ASTObject) string {
+	if expr := expressions.Syntax(n.Expr); expr != nil {
+		return expr.String()
+	}
+	return collapseSpace(n.Args)
+}
+
+// collapseSpace replaces each run of whitespace outside of a string by a single space, and
+// removes leading and trailing whitespace.
+func collapseSpace(s string) string {
+	var (
+		buf   strings.Builder
+		quote rune
+		space bool
+	)
+	for _, r := range strings.TrimSpace(s) {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case unicode.IsSpace(r):
+			space = true
+			continue
+		}
+		if space {
+			buf.WriteByte(' ')
+			space = false
+		}
+		buf.WriteRune(r)
+	}
+	return buf.String()
+}
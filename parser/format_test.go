@@ -0,0 +1,68 @@
+package parser
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var formatTests = []struct{ in, expected string }{
+	{`{{x|f:1,"a"}}`, `{{ x | f: 1, "a" }}`},
+	{`{{-  a.b[0]  -}}`, `{{- a.b[0] -}}`},
+	{`{%-  if  a  ==  'b  c'  -%}x{%endif%}`, `{%- if a == 'b  c' -%}x{% endif %}`},
+	{"text {{ a }}\n  text", "text {{ a }}\n  text"},
+
+	// indentation that a trim marker removes
+	{
+		"<ul>\n{% for x in xs %}\n{{- x }}\n<li>{{x -}}\n{{ y }}</li>\n{% if x %}\n{{- y }}\n{% endif %}\n{% endfor %}\n</ul>\n",
+		"<ul>\n{% for x in xs %}\n  {{- x }}\n<li>{{ x -}}\n  {{ y }}</li>\n{% if x %}\n  {{- y }}\n{% endif %}\n{% endfor %}\n</ul>\n",
+	},
+	{
+		"  <div>\n    {% if a %}\n{{- b }}\n    {%- endif %}",
+		"  <div>\n    {% if a %}\n      {{- b }}\n    {%- endif %}",
+	},
+	{"{% if a %}{% if b %}x{% endif %}{% endif %}", "{% if a %}{% if b %}x{% endif %}{% endif %}"},
+
+	// other indentation is output, and is unchanged
+	{
+		"<ul>\n{% for x in xs %}\n{% if x %}\n<li>{{x}}</li>\n{%else%}\n      {{ y }}\n{% endif %}\n{% endfor %}\n</ul>\n",
+		"<ul>\n{% for x in xs %}\n{% if x %}\n<li>{{ x }}</li>\n{% else %}\n      {{ y }}\n{% endif %}\n{% endfor %}\n</ul>\n",
+	},
+	{"{% if a -%}\n  {{ b }}\n{{ c -}}\n x {{ d }}{% endif %}", "{% if a -%}\n  {{ b }}\n{{ c -}}\n x {{ d }}{% endif %}"},
+
+	// raw and comment text is unchanged
+	{"{%raw%}  {{x}}\n {%if%} {%endraw%}", "{% raw %}  {{x}}\n {%if%} {% endraw %}"},
+	{
+		"{% if a %}\n{%comment%}\n  keep  {{ this }}\n   {% endcomment %}\n{% endif %}",
+		"{% if a %}\n{% comment %}\n  keep  {{ this }}\n   {% endcomment %}\n{% endif %}",
+	},
+}
+
+func TestFormat(t *testing.T) {
+	cfg := Config{Grammar: grammarFake{}}
+	for i, test := range formatTests {
+		t.Run(fmt.Sprintf("%02d", i+1), func(t *testing.T) {
+			root, err := cfg.Parse(test.in, SourceLoc{})
+			require.NoError(t, err, test.in)
+			actual := cfg.Format(root, "  ")
+			require.Equal(t, test.expected, actual, test.in)
+
+			// formatting is idempotent
+			root, err = cfg.Parse(actual, SourceLoc{})
+			require.NoError(t, err, actual)
+			require.Equal(t, actual, cfg.Format(root, "  "), actual)
+		})
+	}
+
+	// an empty indent leaves the indentation unchanged
+	root, err := cfg.Parse("{% if a %}\n      {{b}}\n{% endif %}", SourceLoc{})
+	require.NoError(t, err)
+	require.Equal(t, "{% if a %}\n      {{ b }}\n{% endif %}", cfg.Format(root, ""))
+
+	// custom delimiters
+	cfg.Delims = []string{"<<", ">>", "<%", "%>"}
+	root, err = cfg.Parse("<%if a%><<b>><%endif%>", SourceLoc{})
+	require.NoError(t, err)
+	require.Equal(t, "<% if a %><< b >><% endif %>", cfg.Format(root, ""))
+}
@@ -0,0 +1,279 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/etecs-ru/liquid/v2/expressions"
+)
+
+// The JSON representation of an ASTNode is an object whose "type" is one of "seq", "text",
+// "object", "tag", "block", "raw", or "comment". For example, {% if a %}{{ b }}{% endif %} is:
+//
+//	{"type": "seq", "children": [
+//	  {"type": "block", "loc": {"line": 1, "column": 1, "offset": 0}, "source": "{% if a %}",
+//	   "name": "if", "args": "a", "body": [
+//	     {"type": "object", "loc": …, "source": "{{ b }}", "args": "b",
+//	      "expr": {"type": "variable", "name": "b"}}],
+//	   "end": {"type": "tag", "loc": …, "source": "{% endif %}", "name": "endif"}}]}
+//
+// An object's "expr" is its expression's syntax tree; see expressions.UnmarshalNode. A block's
+// "clauses" are blocks, e.g. {% else %}. The "text" of a raw or comment tag is the source
+// between the start and end tags.
+
+type astJSON struct {
+	Type      string          `json:"type"`
+	Loc       *locJSON        `json:"loc,omitempty"`
+	Source    string          `json:"source,omitempty"`
+	Name      string          `json:"name,omitempty"`
+	Args      string          `json:"args,omitempty"`
+	TrimLeft  bool            `json:"trimLeft,omitempty"`
+	TrimRight bool            `json:"trimRight,omitempty"`
+	Expr      json.RawMessage `json:"expr,omitempty"`
+	Children  []*astJSON      `json:"children,omitempty"`
+	Body      []*astJSON      `json:"body,omitempty"`
+	Clauses   []*astJSON      `json:"clauses,omitempty"`
+	Text      *string         `json:"text,omitempty"`
+	End       *astJSON        `json:"end,omitempty"`
+}
+
+type locJSON struct {
+	Path   string `json:"path,omitempty"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+	Offset int    `json:"offset"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (n *ASTSeq) MarshalJSON() ([]byte, error) { return marshalAST(n) }
+
+// MarshalJSON implements json.Marshaler.
+func (n *ASTText) MarshalJSON() ([]byte, error) { return marshalAST(n) }
+
+// MarshalJSON implements json.Marshaler.
+func (n *ASTObject) MarshalJSON() ([]byte, error) { return marshalAST(n) }
+
+// MarshalJSON implements json.Marshaler.
+func (n *ASTTag) MarshalJSON() ([]byte, error) { return marshalAST(n) }
+
+// MarshalJSON implements json.Marshaler.
+func (n *ASTBlock) MarshalJSON() ([]byte, error) { return marshalAST(n) }
+
+// MarshalJSON implements json.Marshaler.
+func (n *ASTRaw) MarshalJSON() ([]byte, error) { return marshalAST(n) }
+
+// MarshalJSON implements json.Marshaler.
+func (n *ASTComment) MarshalJSON() ([]byte, error) { return marshalAST(n) }
+
+func marshalAST(n ASTNode) ([]byte, error) {
+	v, err := toJSON(n)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
+
+func toJSON(node ASTNode) (*astJSON, error) { // nolint: gocyclo
+	var (
+		v   *astJSON
+		err error
+	)
+	nodes := func(nodes []ASTNode) []*astJSON {
+		out := make([]*astJSON, 0, len(nodes))
+		for _, n := range nodes {
+			c, e := toJSON(n)
+			if err == nil {
+				err = e
+			}
+			out = append(out, c)
+		}
+		return out
+	}
+	switch n := node.(type) {
+	case *ASTSeq:
+		v = &astJSON{Type: "seq", Children: nodes(n.Children)}
+	case *ASTText:
+		v = tokenJSON("text", n.Token)
+	case *ASTObject:
+		v = tokenJSON("object", n.Token)
+		if expr := expressions.Syntax(n.Expr); expr != nil {
+			v.Expr, err = json.Marshal(expr)
+		}
+	case *ASTTag:
+		v = tokenJSON("tag", n.Token)
+	case *ASTBlock:
+		v = tokenJSON("block", n.Token)
+		v.Body = nodes(n.Body)
+		for _, clause := range n.Clauses {
+			c, e := toJSON(clause)
+			if err == nil {
+				err = e
+			}
+			v.Clauses = append(v.Clauses, c)
+		}
+		v.End = endTagJSON(n.End)
+	case *ASTRaw:
+		v = textBlockJSON("raw", n.Token, n.Slices, n.End)
+	case *ASTComment:
+		v = textBlockJSON("comment", n.Token, n.Slices, n.End)
+	default:
+		return nil, fmt.Errorf("unknown AST node type %T", n)
+	}
+	return v, err
+}
+
+func tokenJSON(typ string, tok Token) *astJSON {
+	loc := tok.SourceLoc
+	return &astJSON{
+		Type:      typ,
+		Loc:       &locJSON{loc.Pathname, loc.LineNo, loc.ColNo, loc.Offset},
+		Source:    tok.Source,
+		Name:      tok.Name,
+		Args:      tok.Args,
+		TrimLeft:  tok.TrimLeft,
+		TrimRight: tok.TrimRight,
+	}
+}
+
+func textBlockJSON(typ string, tok Token, slices []string, end Token) *astJSON {
+	v := tokenJSON(typ, tok)
+	text := strings.Join(slices, "")
+	v.Text = &text
+	v.End = endTagJSON(end)
+	return v
+}
+
+// endTagJSON returns nil for the zero Token, which is the end tag of a clause.
+func endTagJSON(tok Token) *astJSON {
+	if tok.Source == "" && tok.Name == "" {
+		return nil
+	}
+	return tokenJSON("tag", tok)
+}
+
+// UnmarshalAST decodes the JSON representation of an AST, such as json.Marshal produces from
+// the result of Parse. It looks up the blocks in the config's grammar.
+func (c Config) UnmarshalAST(data []byte) (ASTNode, error) {
+	var v astJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	return c.fromJSON(&v, nil)
+}
+
+// fromJSON decodes a node. The parent is the syntax of the block that the node is a clause of.
+func (c Config) fromJSON(v *astJSON, parent BlockSyntax) (ASTNode, error) { // nolint: gocyclo
+	if v == nil {
+		return nil, fmt.Errorf("missing AST node")
+	}
+	tok := v.token()
+	nodes := func(vs []*astJSON) ([]ASTNode, error) {
+		var out []ASTNode
+		for _, child := range vs {
+			n, err := c.fromJSON(child, nil)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, n)
+		}
+		return out, nil
+	}
+	switch v.Type {
+	case "seq":
+		children, err := nodes(v.Children)
+		if err != nil {
+			return nil, err
+		}
+		return &ASTSeq{Children: children}, nil
+	case "text":
+		tok.Type = TextTokenType
+		return &ASTText{tok}, nil
+	case "object":
+		tok.Type = ObjTokenType
+		var expr expressions.Expression
+		if v.Expr != nil {
+			n, err := expressions.UnmarshalNode(v.Expr)
+			if err != nil {
+				return nil, WrapError(err, tok)
+			}
+			expr = expressions.FromSyntax(n)
+		} else {
+			e, err := expressions.Parse(tok.Args)
+			if err != nil {
+				return nil, WrapError(err, tok)
+			}
+			expr = e
+		}
+		return &ASTObject{tok, expr}, nil
+	case "tag":
+		return &ASTTag{tok}, nil
+	case "block":
+		cs, err := c.checkBlock(tok, parent)
+		if err != nil {
+			return nil, err
+		}
+		body, err := nodes(v.Body)
+		if err != nil {
+			return nil, err
+		}
+		n := &ASTBlock{Token: tok, syntax: cs, Body: body}
+		for _, cv := range v.Clauses {
+			clause, err := c.fromJSON(cv, cs)
+			if err != nil {
+				return nil, err
+			}
+			n.Clauses = append(n.Clauses, clause.(*ASTBlock))
+		}
+		if v.End != nil {
+			n.End = v.End.token()
+		}
+		return n, nil
+	case "raw", "comment":
+		var slices []string
+		if v.Text != nil && *v.Text != "" {
+			slices = []string{*v.Text}
+		}
+		var end Token
+		if v.End != nil {
+			end = v.End.token()
+		}
+		if v.Type == "raw" {
+			return &ASTRaw{tok, slices, end}, nil
+		}
+		return &ASTComment{tok, slices, end}, nil
+	default:
+		return nil, fmt.Errorf("unknown AST node type %q", v.Type)
+	}
+}
+
+// checkBlock returns the syntax of the block tok, which is a clause of the block whose syntax is
+// parent, or a block if parent is nil.
+func (c Config) checkBlock(tok Token, parent BlockSyntax) (BlockSyntax, error) {
+	cs, ok := c.Grammar.BlockSyntax(tok.Name)
+	switch {
+	case !ok || cs.IsBlockEnd():
+		return nil, Errorf(tok, "undefined block %q", tok.Name)
+	case parent == nil && !cs.IsBlockStart():
+		return nil, Errorf(tok, "%q must be a clause of a block", tok.Name)
+	case parent != nil && (!cs.IsClause() || !cs.CanHaveParent(parent)):
+		return nil, Errorf(tok, "%q can't be a clause of %q", tok.Name, parent.TagName())
+	}
+	return cs, nil
+}
+
+// token returns the token of a tag or block. The caller sets the type of a text or object.
+func (v *astJSON) token() Token {
+	tok := Token{
+		Type:      TagTokenType,
+		Source:    v.Source,
+		Name:      v.Name,
+		Args:      v.Args,
+		TrimLeft:  v.TrimLeft,
+		TrimRight: v.TrimRight,
+	}
+	if v.Loc != nil {
+		tok.SourceLoc = SourceLoc{v.Loc.Path, v.Loc.Line, v.Loc.Column, v.Loc.Offset}
+	}
+	return tok
+}
@@ -0,0 +1,83 @@
+package parser
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAST_MarshalJSON(t *testing.T) {
+	cfg := Config{Grammar: grammarFake{}}
+	root, perr := cfg.Parse(`{% if a %}{{- b.c -}}{% else %}{% raw %}{{ x }}{% endraw %}{% endif %}`, SourceLoc{Pathname: "t.html", LineNo: 1})
+	require.NoError(t, perr)
+	b, err := json.Marshal(root)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"type": "seq", "children": [{
+		"type": "block",
+		"loc": {"path": "t.html", "line": 1, "column": 1, "offset": 0},
+		"source": "{% if a %}",
+		"name": "if",
+		"args": "a",
+		"body": [{
+			"type": "object",
+			"loc": {"path": "t.html", "line": 1, "column": 11, "offset": 10},
+			"source": "{{- b.c -}}",
+			"args": "b.c",
+			"trimLeft": true,
+			"trimRight": true,
+			"expr": {"type": "property", "object": {"type": "variable", "name": "b"}, "name": "c"}
+		}],
+		"clauses": [{
+			"type": "block",
+			"loc": {"path": "t.html", "line": 1, "column": 22, "offset": 21},
+			"source": "{% else %}",
+			"name": "else",
+			"body": [{
+				"type": "raw",
+				"loc": {"path": "t.html", "line": 1, "column": 32, "offset": 31},
+				"source": "{% raw %}",
+				"name": "raw",
+				"text": "{{ x }}",
+				"end": {"type": "tag", "loc": {"path": "t.html", "line": 1, "column": 48, "offset": 47}, "source": "{% endraw %}", "name": "endraw"}
+			}]
+		}],
+		"end": {"type": "tag", "loc": {"path": "t.html", "line": 1, "column": 60, "offset": 59}, "source": "{% endif %}", "name": "endif"}
+	}]}`, string(b))
+}
+
+func TestConfig_UnmarshalAST(t *testing.T) {
+	cfg := Config{Grammar: grammarFake{}}
+	source := "{% for x in xs %}\n{%- if x.y -%}{{ x | f: 1 }}{% else %}{% comment %} c {% endcomment %}{% endif %}\ntext{% endfor %}"
+	parsed, perr := cfg.Parse(source, SourceLoc{LineNo: 1})
+	require.NoError(t, perr)
+	b, err := json.Marshal(parsed)
+	require.NoError(t, err)
+	root2, err := cfg.UnmarshalAST(b)
+	require.NoError(t, err)
+	b2, err := json.Marshal(root2)
+	require.NoError(t, err)
+	require.JSONEq(t, string(b), string(b2))
+	require.Equal(t, source, cfg.Format(root2, ""))
+
+	// an object's expression can be given by its source
+	root, err := cfg.UnmarshalAST([]byte(`{"type": "seq", "children": [{"type": "object", "args": "a | f"}]}`))
+	require.NoError(t, err)
+	require.Equal(t, "{{ a | f }}", cfg.Format(root, ""))
+
+	errorTests := []struct{ in, expected string }{
+		{`{"type": "seq", "children": [{"type": "object", "args": "a |"}]}`, "syntax error"},
+		{`{"type": "object", "expr": {"type": "call"}}`, `unknown expression type "call"`},
+		{`{"type": "block", "name": "else"}`, `"else" must be a clause of a block`},
+		{`{"type": "block", "name": "endif"}`, `undefined block "endif"`},
+		{`{"type": "block", "name": "for", "clauses": [{"type": "block", "name": "else"}]}`, `"else" can't be a clause of "for"`},
+		{`{"type": "seq", "children": [null]}`, "missing AST node"},
+		{`{"type": "list"}`, `unknown AST node type "list"`},
+		{`[]`, "cannot unmarshal"},
+	}
+	for _, test := range errorTests {
+		_, err := cfg.UnmarshalAST([]byte(test.in))
+		require.Error(t, err, test.in)
+		require.Contains(t, err.Error(), test.expected, test.in)
+	}
+}
@@ -1,8 +1,9 @@
-// Package parser is an internal package that parses template source into an abstract syntax tree.
+// Package parser parses template source into an abstract syntax tree (AST).
 package parser
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/etecs-ru/liquid/v2/expressions"
@@ -10,18 +11,47 @@ import (
 
 // Parse parses a source template. It returns an AST root, that can be compiled and evaluated.
 func (c Config) Parse(source string, loc SourceLoc) (ASTNode, Error) {
-	tokens := Scan(source, loc, c.Delims)
-	return c.parseTokens(tokens)
+	tokens, errs := c.scan(source, loc)
+	if errs != nil {
+		return nil, errs[0]
+	}
+	root, errs := c.parseTokens(tokens, false)
+	if errs != nil {
+		return nil, errs[0]
+	}
+	return root, nil
 }
 
-// Parse creates an AST from a sequence of tokens.
-func (c Config) parseTokens(tokens []Token) (ASTNode, Error) { // nolint: gocyclo
-	// a stack of control tag state, for matching nested {%if}{%endif%} etc.
-	type frame struct {
-		syntax BlockSyntax
-		node   *ASTBlock
-		ap     *[]ASTNode
+// ParseAll is the same as Parse, except that it continues past syntax errors, and returns
+// all of them, in source order. The parser skips a token that it can't parse. An end tag
+// closes any unterminated blocks inside the block that it ends.
+//
+// The AST omits the tokens that had errors.
+func (c Config) ParseAll(source string, loc SourceLoc) (ASTNode, ErrorList) {
+	tokens, scanErrs := c.scan(source, loc)
+	root, errs := c.parseTokens(tokens, true)
+	errs = append(scanErrs, errs...)
+	sort.SliceStable(errs, func(i, j int) bool { return errs[i].Offset() < errs[j].Offset() })
+	return root, errs
+}
+
+func (c Config) scan(source string, loc SourceLoc) ([]Token, ErrorList) {
+	if c.LaxDelims {
+		return Scan(source, loc, c.Delims), nil
 	}
+	return ScanStrict(source, loc, c.Delims)
+}
+
+// A frame saves the enclosing block's state, for matching nested {%if}{%endif%} etc.
+type frame struct {
+	syntax BlockSyntax
+	node   *ASTBlock
+	ap     *[]ASTNode
+}
+
+// parseTokens creates an AST from a sequence of tokens.
+// Unless all is true, it stops at the first error.
+func (c Config) parseTokens(tokens []Token, all bool) (ASTNode, ErrorList) { // nolint: gocyclo
 	var (
 		g         = c.Grammar
 		root      = &ASTSeq{}      // root of AST; will be returned
@@ -30,9 +60,16 @@ func (c Config) parseTokens(tokens []Token) (ASTNode, Error) { // nolint: gocycl
 		bn        *ASTBlock        // current block node
 		stack     []frame          // stack of blocks
 		rawTag    *ASTRaw          // current raw tag
+		comment   *ASTComment      // current comment tag
 		inComment = false
 		inRaw     = false
+		errs      ErrorList
 	)
+	pop := func() {
+		f := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		sd, bn, ap = f.syntax, f.node, f.ap
+	}
 	for _, tok := range tokens {
 		tokV := tok
 		switch {
@@ -42,17 +79,24 @@ func (c Config) parseTokens(tokens []Token) (ASTNode, Error) { // nolint: gocycl
 		case inComment:
 			if tokV.Type == TagTokenType && tokV.Name == "endcomment" {
 				inComment = false
+				comment.End = tokV
+			} else {
+				comment.Slices = append(comment.Slices, tokV.Source)
 			}
 		case inRaw:
 			if tokV.Type == TagTokenType && tokV.Name == "endraw" {
 				inRaw = false
-			} else if rawTag != nil {
+				rawTag.End = tokV
+			} else {
 				rawTag.Slices = append(rawTag.Slices, tokV.Source)
 			}
 		case tokV.Type == ObjTokenType:
 			expr, err := expressions.Parse(tokV.Args)
 			if err != nil {
-				return nil, WrapError(err, tokV)
+				if errs = append(errs, WrapError(err, tokV)); !all {
+					return nil, errs
+				}
+				continue
 			}
 			*ap = append(*ap, &ASTObject{tokV, expr})
 		case tokV.Type == TextTokenType:
@@ -62,16 +106,32 @@ func (c Config) parseTokens(tokens []Token) (ASTNode, Error) { // nolint: gocycl
 				switch {
 				case tokV.Name == "comment":
 					inComment = true
+					comment = &ASTComment{Token: tokV}
+					*ap = append(*ap, comment)
 				case tokV.Name == "raw":
 					inRaw = true
-					rawTag = &ASTRaw{}
+					rawTag = &ASTRaw{Token: tokV}
 					*ap = append(*ap, rawTag)
 				case cs.RequiresParent() && (sd == nil || !cs.CanHaveParent(sd)):
 					suffix := ""
 					if sd != nil {
 						suffix = "; immediate parent is " + sd.TagName()
 					}
-					return nil, Errorf(tokV, "%s not inside %s%s", tokV.Name, strings.Join(cs.ParentTags(), " or "), suffix)
+					err := Errorf(tokV, "%s not inside %s%s", tokV.Name, strings.Join(cs.ParentTags(), " or "), suffix)
+					if !all {
+						return nil, ErrorList{err}
+					}
+					// If the end tag matches an enclosing block, the blocks inside that are
+					// unterminated. Close them all.
+					if i := matchingFrame(stack, cs); i > 0 && cs.IsBlockEnd() {
+						for len(stack) > i {
+							errs = append(errs, Errorf(bn, "unterminated %q block", bn.Name))
+							pop()
+						}
+						pop()
+						break
+					}
+					errs = append(errs, err)
 				case cs.IsBlockStart():
 					push := func() {
 						stack = append(stack, frame{syntax: sd, node: bn, ap: ap})
@@ -85,11 +145,7 @@ func (c Config) parseTokens(tokens []Token) (ASTNode, Error) { // nolint: gocycl
 					bn.Clauses = append(bn.Clauses, n)
 					ap = &n.Body
 				case cs.IsBlockEnd():
-					pop := func() {
-						f := stack[len(stack)-1]
-						stack = stack[:len(stack)-1]
-						sd, bn, ap = f.syntax, f.node, f.ap
-					}
+					bn.End = tokV
 					pop()
 				default:
 					panic(fmt.Errorf("block type %q", tokV.Name))
@@ -99,8 +155,22 @@ func (c Config) parseTokens(tokens []Token) (ASTNode, Error) { // nolint: gocycl
 			}
 		}
 	}
-	if bn != nil {
-		return nil, Errorf(bn, "unterminated %q block", bn.Name)
+	for bn != nil {
+		if errs = append(errs, Errorf(bn, "unterminated %q block", bn.Name)); !all {
+			return nil, errs
+		}
+		pop()
 	}
-	return root, nil
+	return root, errs
+}
+
+// matchingFrame returns the index of the innermost frame whose block can be the parent of cs,
+// or -1.
+func matchingFrame(stack []frame, cs BlockSyntax) int {
+	for i := len(stack) - 1; i > 0; i-- {
+		if cs.CanHaveParent(stack[i].syntax) {
+			return i
+		}
+	}
+	return -1
 }
@@ -31,6 +31,7 @@ func (g blockSyntaxFake) TagName() string      { return string(g) }
 var parseErrorTests = []struct{ in, expected string }{
 	{"{% if test %}", `unterminated "if" block`},
 	{"{% if test %}{% endunless %}", "not inside unless"},
+	{"{% if test %}{{ x {% endif %}", "unterminated object"},
 	// TODO tag syntax could specify statement type to catch these in parser
 	// {"{{ syntax error }}", "syntax error"},
 	// {"{% for syntax error %}{% endfor %}", "syntax error"},
@@ -70,3 +71,37 @@ func TestParser(t *testing.T) {
 		})
 	}
 }
+
+var parseAllTests = []struct {
+	in       string
+	expected []string
+}{
+	{`{% if a %}{% endif %}`, nil},
+	{`{{ a | }}{% if a %}{{ ) }}{% endif %}`, []string{"syntax error", "syntax error"}},
+	{`{% if a %}{% for x %}{% endif %}{% else %}`, []string{`unterminated "for" block`, "else not inside unless"}},
+	{`{% if a %}{% unless b %}`, []string{`unterminated "if" block`, `unterminated "unless" block`}},
+	{`{% endif %}{{ a | }}`, []string{"endif not inside unless", "syntax error"}},
+	{`{{ a | }}{% if a {% endif %}`, []string{"syntax error", "inside a tag", `unterminated "if" block`}},
+}
+
+func TestParseAll(t *testing.T) {
+	cfg := Config{Grammar: grammarFake{}}
+	for i, test := range parseAllTests {
+		testV := test
+		t.Run(fmt.Sprintf("%02d", i+1), func(t *testing.T) {
+			root, errs := cfg.ParseAll(testV.in, SourceLoc{LineNo: 1})
+			require.NotNil(t, root)
+			require.Lenf(t, errs, len(testV.expected), "%v", errs)
+			for j, err := range errs {
+				require.Containsf(t, err.Error(), testV.expected[j], testV.in)
+			}
+		})
+	}
+
+	root, errs := cfg.ParseAll(`{% if a %}{% for x %}{% endif %}{{ b }}`, SourceLoc{LineNo: 1})
+	require.Len(t, errs, 1)
+	require.Equal(t, 11, errs[0].ColumnNumber())
+	seq := root.(*ASTSeq)
+	require.Len(t, seq.Children, 2)
+	require.IsType(t, &ASTObject{}, seq.Children[1])
+}
@@ -4,42 +4,127 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+	"unicode/utf8"
 )
 
-// Scan breaks a string into a sequence of Tokens.
-func Scan(data string, loc SourceLoc, delims []string) (tokens []Token) {
+// Scan breaks a string into a sequence of Tokens. Text that isn't a well-formed object or tag,
+// such as an unterminated {{ or {%, is scanned as text.
+func Scan(data string, loc SourceLoc, delims []string) []Token {
+	tokens, _ := scan(data, loc, delims, false)
+	return tokens
+}
 
-	// Apply defaults
-	if len(delims) != 4 {
-		delims = []string{"{{", "}}", "{%", "%}"}
-	}
+// ScanStrict is the same as Scan, except that it also returns an error for each unterminated
+// object or tag, and for each object or tag that contains an object or tag delimiter outside of
+// a string. The text of {% raw %} and {% comment %} tags isn't checked.
+func ScanStrict(data string, loc SourceLoc, delims []string) ([]Token, ErrorList) {
+	return scan(data, loc, delims, true)
+}
+
+func scan(data string, loc SourceLoc, delims []string, strict bool) (tokens []Token, errs ErrorList) { // nolint: gocyclo
+
+	delims = withDefaultDelims(delims)
 	tokenMatcher := formTokenMatcher(delims)
 
-	// TODO error on unterminated {{ and {%
-	// TODO probably an error when a tag contains a {{ or {%, at least outside of a string
+	// locAt returns the location of data[i]. Successive calls must have non-decreasing i.
+	pos, lineStart, col := 0, 0, loc.ColNo
+	if col == 0 {
+		col = 1
+	}
+	locAt := func(i int) SourceLoc {
+		if n := strings.Count(data[pos:i], "\n"); n > 0 {
+			loc.LineNo += n
+			lineStart = pos + strings.LastIndexByte(data[pos:i], '\n') + 1
+			col = 1
+		}
+		pos = i
+		return SourceLoc{
+			Pathname: loc.Pathname,
+			LineNo:   loc.LineNo,
+			ColNo:    col + utf8.RuneCountInString(data[lineStart:i]),
+			Offset:   loc.Offset + i,
+		}
+	}
+
+	// checkText reports the first object or tag delimiter in data[p:pe]. Since the token
+	// matcher didn't match it, it starts an unterminated or malformed object or tag.
+	skipUntil := "" // the end tag of a raw or comment block whose text isn't checked
+	checkText := func(p, pe int) {
+		if !strict || skipUntil != "" {
+			return
+		}
+		i, open := indexDelim(data[p:pe], false, delims[0], delims[2])
+		if i < 0 {
+			return
+		}
+		i += p
+		kind, closer := "object", delims[1]
+		if open == delims[2] {
+			kind, closer = "tag", delims[3]
+		}
+		excerpt := data[i:pe]
+		if j := strings.IndexByte(excerpt, '\n'); j >= 0 {
+			excerpt = excerpt[:j]
+		}
+		tok := Token{Type: TextTokenType, SourceLoc: locAt(i), Source: excerpt}
+		if strings.Contains(data[i+len(open):pe], closer) {
+			errs = append(errs, Errorf(tok, "invalid %s", kind))
+		} else {
+			errs = append(errs, Errorf(tok, "unterminated %s; expected %q", kind, closer))
+		}
+	}
+	// checkArgs reports a delimiter inside an object or tag. A tag can contain
+	// {{ expression }} substitutions, e.g. {% include {{ page.name }}.html %}.
+	checkArgs := func(tok Token) {
+		if !strict || skipUntil != "" {
+			return
+		}
+		args := tok.Args
+		for {
+			i, open := indexDelim(args, true, delims[0], delims[2])
+			if i < 0 {
+				return
+			}
+			args = args[i+len(open):]
+			if tok.Type == TagTokenType && open == delims[0] {
+				if j, _ := indexDelim(args, true, delims[1]); j >= 0 {
+					args = args[j+len(delims[1]):]
+					continue
+				}
+			}
+			kind := "an object"
+			if tok.Type == TagTokenType {
+				kind = "a tag"
+			}
+			errs = append(errs, Errorf(tok, "unexpected %q inside %s", open, kind))
+			return
+		}
+	}
+
 	p, pe := 0, len(data)
 	for _, m := range tokenMatcher.FindAllStringSubmatchIndex(data, -1) {
 		ts, te := m[0], m[1]
 		if p < ts {
-			tokens = append(tokens, Token{Type: TextTokenType, SourceLoc: loc, Source: data[p:ts]})
-			loc.LineNo += strings.Count(data[p:ts], "\n")
+			tokens = append(tokens, Token{Type: TextTokenType, SourceLoc: locAt(p), Source: data[p:ts]})
+			checkText(p, ts)
 		}
 		source := data[ts:te]
 		switch {
 		case data[ts:ts+len(delims[0])] == delims[0]:
 			tok := Token{
 				Type:      ObjTokenType,
-				SourceLoc: loc,
+				SourceLoc: locAt(ts),
 				Source:    source,
 				Args:      data[m[2]:m[3]],
 				TrimLeft:  source[2] == '-',
 				TrimRight: source[len(source)-3] == '-',
 			}
+			checkArgs(tok)
 			tokens = append(tokens, tok)
 		case data[ts:ts+len(delims[2])] == delims[2]:
 			tok := Token{
 				Type:      TagTokenType,
-				SourceLoc: loc,
+				SourceLoc: locAt(ts),
 				Source:    source,
 				Name:      data[m[4]:m[5]],
 				TrimLeft:  source[2] == '-',
@@ -48,15 +133,38 @@ func Scan(data string, loc SourceLoc, delims []string) (tokens []Token) {
 			if m[6] > 0 {
 				tok.Args = data[m[6]:m[7]]
 			}
+			switch {
+			case tok.Name == skipUntil:
+				skipUntil = ""
+			case skipUntil != "":
+			case tok.Name == "raw" || tok.Name == "comment":
+				skipUntil = "end" + tok.Name
+			default:
+				checkArgs(tok)
+			}
 			tokens = append(tokens, tok)
 		}
-		loc.LineNo += strings.Count(source, "\n")
 		p = te
 	}
 	if p < pe {
-		tokens = append(tokens, Token{Type: TextTokenType, SourceLoc: loc, Source: data[p:]})
+		tokens = append(tokens, Token{Type: TextTokenType, SourceLoc: locAt(p), Source: data[p:]})
+		checkText(p, pe)
 	}
-	return tokens
+	return tokens, errs
+}
+
+// withDefaultDelims returns the object and tag delimiters, with the default in place of each
+// one that is empty.
+func withDefaultDelims(delims []string) []string {
+	defaults := []string{"{{", "}}", "{%", "%}"}
+	if len(delims) == 4 {
+		for i, d := range delims {
+			if d != "" {
+				defaults[i] = d
+			}
+		}
+	}
+	return defaults
 }
 
 func formTokenMatcher(delims []string) *regexp.Regexp {
@@ -74,7 +182,7 @@ func formTokenMatcher(delims []string) *regexp.Regexp {
 	}
 
 	tokenMatcher := regexp.MustCompile(
-		fmt.Sprintf(`%s-?\s*(.+?)\s*-?%s|%s-?\s*(\w+)(?:\s+((?:%v)+?))?\s*-?%s`,
+		fmt.Sprintf(`%s-?\s*(.+?)\s*-?%s|%s-?\s*(\w+)(?:\s+((?:%v)+?))??\s*-?%s`,
 			// QuoteMeta will escape any of these that are regex commands
 			regexp.QuoteMeta(delims[0]), regexp.QuoteMeta(delims[1]),
 			regexp.QuoteMeta(delims[2]), strings.Join(exclusion, "|"), regexp.QuoteMeta(delims[3]),
@@ -83,3 +191,27 @@ func formTokenMatcher(delims []string) *regexp.Regexp {
 
 	return tokenMatcher
 }
+
+// indexDelim returns the index of the first instance in s of any of delims, and that delimiter.
+// If quoted is true, it skips delimiters inside quoted strings. It returns -1 if there are none.
+func indexDelim(s string, quoted bool, delims ...string) (int, string) {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+			continue
+		case quoted && (c == '"' || c == '\''):
+			quote = c
+			continue
+		}
+		for _, d := range delims {
+			if strings.HasPrefix(s[i:], d) {
+				return i, d
+			}
+		}
+	}
+	return -1, ""
+}
@@ -2,6 +2,7 @@ package parser
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -82,6 +83,8 @@ func TestScan_ws(t *testing.T) {
 		{`{% tag arg %}`, "tag", false, false},
 		{`{%- tag arg %}`, "tag", true, false},
 		{`{% tag arg -%}`, "tag", false, true},
+		{`{% tag -%}`, "tag", false, true},
+		{`{%- tag -%}`, "tag", true, true},
 	}
 	for i, test := range wsTests {
 		testV := test
@@ -91,7 +94,11 @@ func TestScan_ws(t *testing.T) {
 			tok := tokens[0]
 			if testV.expect == "tag" {
 				require.Equalf(t, "tag", tok.Name, testV.in)
-				require.Equalf(t, "arg", tok.Args, testV.in)
+				if strings.Contains(testV.in, "arg") {
+					require.Equalf(t, "arg", tok.Args, testV.in)
+				} else {
+					require.Emptyf(t, tok.Args, testV.in)
+				}
 			} else {
 				require.Equalf(t, "expr", tok.Args, testV.in)
 			}
@@ -163,3 +170,72 @@ func TestScan_delims(t *testing.T) {
 		})
 	}
 }
+
+func TestScan_sourceLoc(t *testing.T) {
+	tokens := Scan("ab{{ x }}\n\tç{% tag %}\n{{ y }}", SourceLoc{Pathname: "f.html", LineNo: 1}, nil)
+	require.Len(t, tokens, 6)
+	locs := make([]SourceLoc, len(tokens))
+	for i, tok := range tokens {
+		locs[i] = tok.SourceLoc
+	}
+	require.Equal(t, []SourceLoc{
+		{Pathname: "f.html", LineNo: 1, ColNo: 1, Offset: 0},
+		{Pathname: "f.html", LineNo: 1, ColNo: 3, Offset: 2},
+		{Pathname: "f.html", LineNo: 1, ColNo: 10, Offset: 9},
+		{Pathname: "f.html", LineNo: 2, ColNo: 3, Offset: 13},
+		{Pathname: "f.html", LineNo: 2, ColNo: 12, Offset: 22},
+		{Pathname: "f.html", LineNo: 3, ColNo: 1, Offset: 23},
+	}, locs)
+	require.Equal(t, "f.html:2:3", locs[3].String())
+
+	// a fragment starts at the location that it's scanned with
+	tokens = Scan("a{{ x }}", SourceLoc{LineNo: 4, ColNo: 10, Offset: 100}, nil)
+	require.Equal(t, SourceLoc{LineNo: 4, ColNo: 11, Offset: 101}, tokens[1].SourceLoc)
+	require.Equal(t, "line 4, column 11", tokens[1].SourceLoc.String())
+}
+
+var scanStrictErrorTests = []struct{ in, expected string }{
+	{`Hello {{ name`, `unterminated object; expected "}}"`},
+	{`Hello {% if x`, `unterminated tag; expected "%}"`},
+	{"{{ a |\n upcase }}", `invalid object`},
+	{`{%%}`, `invalid tag`},
+	{`{% if a {% endif %}`, `unexpected "{%" inside a tag`},
+	{`{% if a {{ b %}`, `unexpected "{{" inside a tag`},
+	{`{{ a {{ b }}`, `unexpected "{{" inside an object`},
+	{`{{ a }}{% if b %}x{{ c {% endif %}`, `unterminated object`},
+	{`{{ a {% b }}`, `unexpected "{%" inside an object`},
+}
+
+var scanStrictTests = []string{
+	`{{ a }} {% if b %}{% endif %}`,
+	`{{ "{{" }}{% assign x = '{%' %}`,
+	`{% include {{ page.card }}.html %}`,
+	`{% raw %}{{ {% if %}{% endraw %}`,
+	`{% comment %}{{ unterminated{% endcomment %}`,
+	`100% }} {`,
+}
+
+func TestScanStrict(t *testing.T) {
+	for i, test := range scanStrictErrorTests {
+		testV := test
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			_, errs := ScanStrict(testV.in, SourceLoc{LineNo: 1}, nil)
+			require.Lenf(t, errs, 1, testV.in)
+			require.Containsf(t, errs[0].Error(), testV.expected, testV.in)
+		})
+	}
+	for i, test := range scanStrictTests {
+		testV := test
+		t.Run(fmt.Sprintf("ok-%02d", i), func(t *testing.T) {
+			tokens, errs := ScanStrict(testV, SourceLoc{LineNo: 1}, nil)
+			require.Emptyf(t, errs, testV)
+			require.Equal(t, Scan(testV, SourceLoc{LineNo: 1}, nil), tokens)
+		})
+	}
+
+	_, errs := ScanStrict("a\nb {{ name\nc", SourceLoc{Pathname: "f.html", LineNo: 1}, nil)
+	require.Len(t, errs, 1)
+	require.Equal(t, 2, errs[0].LineNumber())
+	require.Equal(t, 3, errs[0].ColumnNumber())
+	require.Equal(t, `Liquid error (line 2): unterminated object; expected "}}" in f.html`, errs[0].Error())
+}
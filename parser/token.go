@@ -30,6 +30,8 @@ const (
 type SourceLoc struct {
 	Pathname string
 	LineNo   int
+	ColNo    int // the 1-based column, counted in characters; 0 if unknown
+	Offset   int // the 0-based byte offset from the start of the source
 }
 
 // SourceLocation returns the token's source location, for use in error reporting.
@@ -38,9 +40,9 @@ func (c Token) SourceLocation() SourceLoc { return c.SourceLoc }
 // SourceText returns the token's source text, for use in error reporting.
 func (c Token) SourceText() string { return c.Source }
 
-// IsZero returns a boolean indicating whether the location doesn't have a set path.
+// IsZero returns a boolean indicating whether the location doesn't have a set path, line or column.
 func (s SourceLoc) IsZero() bool {
-	return s.Pathname == "" && s.LineNo == 0
+	return s.Pathname == "" && s.LineNo == 0 && s.ColNo == 0
 }
 
 func (c Token) String() string {
@@ -57,8 +59,15 @@ func (c Token) String() string {
 }
 
 func (s SourceLoc) String() string {
+	col := ""
+	if s.ColNo > 0 {
+		col = fmt.Sprintf(":%d", s.ColNo)
+	}
 	if s.Pathname != "" {
-		return fmt.Sprintf("%s:%d", s.Pathname, s.LineNo)
+		return fmt.Sprintf("%s:%d%s", s.Pathname, s.LineNo, col)
+	}
+	if s.ColNo > 0 {
+		return fmt.Sprintf("line %d, column %d", s.LineNo, s.ColNo)
 	}
 	return fmt.Sprintf("line %d", s.LineNo)
 }
@@ -0,0 +1,97 @@
+package parser
+
+import (
+	"fmt"
+	"hash/fnv"
+
+	"github.com/etecs-ru/liquid/v2/expressions"
+)
+
+// A Visitor's Visit method is invoked for each node that Walk encounters. If the result visitor
+// w is not nil, Walk visits each of the children of node with w, followed by a call of
+// w.Visit(nil).
+type Visitor interface {
+	Visit(node ASTNode) (w Visitor)
+}
+
+// Walk traverses an AST in depth-first order. It starts by calling v.Visit(node). The children
+// of an ASTSeq, and the body and then the clauses of an ASTBlock, are visited in order.
+func Walk(v Visitor, node ASTNode) {
+	if v = v.Visit(node); v == nil {
+		return
+	}
+	switch n := node.(type) {
+	case *ASTSeq:
+		for _, c := range n.Children {
+			Walk(v, c)
+		}
+	case *ASTBlock:
+		for _, c := range n.Body {
+			Walk(v, c)
+		}
+		for _, clause := range n.Clauses {
+			Walk(v, clause)
+		}
+	}
+	v.Visit(nil)
+}
+
+type inspector func(ASTNode) bool
+
+func (f inspector) Visit(node ASTNode) Visitor {
+	if f(node) {
+		return f
+	}
+	return nil
+}
+
+// Inspect traverses an AST in depth-first order. It starts by calling f(node). If f returns
+// true, Inspect invokes f for each of the children of node, followed by a call of f(nil).
+func Inspect(node ASTNode, f func(ASTNode) bool) {
+	Walk(inspector(f), node)
+}
+
+// Checksum returns a hash of an AST. It changes if the shape of the tree changes, or the source,
+// name, arguments, trim markers, expression or text of one of its nodes.
+func Checksum(root ASTNode) uint64 {
+	h := fnv.New64a()
+	Inspect(root, func(node ASTNode) bool {
+		switch n := node.(type) {
+		case nil:
+			fmt.Fprint(h, ")")
+			return false
+		case *ASTSeq:
+			fmt.Fprint(h, "seq(")
+			return true
+		case *ASTObject:
+			fmt.Fprint(h, expressions.Syntax(n.Expr))
+		case *ASTRaw:
+			fmt.Fprintf(h, "%q", n.Slices)
+		case *ASTComment:
+			fmt.Fprintf(h, "%q", n.Slices)
+		}
+		tok := tokenOf(node)
+		fmt.Fprintf(h, "%T(%q %q %q %t %t ", node, tok.Source, tok.Name, tok.Args, tok.TrimLeft, tok.TrimRight)
+		return true
+	})
+	return h.Sum64()
+}
+
+func tokenOf(node ASTNode) Token {
+	switch n := node.(type) {
+	case *ASTBlock:
+		return n.Token
+	case *ASTRaw:
+		return n.Token
+	case *ASTComment:
+		return n.Token
+	case *ASTTag:
+		return n.Token
+	case *ASTText:
+		return n.Token
+	case *ASTObject:
+		return n.Token
+	default:
+		return Token{}
+	}
+}
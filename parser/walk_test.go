@@ -0,0 +1,53 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInspect(t *testing.T) {
+	cfg := Config{Grammar: grammarFake{}}
+	root, err := cfg.Parse(`a{% if b %}{{ c }}{% else %}{% raw %}d{% endraw %}{% endif %}`, SourceLoc{})
+	require.NoError(t, err)
+	var visited []string
+	Inspect(root, func(n ASTNode) bool {
+		switch n := n.(type) {
+		case nil:
+			visited = append(visited, "end")
+		case *ASTSeq:
+			visited = append(visited, "seq")
+		default:
+			visited = append(visited, n.SourceText())
+		}
+		return true
+	})
+	require.Equal(t, []string{
+		"seq",
+		"a", "end",
+		"{% if b %}", "{{ c }}", "end",
+		"{% else %}", "{% raw %}", "end", "end",
+		"end",
+		"end",
+	}, visited)
+}
+
+func TestChecksum(t *testing.T) {
+	cfg := Config{Grammar: grammarFake{}}
+	checksum := func(source string) uint64 {
+		root, err := cfg.Parse(source, SourceLoc{})
+		require.NoError(t, err)
+		return Checksum(root)
+	}
+	source := `a{% if b %}{{ c }}{% else %}{% raw %}d{% endraw %}{% endif %}`
+	require.Equal(t, checksum(source), checksum(source))
+	for _, other := range []string{
+		`b{% if b %}{{ c }}{% else %}{% raw %}d{% endraw %}{% endif %}`,
+		`a{% if c %}{{ c }}{% else %}{% raw %}d{% endraw %}{% endif %}`,
+		`a{% if b %}{{- c }}{% else %}{% raw %}d{% endraw %}{% endif %}`,
+		`a{% if b %}{{ c }}{% else %}{% raw %}e{% endraw %}{% endif %}`,
+		`a{% if b %}{{ c }}{% endif %}{% raw %}d{% endraw %}`,
+	} {
+		require.NotEqual(t, checksum(source), checksum(other), other)
+	}
+}
@@ -0,0 +1,282 @@
+package render
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/etecs-ru/liquid/v2/expressions"
+	"github.com/etecs-ru/liquid/v2/parser"
+)
+
+// A NodeAnalysis describes how a tag or block uses variables.
+type NodeAnalysis struct {
+	// Arguments are the expressions that the tag evaluates, in the enclosing scope.
+	Arguments []expressions.Expression
+	// Assigns are the variables that the tag sets in the template's scope, e.g. x in {% assign x = 1 %}.
+	Assigns []string
+	// Locals are the variables that a block binds within its body, e.g. item and forloop in
+	// {% for item in items %}.
+	Locals []string
+	// Includes are the names of the templates that the tag renders, if these are constants.
+	Includes []string
+	// Warnings are probable mistakes, such as an else clause that isn't a block's last clause.
+	// A warning that isn't a parser.Error is reported at the tag's location.
+	Warnings []error
+}
+
+// A TagAnalyzer returns the analysis of a tag's arguments.
+type TagAnalyzer func(args string) NodeAnalysis
+
+// A BlockAnalyzer returns the analysis of a block. It is called with the block, and then with
+// each of its clauses.
+type BlockAnalyzer func(BlockNode) NodeAnalysis
+
+// An Analysis is the result of a static analysis of a template.
+type Analysis struct {
+	// Globals are the uses of variables that the template doesn't define.
+	Globals []VariableRef
+	// Locals are the uses of variables that the template defines.
+	Locals []VariableRef
+	// Definitions are the variables that the template assigns, captures, or binds in a loop.
+	Definitions []Definition
+	// Filters are the uses of filters.
+	Filters []NameRef
+	// Tags are the uses of tags and blocks, and of their clauses.
+	Tags []NameRef
+	// Includes are the templates that the template includes, renders, or extends.
+	Includes []NameRef
+	// Warnings are the probable mistakes that the tag and block analyzers found.
+	Warnings []parser.Error
+}
+
+// A VariableRef is a use of a variable, e.g. a.b[0] in {{ a.b[0] | size }}.
+type VariableRef struct {
+	// Path is the variable name, followed by its property names and constant indices.
+	// A property name or string index is a string; a numeric index is an int.
+	// The path stops before an index that isn't a constant.
+	Path []interface{}
+	Loc  parser.SourceLoc
+}
+
+// Name returns the name of the variable.
+func (r VariableRef) Name() string {
+	return r.Path[0].(string)
+}
+
+func (r VariableRef) String() string {
+	buf := new(strings.Builder)
+	for i, p := range r.Path {
+		switch p := p.(type) {
+		case string:
+			if i > 0 {
+				buf.WriteByte('.')
+			}
+			buf.WriteString(p)
+		default:
+			fmt.Fprintf(buf, "[%v]", p)
+		}
+	}
+	return buf.String()
+}
+
+// A Definition is a variable that the template defines.
+type Definition struct {
+	Name string
+	Tag  string // the tag that defines the variable, e.g. assign or for
+	Loc  parser.SourceLoc
+}
+
+// A NameRef is a use of a named filter, tag, or template.
+type NameRef struct {
+	Name string
+	Loc  parser.SourceLoc
+}
+
+// AddTagAnalyzer sets the analyzer for a tag definition.
+func (c *Config) AddTagAnalyzer(name string, a TagAnalyzer) {
+	c.tagAnalyzers[name] = a
+}
+
+// Analyzer sets the analyzer for a control tag definition.
+func (b blockDefBuilder) Analyzer(fn BlockAnalyzer) blockDefBuilder {
+	b.tag.analyzer = fn
+	return b
+}
+
+// Analyze walks a render tree, and reports the variables, filters, tags, and templates that
+// it uses. It relies on the analyzers of the tags and blocks; a tag without an analyzer is
+// reported in Tags, but its arguments aren't analyzed.
+func Analyze(node Node, c Config) Analysis {
+	a := analyzer{config: c, scopes: []map[string]bool{{}}}
+	a.node(node)
+	return a.result
+}
+
+type analyzer struct {
+	config Config
+	result Analysis
+	scopes []map[string]bool // the template scope, and the scopes of the enclosing blocks
+}
+
+func (a *analyzer) node(node Node) {
+	switch n := node.(type) {
+	case *SeqNode:
+		a.nodes(n.Children)
+	case *ObjectNode:
+		a.expression(n.expr, n.SourceLoc)
+	case *TagNode:
+		a.result.Tags = append(a.result.Tags, NameRef{n.Name, n.SourceLoc})
+		if fn, ok := a.config.tagAnalyzers[n.Name]; ok {
+			info := fn(n.Args)
+			a.tag(info, n.Token)
+			a.assign(info, n.Token)
+		}
+	case *BlockNode:
+		a.block(n, n)
+	case *GoNode:
+		if tree, err := n.renderTree(); err == nil {
+			a.node(tree)
+		}
+	case *FoldedNode:
+		a.node(n.Node)
+	}
+}
+
+func (a *analyzer) nodes(nodes []Node) {
+	for _, n := range nodes {
+		a.node(n)
+	}
+}
+
+// block analyzes a block, or one of the block's clauses.
+func (a *analyzer) block(block, n *BlockNode) {
+	a.result.Tags = append(a.result.Tags, NameRef{n.Name, n.SourceLoc})
+	var info NodeAnalysis
+	if cd, ok := a.config.findBlockDef(block.Name); ok && cd.analyzer != nil {
+		info = cd.analyzer(*n)
+	}
+	a.tag(info, n.Token)
+	scope := map[string]bool{}
+	for _, name := range info.Locals {
+		scope[name] = true
+		a.result.Definitions = append(a.result.Definitions, Definition{name, n.Name, n.SourceLoc})
+	}
+	a.scopes = append(a.scopes, scope)
+	a.nodes(n.Body)
+	a.scopes = a.scopes[:len(a.scopes)-1]
+	a.assign(info, n.Token)
+	if block == n {
+		for _, clause := range n.Clauses {
+			a.block(block, clause)
+		}
+	}
+}
+
+// tag analyzes a tag's arguments, includes, and warnings.
+func (a *analyzer) tag(info NodeAnalysis, tok parser.Token) {
+	for _, err := range info.Warnings {
+		a.result.Warnings = append(a.result.Warnings, parser.WrapError(err, tok))
+	}
+	for _, expr := range info.Arguments {
+		a.expression(expr, tok.SourceLoc)
+	}
+	for _, name := range info.Includes {
+		a.result.Includes = append(a.result.Includes, NameRef{name, tok.SourceLoc})
+	}
+}
+
+// assign defines the variables that a tag assigns.
+func (a *analyzer) assign(info NodeAnalysis, tok parser.Token) {
+	for _, name := range info.Assigns {
+		a.scopes[0][name] = true
+		a.result.Definitions = append(a.result.Definitions, Definition{name, tok.Name, tok.SourceLoc})
+	}
+}
+
+func (a *analyzer) expression(expr expressions.Expression, loc parser.SourceLoc) {
+	if n := expressions.Syntax(expr); n != nil {
+		a.expressionNode(n, loc)
+	}
+}
+
+func (a *analyzer) expressionNode(n expressions.Node, loc parser.SourceLoc) {
+	switch n := n.(type) {
+	case *expressions.Variable, *expressions.Property, *expressions.Index:
+		a.reference(n, loc)
+	case *expressions.Filter:
+		a.expressionNode(n.Receiver, loc)
+		a.result.Filters = append(a.result.Filters, NameRef{n.Name, loc})
+		for _, arg := range n.Args {
+			a.expressionNode(arg, loc)
+		}
+	case *expressions.Binary:
+		a.expressionNode(n.Left, loc)
+		a.expressionNode(n.Right, loc)
+	case *expressions.Range:
+		a.expressionNode(n.Start, loc)
+		a.expressionNode(n.End, loc)
+	}
+}
+
+// reference records a use of a variable, with the path of its properties and constant indices.
+func (a *analyzer) reference(n expressions.Node, loc parser.SourceLoc) {
+	var path []interface{}
+	var indices []expressions.Node // computed indices, from the outermost
+	defer func() {
+		for i := len(indices) - 1; i >= 0; i-- {
+			a.expressionNode(indices[i], loc)
+		}
+	}()
+	for {
+		switch e := n.(type) {
+		case *expressions.Property:
+			path = append(path, e.Name)
+			n = e.Object
+			continue
+		case *expressions.Index:
+			if lit, ok := e.Index.(*expressions.Literal); ok && isPathIndex(lit.Value) {
+				path = append(path, lit.Value)
+			} else {
+				// a computed index ends the path
+				indices = append(indices, e.Index)
+				path = path[:0]
+			}
+			n = e.Object
+			continue
+		case *expressions.Variable:
+			path = append(path, e.Name)
+		default:
+			// the object is a literal or a range, e.g. (1..n).first
+			a.expressionNode(n, loc)
+			return
+		}
+		break
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	ref := VariableRef{path, loc}
+	if a.isDefined(ref.Name()) {
+		a.result.Locals = append(a.result.Locals, ref)
+	} else {
+		a.result.Globals = append(a.result.Globals, ref)
+	}
+}
+
+func (a *analyzer) isDefined(name string) bool {
+	for _, scope := range a.scopes {
+		if scope[name] {
+			return true
+		}
+	}
+	return false
+}
+
+func isPathIndex(value interface{}) bool {
+	switch value.(type) {
+	case string, int:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,67 @@
+package render
+
+import (
+	"io"
+	"testing"
+
+	"github.com/etecs-ru/liquid/v2/expressions"
+	"github.com/etecs-ru/liquid/v2/parser"
+	"github.com/stretchr/testify/require"
+)
+
+func addAnalyzerTestTags(c Config) {
+	c.AddTag("set", func(string) (func(io.Writer, Context) error, error) { return nil, nil })
+	c.AddTag("other", func(string) (func(io.Writer, Context) error, error) { return nil, nil })
+	c.AddTagAnalyzer("set", func(args string) NodeAnalysis {
+		expr, err := expressions.Parse(args[4:])
+		if err != nil {
+			panic(err)
+		}
+		return NodeAnalysis{Arguments: []expressions.Expression{expr}, Assigns: []string{args[:1]}}
+	})
+	c.AddBlock("each").Clause("empty").Analyzer(func(n BlockNode) NodeAnalysis {
+		if n.Name == "empty" {
+			return NodeAnalysis{}
+		}
+		expr, err := expressions.Parse(n.Args)
+		if err != nil {
+			panic(err)
+		}
+		return NodeAnalysis{Arguments: []expressions.Expression{expr}, Locals: []string{"it"}}
+	}).Compiler(func(BlockNode) (func(io.Writer, Context) error, error) { return nil, nil })
+}
+
+func TestAnalyze(t *testing.T) {
+	cfg := NewConfig()
+	addAnalyzerTestTags(cfg)
+	src := "{{ a.b[0].c }}{% set x = y | f: z[k].w %}{{ x.p }}\n{% each list %}{{ it.name | g }}{% empty %}{{ it }}{% endeach %}{% other %}"
+	root, err := cfg.Compile(src, parser.SourceLoc{Pathname: "t.html", LineNo: 1})
+	require.NoError(t, err)
+	a := Analyze(root, cfg)
+
+	var globals, locals []string
+	for _, ref := range a.Globals {
+		globals = append(globals, ref.String())
+	}
+	for _, ref := range a.Locals {
+		locals = append(locals, ref.String())
+	}
+	require.Equal(t, []string{"a.b[0].c", "y", "z", "k", "list", "it"}, globals)
+	require.Equal(t, []string{"x.p", "it.name"}, locals)
+	require.Equal(t, []interface{}{"a", "b", 0, "c"}, a.Globals[0].Path)
+	require.Equal(t, 2, a.Locals[1].Loc.LineNo)
+
+	require.Equal(t, []Definition{
+		{"x", "set", parser.SourceLoc{Pathname: "t.html", LineNo: 1, ColNo: 15, Offset: 14}},
+		{"it", "each", parser.SourceLoc{Pathname: "t.html", LineNo: 2, ColNo: 1, Offset: 51}},
+	}, a.Definitions)
+	require.Equal(t, []NameRef{
+		{"f", parser.SourceLoc{Pathname: "t.html", LineNo: 1, ColNo: 15, Offset: 14}},
+		{"g", parser.SourceLoc{Pathname: "t.html", LineNo: 2, ColNo: 16, Offset: 66}},
+	}, a.Filters)
+	var tags []string
+	for _, ref := range a.Tags {
+		tags = append(tags, ref.Name)
+	}
+	require.Equal(t, []string{"set", "each", "empty", "other"}, tags)
+}
@@ -17,6 +17,8 @@ type blockSyntax struct {
 	startName             string          // for an end tag, the name of the correspondign start tag
 	parents               map[string]bool // if non-nil, must be an immediate clause of one of these
 	parser                BlockCompiler
+	analyzer              BlockAnalyzer
+	folder                BlockFolder
 }
 
 func (s *blockSyntax) CanHaveParent(parent parser.BlockSyntax) bool {
@@ -0,0 +1,101 @@
+package render
+
+import (
+	"container/list"
+	"sync"
+)
+
+// A TemplateCache holds the compiled templates that Context.RenderFile has read, keyed by their
+// resolved path, so that a template that is included many times is only read and compiled once.
+//
+// A TemplateCache is safe for concurrent use. The nil *TemplateCache is an empty cache that doesn't
+// retain anything.
+type TemplateCache struct {
+	maxSize int
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     list.List // of *cacheEntry; most recently used first
+}
+
+type cacheEntry struct {
+	path string
+	root Node
+}
+
+// NewTemplateCache creates a TemplateCache. If maxSize is positive, the cache holds at most this
+// many templates, and discards the least recently used template to make room for a new one.
+func NewTemplateCache(maxSize int) *TemplateCache {
+	return &TemplateCache{maxSize: maxSize, entries: map[string]*list.Element{}}
+}
+
+// empty returns an empty cache with the same maximum size, or nil if c is nil.
+func (c *TemplateCache) empty() *TemplateCache {
+	if c == nil {
+		return nil
+	}
+	return NewTemplateCache(c.maxSize)
+}
+
+// Get returns the compiled template for path, if it is present.
+func (c *TemplateCache) Get(path string) (Node, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[path]; ok {
+		c.lru.MoveToFront(e)
+		return e.Value.(*cacheEntry).root, true
+	}
+	return nil, false
+}
+
+// Put adds the compiled template for path, replacing any existing entry.
+func (c *TemplateCache) Put(path string, root Node) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[path]; ok {
+		e.Value.(*cacheEntry).root = root
+		c.lru.MoveToFront(e)
+		return
+	}
+	c.entries[path] = c.lru.PushFront(&cacheEntry{path, root})
+	for c.maxSize > 0 && c.lru.Len() > c.maxSize {
+		e := c.lru.Back()
+		c.lru.Remove(e)
+		delete(c.entries, e.Value.(*cacheEntry).path)
+	}
+}
+
+// Invalidate removes the named paths from the cache. With no arguments, it empties the cache.
+func (c *TemplateCache) Invalidate(paths ...string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(paths) == 0 {
+		c.entries = map[string]*list.Element{}
+		c.lru.Init()
+		return
+	}
+	for _, path := range paths {
+		if e, ok := c.entries[path]; ok {
+			c.lru.Remove(e)
+			delete(c.entries, path)
+		}
+	}
+}
+
+// Len returns the number of templates in the cache.
+func (c *TemplateCache) Len() int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
@@ -0,0 +1,73 @@
+package render
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/etecs-ru/liquid/v2/parser"
+	"github.com/stretchr/testify/require"
+)
+
+type countingLoader struct {
+	MapLoader
+	reads map[string]int
+}
+
+func (l countingLoader) ReadTemplate(name string) ([]byte, error) {
+	l.reads[name]++
+	return l.MapLoader.ReadTemplate(name)
+}
+
+func TestTemplateCache(t *testing.T) {
+	c := NewTemplateCache(2)
+	a, b, d := &TextNode{}, &TextNode{}, &TextNode{}
+	c.Put("a", a)
+	c.Put("b", b)
+	root, ok := c.Get("a")
+	require.True(t, ok)
+	require.Equal(t, a, root)
+
+	// evicts the least recently used entry
+	c.Put("d", d)
+	require.Equal(t, 2, c.Len())
+	_, ok = c.Get("b")
+	require.False(t, ok)
+	_, ok = c.Get("a")
+	require.True(t, ok)
+
+	c.Invalidate("a")
+	_, ok = c.Get("a")
+	require.False(t, ok)
+	require.Equal(t, 1, c.Len())
+
+	c.Invalidate()
+	require.Equal(t, 0, c.Len())
+
+	var nilCache *TemplateCache
+	nilCache.Put("a", a)
+	_, ok = nilCache.Get("a")
+	require.False(t, ok)
+	nilCache.Invalidate()
+	require.Equal(t, 0, nilCache.Len())
+}
+
+func TestRenderFile_cache(t *testing.T) {
+	loader := countingLoader{MapLoader{"card.html": "card;"}, map[string]int{}}
+	cfg := NewConfig()
+	addContextTestTags(cfg)
+	cfg.TemplateLoader = loader
+	cfg.TemplateCache = NewTemplateCache(0)
+	root, err := cfg.Compile(`{% test_render_file card.html %}{% test_render_file card.html %}`, parser.SourceLoc{})
+	require.NoError(t, err)
+	buf := new(bytes.Buffer)
+	require.NoError(t, Render(root, buf, contextTestBindings, cfg))
+	require.Equal(t, "card;card;", buf.String())
+	require.Equal(t, 1, loader.reads["card.html"])
+
+	loader.MapLoader["card.html"] = "changed;"
+	cfg.TemplateCache.Invalidate("card.html")
+	buf = new(bytes.Buffer)
+	require.NoError(t, Render(root, buf, contextTestBindings, cfg))
+	require.Equal(t, "changed;changed;", buf.String())
+	require.Equal(t, 2, loader.reads["card.html"])
+}
@@ -2,6 +2,8 @@ package render
 
 import (
 	"fmt"
+	"io"
+	"sort"
 
 	"github.com/etecs-ru/liquid/v2/parser"
 )
@@ -12,11 +14,60 @@ func (c Config) Compile(source string, loc parser.SourceLoc) (Node, parser.Error
 	if err != nil {
 		return nil, err
 	}
-	return c.compileNode(root)
+	return c.compileAST(root, loc)
 }
 
+// CompileAST applies the rewriters to an AST, such as Parse returns, and compiles it. It returns
+// the first error.
+func (c Config) CompileAST(root parser.ASTNode) (Node, parser.Error) {
+	return c.compileAST(root, parser.SourceLoc{})
+}
+
+func (c Config) compileAST(root parser.ASTNode, loc parser.SourceLoc) (Node, parser.Error) {
+	root, err := c.Rewrite(root, loc)
+	if err != nil {
+		return nil, err
+	}
+	return compiler{c, nil}.compileNode(root)
+}
+
+// CompileAll is the same as Compile, except that it continues past errors. It returns every
+// syntax error, undefined tag, and unterminated block, in source order.
+//
+// The returned tree omits the nodes that had errors. It is nil if there are any errors.
+func (c Config) CompileAll(source string, loc parser.SourceLoc) (Node, parser.ErrorList) {
+	root, errs := c.ParseAll(source, loc)
+	root, err := c.Rewrite(root, loc)
+	if err != nil {
+		return nil, append(errs, err)
+	}
+	compiled, _ := compiler{c, &errs}.compileNode(root)
+	if len(errs) > 0 {
+		sort.SliceStable(errs, func(i, j int) bool { return errs[i].Offset() < errs[j].Offset() })
+		return nil, errs
+	}
+	return compiled, nil
+}
+
+// A compiler compiles an AST. If errs is nil, it stops at the first error. Otherwise it
+// appends each error to errs, and omits the node from the compiled tree.
+type compiler struct {
+	Config
+	errs *parser.ErrorList
+}
+
+// fail returns err, or records it and returns nil if the compiler continues past errors.
+func (c compiler) fail(err parser.Error) parser.Error {
+	if c.errs == nil {
+		return err
+	}
+	*c.errs = append(*c.errs, err)
+	return nil
+}
+
+// compileNode returns a nil Node if the compiler skipped n.
 // nolint: gocyclo
-func (c Config) compileNode(n parser.ASTNode) (Node, parser.Error) {
+func (c compiler) compileNode(n parser.ASTNode) (Node, parser.Error) {
 	switch n := n.(type) {
 	case *parser.ASTBlock:
 		body, err := c.compileNodes(n.Body)
@@ -30,23 +81,28 @@ func (c Config) compileNode(n parser.ASTNode) (Node, parser.Error) {
 
 		cd, ok := c.findBlockDef(n.Name)
 		if !ok {
-			return nil, parser.Errorf(n, "undefined tag %q", n.Name)
+			return nil, c.fail(parser.Errorf(n, "undefined tag %q", n.Name))
 		}
 		node := BlockNode{
 			Token:   n.Token,
 			Body:    body,
 			Clauses: branches,
+			parses:  c.ParseTable,
 		}
 		if cd.parser != nil {
 			r, err := cd.parser(node)
 			if err != nil {
-				return nil, parser.WrapError(err, n)
+				return nil, c.fail(parser.WrapError(err, n))
 			}
 			node.renderer = r
 		}
-		return &node, nil
+		folded := c.foldBlock(&node, cd)
+		node.parses = nil
+		return folded, nil
 	case *parser.ASTRaw:
-		return &RawNode{n.Slices, sourcelessNode{}}, nil
+		return &RawNode{n.Token, n.Slices}, nil
+	case *parser.ASTComment:
+		return nil, nil
 	case *parser.ASTSeq:
 		children, err := c.compileNodes(n.Children)
 		if err != nil {
@@ -55,42 +111,54 @@ func (c Config) compileNode(n parser.ASTNode) (Node, parser.Error) {
 		return &SeqNode{children, sourcelessNode{}}, nil
 	case *parser.ASTTag:
 		if td, ok := c.FindTagDefinition(n.Name); ok {
-			f, err := td(n.Args)
+			var (
+				f   func(io.Writer, Context) error
+				err error
+			)
+			if ptd, ok := c.parsingTags[n.Name]; ok {
+				f, err = ptd(n.Args, c.ParseTable)
+			} else {
+				f, err = td(n.Args)
+			}
 			if err != nil {
-				return nil, parser.Errorf(n, "%s", err)
+				return nil, c.fail(parser.Errorf(n, "%s", err))
 			}
 			return &TagNode{n.Token, f}, nil
 		}
-		return nil, parser.Errorf(n, "undefined tag %q", n.Name)
+		return nil, c.fail(parser.Errorf(n, "undefined tag %q", n.Name))
 	case *parser.ASTText:
 		return &TextNode{n.Token}, nil
 	case *parser.ASTObject:
-		return &ObjectNode{n.Token, n.Expr}, nil
+		return c.foldObject(&ObjectNode{n.Token, n.Expr}), nil
 	default:
 		panic(fmt.Errorf("un-compilable node type %T", n))
 	}
 }
 
-func (c Config) compileBlocks(blocks []*parser.ASTBlock) ([]*BlockNode, parser.Error) {
+func (c compiler) compileBlocks(blocks []*parser.ASTBlock) ([]*BlockNode, parser.Error) {
 	out := make([]*BlockNode, 0, len(blocks))
 	for _, child := range blocks {
 		compiled, err := c.compileNode(child)
 		if err != nil {
 			return nil, err
 		}
-		out = append(out, compiled.(*BlockNode))
+		if compiled != nil {
+			out = append(out, compiled.(*BlockNode))
+		}
 	}
 	return out, nil
 }
 
-func (c Config) compileNodes(nodes []parser.ASTNode) ([]Node, parser.Error) {
+func (c compiler) compileNodes(nodes []parser.ASTNode) ([]Node, parser.Error) {
 	out := make([]Node, 0, len(nodes))
 	for _, child := range nodes {
 		compiled, err := c.compileNode(child)
 		if err != nil {
 			return nil, err
 		}
-		out = append(out, compiled)
+		if compiled != nil {
+			out = append(out, compiled)
+		}
 	}
 	return out, nil
 }
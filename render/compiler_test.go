@@ -1,10 +1,12 @@
 package render
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"testing"
 
+	"github.com/etecs-ru/liquid/v2/expressions"
 	"github.com/etecs-ru/liquid/v2/parser"
 	"github.com/stretchr/testify/require"
 )
@@ -40,3 +42,71 @@ func TestCompile_errors(t *testing.T) {
 		})
 	}
 }
+
+func TestCompileAll(t *testing.T) {
+	settings := NewConfig()
+	addCompilerTestTags(settings)
+	src := `{% undefined_tag %}{{ a | }}{% block %}{% error_block %}{% enderror_block %}{% other %}`
+	root, errs := settings.CompileAll(src, parser.SourceLoc{LineNo: 1})
+	require.Nil(t, root)
+	require.Len(t, errs, 5)
+	require.Contains(t, errs[0].Error(), `undefined tag "undefined_tag"`)
+	require.Contains(t, errs[1].Error(), "syntax error")
+	require.Contains(t, errs[2].Error(), `unterminated "block" block`)
+	require.Contains(t, errs[3].Error(), "block compiler error")
+	require.Contains(t, errs[4].Error(), `undefined tag "other"`)
+
+	root, errs = settings.CompileAll(`{% block %}{{ a }}{% endblock %}`, parser.SourceLoc{LineNo: 1})
+	require.Nil(t, errs)
+	require.NotNil(t, root)
+}
+
+func TestCompile_parseTable(t *testing.T) {
+	cfg := NewConfig()
+	parse := func(table *expressions.ParseTable, source string) (func(io.Writer, Context) error, error) {
+		expr, err := table.Parse(source)
+		if err != nil {
+			return nil, err
+		}
+		return func(w io.Writer, ctx Context) error {
+			value, err := ctx.Evaluate(expr)
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprint(w, value)
+			return err
+		}, nil
+	}
+	cfg.AddParsingTag("show", func(args string, table *expressions.ParseTable) (func(io.Writer, Context) error, error) {
+		return parse(table, args)
+	})
+	cfg.AddBlock("shown").Compiler(func(node BlockNode) (func(io.Writer, Context) error, error) {
+		return parse(node.ParseTable(), node.Args)
+	})
+	src := `{% show a %}{% shown b %}{% endshown %}`
+
+	// without a table, the compilers parse each source
+	root, err := cfg.Compile(src, parser.SourceLoc{})
+	require.NoError(t, err)
+	buf := new(bytes.Buffer)
+	require.NoError(t, Render(root, buf, map[string]interface{}{"a": 1, "b": 2}, cfg))
+	require.Equal(t, "12", buf.String())
+
+	c := cfg
+	c.ParseTable = expressions.NewParseTable()
+	_, err = c.Compile(src, parser.SourceLoc{})
+	require.NoError(t, err)
+	require.Equal(t, 2, c.ParseTable.Len())
+	_, err = cfg.Compile(`{% show c %}`, parser.SourceLoc{})
+	require.NoError(t, err)
+	require.Equal(t, 2, c.ParseTable.Len())
+
+	// a tag that is added again by AddTag doesn't use the table
+	cfg.AddTag("show", func(args string) (func(io.Writer, Context) error, error) {
+		return parse(nil, args)
+	})
+	c.ParseTable = expressions.NewParseTable()
+	_, err = c.Compile(`{% show d %}`, parser.SourceLoc{})
+	require.NoError(t, err)
+	require.Equal(t, 0, c.ParseTable.Len())
+}
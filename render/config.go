@@ -1,6 +1,14 @@
 package render
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+
+	"github.com/etecs-ru/liquid/v2/expressions"
 	"github.com/etecs-ru/liquid/v2/parser"
 )
 
@@ -8,18 +16,149 @@ import (
 type Config struct {
 	parser.Config
 	grammar
+
+	// TemplateLoader reads the templates that are named by the {% include %} tag.
+	TemplateLoader TemplateLoader
+	// SearchPaths are directories that are searched, in order, for an included template
+	// that isn't found relative to the including template.
+	SearchPaths []string
+	// TemplateCache, if non-nil, holds the compiled templates that are read by the {% include %} tag.
+	TemplateCache *TemplateCache
+	// Limits bound the resources that a render may use.
+	Limits Limits
+	// ErrorPlaceholder, if non-nil, makes rendering continue past a node whose render fails.
+	// The node's output is replaced by ErrorPlaceholder(err), and the render returns its errors
+	// as a parser.ErrorList. Control flow errors, and cancellation and limit errors, still stop
+	// the render.
+	ErrorPlaceholder func(Error) string
+	// AutoEscape HTML-escapes the output of {{ objects }}, except for values of type values.SafeString.
+	AutoEscape bool
+	// ParseTable, if non-nil, holds the parses of the tags' arguments. The compilers of the tags
+	// that are added by AddParsingTag, and of the blocks, look up their arguments in it, and add
+	// the ones that it doesn't hold. It's for compiling one template at a time: a cache of
+	// compiled templates sets it on a copy of the configuration, to record or to reuse the parses
+	// of a template.
+	ParseTable *expressions.ParseTable
+
+	rewriters   []Rewriter
+	fingerprint *fingerprintCache // nil unless CacheFingerprint was called
+}
+
+// A fingerprintCache holds the Fingerprint of a configuration that doesn't change.
+type fingerprintCache struct {
+	once  sync.Once
+	value string
 }
 
 type grammar struct {
-	tags      map[string]TagCompiler
-	blockDefs map[string]*blockSyntax
+	tags         map[string]TagCompiler
+	parsingTags  map[string]ParsingTagCompiler
+	tagAnalyzers map[string]TagAnalyzer
+	blockDefs    map[string]*blockSyntax
 }
 
 // NewConfig creates a new Settings.
 func NewConfig() Config {
 	g := grammar{
-		tags:      map[string]TagCompiler{},
-		blockDefs: map[string]*blockSyntax{},
+		tags:         map[string]TagCompiler{},
+		parsingTags:  map[string]ParsingTagCompiler{},
+		tagAnalyzers: map[string]TagAnalyzer{},
+		blockDefs:    map[string]*blockSyntax{},
+	}
+	return Config{Config: parser.NewConfig(g), grammar: g, TemplateLoader: DirLoader("")}
+}
+
+// Clone returns a copy of the configuration, that tags, blocks and filters can be added to
+// without affecting the original. The copy shares the original's TemplateLoader. If the original
+// has a TemplateCache, the copy has an empty cache of the same size, since the templates in the
+// original's cache were compiled with the original's tags, blocks and filters.
+func (c Config) Clone() Config {
+	g := c.grammar.clone()
+	c.TemplateCache = c.TemplateCache.empty()
+	c.grammar = g
+	c.Config.Grammar = g
+	c.Config.Config = c.Config.Config.Clone()
+	c.SearchPaths = append([]string(nil), c.SearchPaths...)
+	c.rewriters = append([]Rewriter(nil), c.rewriters...)
+	c.fingerprint = nil
+	return c
+}
+
+func (g grammar) clone() grammar {
+	c := grammar{
+		tags:         make(map[string]TagCompiler, len(g.tags)),
+		parsingTags:  make(map[string]ParsingTagCompiler, len(g.parsingTags)),
+		tagAnalyzers: make(map[string]TagAnalyzer, len(g.tagAnalyzers)),
+		blockDefs:    make(map[string]*blockSyntax, len(g.blockDefs)),
+	}
+	for k, v := range g.tags {
+		c.tags[k] = v
+	}
+	for k, v := range g.parsingTags {
+		c.parsingTags[k] = v
+	}
+	for k, v := range g.tagAnalyzers {
+		c.tagAnalyzers[k] = v
+	}
+	for k, v := range g.blockDefs {
+		def := *v
+		if v.parents != nil {
+			def.parents = make(map[string]bool, len(v.parents))
+			for p := range v.parents {
+				def.parents[p] = true
+			}
+		}
+		c.blockDefs[k] = &def
+	}
+	return c
+}
+
+// Fingerprint returns a digest of the parts of the configuration that parsing and compiling a
+// template depend on: the delimiters, the names of the tags and blocks and the clauses that each
+// block allows, and the names, types and purity of the filters. Go functions can't be compared
+// between processes, so a change to the implementation of a tag or filter that keeps its name
+// and type doesn't change the fingerprint.
+//
+// The fingerprint is computed each time, unless CacheFingerprint was called.
+func (c Config) Fingerprint() string {
+	if fp := c.fingerprint; fp != nil {
+		fp.once.Do(func() { fp.value = c.computeFingerprint() })
+		return fp.value
+	}
+	return c.computeFingerprint()
+}
+
+// CacheFingerprint makes Fingerprint compute the fingerprint once, and return the same value
+// afterwards, including from copies of the configuration. It is for a configuration whose
+// delimiters, tags, blocks and filters no longer change. Clone returns a copy that doesn't
+// cache its fingerprint.
+func (c *Config) CacheFingerprint() {
+	c.fingerprint = &fingerprintCache{}
+}
+
+func (c Config) computeFingerprint() string {
+	h := sha256.New()
+	fmt.Fprintf(h, "delims %q %t\n", c.Delims, c.LaxDelims)
+	for _, name := range sortedNames(c.tags) {
+		fmt.Fprintf(h, "tag %s\n", name)
+	}
+	for _, name := range sortedNames(c.blockDefs) {
+		def := c.blockDefs[name]
+		fmt.Fprintf(h, "block %s %t %t %s %q\n", name, def.isClauseTag, def.isEndTag, def.startName, def.ParentTags())
+	}
+	for _, name := range c.FilterNames() {
+		fmt.Fprintf(h, "filter %s %s %t\n", name, reflect.TypeOf(c.GetFilter(name)), c.IsPureFilter(name))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// sortedNames returns the keys of a map whose keys are strings, in sorted order.
+func sortedNames(m interface{}) []string {
+	keys := reflect.ValueOf(m).MapKeys()
+	names := make([]string, len(keys))
+	for i, k := range keys {
+		names[i] = k.String()
 	}
-	return Config{parser.NewConfig(g), g}
+	sort.Strings(names)
+	return names
 }
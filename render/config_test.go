@@ -0,0 +1,120 @@
+package render
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/etecs-ru/liquid/v2/parser"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_Clone(t *testing.T) {
+	cfg := NewConfig()
+	cfg.AddBlock("if").Clause("else")
+	cfg.AddFilter("f", func(s string) string { return "original" })
+
+	clone := cfg.Clone()
+	clone.AddBlock("unless").Clause("else")
+	clone.AddTag("t", func(string) (func(io.Writer, Context) error, error) {
+		return func(w io.Writer, _ Context) error {
+			_, err := io.WriteString(w, "tag")
+			return err
+		}, nil
+	})
+	clone.AddFilter("f", func(s string) string { return "clone" })
+
+	_, ok := cfg.FindTagDefinition("t")
+	require.False(t, ok)
+	_, ok = cfg.BlockSyntax("unless")
+	require.False(t, ok)
+	def, _ := cfg.findBlockDef("else")
+	require.Equal(t, []string{"if"}, def.ParentTags())
+
+	loc := parser.SourceLoc{}
+	_, err := cfg.Compile(`{% unless x %}{% endunless %}`, loc)
+	require.Error(t, err)
+	_, err = clone.Compile(`{% unless x %}{% else %}{% endunless %}`, loc)
+	require.NoError(t, err)
+	root, err := clone.Compile(`{% t %}{{ "" | f }}`, loc)
+	require.NoError(t, err)
+	buf := new(bytes.Buffer)
+	require.NoError(t, Render(root, buf, map[string]interface{}{}, clone))
+	require.Equal(t, "tagclone", buf.String())
+
+	root, err = cfg.Compile(`{{ "" | f }}`, loc)
+	require.NoError(t, err)
+	buf.Reset()
+	require.NoError(t, Render(root, buf, map[string]interface{}{}, cfg))
+	require.Equal(t, "original", buf.String())
+
+	// the clone has its own template cache
+	cfg.TemplateCache = NewTemplateCache(1)
+	cfg.TemplateCache.Put("a.html", root)
+	clone = cfg.Clone()
+	require.NotNil(t, clone.TemplateCache)
+	require.Equal(t, 0, clone.TemplateCache.Len())
+	clone.TemplateCache.Put("b.html", root)
+	_, ok = cfg.TemplateCache.Get("a.html")
+	require.True(t, ok)
+	_, ok = cfg.TemplateCache.Get("b.html")
+	require.False(t, ok)
+	require.Nil(t, NewConfig().Clone().TemplateCache)
+}
+
+func TestConfig_Fingerprint(t *testing.T) {
+	newConfig := func() Config {
+		cfg := NewConfig()
+		cfg.AddBlock("if").Clause("else")
+		cfg.AddTag("t", func(string) (func(io.Writer, Context) error, error) { return nil, nil })
+		cfg.AddFilter("f", func(s string) string { return s })
+		return cfg
+	}
+	cfg := newConfig()
+	fp := cfg.Fingerprint()
+	require.Len(t, fp, 64)
+	require.Equal(t, fp, newConfig().Fingerprint())
+	require.Equal(t, fp, cfg.Clone().Fingerprint())
+
+	// a filter with the same name and type has the same fingerprint
+	same := newConfig()
+	same.AddFilter("f", func(s string) string { return s + s })
+	require.Equal(t, fp, same.Fingerprint())
+
+	changes := map[string]func(*Config){
+		"tag": func(c *Config) {
+			c.AddTag("u", func(string) (func(io.Writer, Context) error, error) { return nil, nil })
+		},
+		"block":  func(c *Config) { c.AddBlock("unless") },
+		"clause": func(c *Config) { c.AddBlock("case").Clause("else") },
+		"filter": func(c *Config) { c.AddFilter("g", func(s string) string { return s }) },
+		"type":   func(c *Config) { c.AddFilter("f", func(n int) int { return n }) },
+		"pure":   func(c *Config) { c.AddPureFilter("f", func(s string) string { return s }) },
+		"delims": func(c *Config) { c.Delims = []string{"<<", ">>", "<%", "%>"} },
+		"lax":    func(c *Config) { c.LaxDelims = true },
+	}
+	for name, change := range changes {
+		c := newConfig()
+		change(&c)
+		require.NotEqual(t, fp, c.Fingerprint(), name)
+	}
+}
+
+func TestConfig_CacheFingerprint(t *testing.T) {
+	cfg := NewConfig()
+	fp := cfg.Fingerprint()
+	cfg.CacheFingerprint()
+	require.Equal(t, fp, cfg.Fingerprint())
+
+	// the cached value is used, including by copies
+	cfg.AddFilter("f", func(s string) string { return s })
+	copied := cfg
+	require.Equal(t, fp, cfg.Fingerprint())
+	require.Equal(t, fp, copied.Fingerprint())
+
+	// a clone computes its own
+	clone := cfg.Clone()
+	require.NotEqual(t, fp, clone.Fingerprint())
+	clone.AddFilter("g", func(s string) string { return s })
+	require.NotEqual(t, clone.Fingerprint(), cfg.Clone().Fingerprint())
+}
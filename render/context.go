@@ -1,9 +1,8 @@
 package render
 
 import (
-	"bytes"
+	"context"
 	"io"
-	"io/ioutil"
 	"strings"
 
 	"github.com/etecs-ru/liquid/v2/expressions"
@@ -12,6 +11,9 @@ import (
 
 // Context provides the rendering context for a tag renderer.
 type Context interface {
+	// Context returns the context.Context that the template is being rendered with.
+	// A tag that does a lot of work, or that waits on I/O, should stop when it is done.
+	Context() context.Context
 	// Get retrieves the value of a variable from the current lexical environment.
 	Get(name string) interface{}
 	// GetDirect retrieves the value of a variable from the current lexical environment (ignoring lax/strict settings).
@@ -27,9 +29,17 @@ type Context interface {
 	// ExpandTagArg renders the current tag argument string as a Liquid template.
 	// It enables the implementation of tags such as Jekyll's "{% include {{ page.my_variable }} %}" andjekyll-avatar's  "{% avatar {{page.author}} %}".
 	ExpandTagArg() (string, error)
+	// CheckLoopIteration is called by a loop tag before each iteration. It returns an error
+	// if the render has been canceled or has timed out, or if it has exceeded its MaxLoopIterations.
+	CheckLoopIteration() error
+	// Limits returns the resource limits of the current render.
+	Limits() Limits
 	// InnerString is the rendered content of the current block.
-	// It's used in the implementation of the Liquid "capture" tag and the Jekyll "highlght" tag.
+	// It's used in the implementation of the Jekyll "highlght" tag.
 	InnerString() (string, error)
+	// CaptureString is the same as InnerString, except that it fails as soon as the content
+	// exceeds the render's MaxCaptureBytes. It's used in the implementation of the {% capture %} tag.
+	CaptureString() (string, error)
 	// RenderBlock is used in the implementation of the built-in control flow tags.
 	// It's not guaranteed stable.
 	RenderBlock(io.Writer, *BlockNode) error
@@ -37,8 +47,28 @@ type Context interface {
 	// It's not guaranteed stable.
 	RenderChildren(io.Writer) Error
 	// RenderFile parses and renders a template. It's used in the implementation of the {% include %} tag.
-	// RenderFile does not cache the compiled template.
+	// A relative filename is resolved against the directory of the current template, and then against
+	// each of the configured search paths. The template is read by the configured TemplateLoader.
+	// If the configuration has a TemplateCache, the compiled template is cached by its resolved path.
 	RenderFile(string, map[string]interface{}) (string, error)
+	// RenderPartial is like RenderFile, except that the template is rendered in an isolated scope.
+	// It sees the variables that the render started with, and the bindings, but not the variables
+	// of the current template. It's used in the implementation of the {% render %} tag.
+	RenderPartial(string, map[string]interface{}) (string, error)
+	// SetLayout makes the current template extend the named template, which is resolved as
+	// by RenderFile. The current template's output is discarded; it fails if the template has
+	// already written output other than whitespace. Once the current template has rendered,
+	// the named template is rendered in its place, with the same variables. An error that
+	// the named template can't be read is reported at the current tag.
+	// It's used in the implementation of the {% extends %} tag.
+	SetLayout(filename string) error
+	// Layout returns the resolved path of the template that the current template extends,
+	// or "" if it doesn't extend one.
+	Layout() string
+	// LayoutState returns a map that is shared by the templates of the current inheritance
+	// chain: a template, and the layouts that it extends. A template that is rendered by
+	// RenderFile starts a new chain. It's used in the implementation of the {% block %} tag.
+	LayoutState() map[string]interface{}
 	// Set updates the value of a variable in the current lexical environment.
 	// It's used in the implementation of the {% assign %} and {% capture %} tags.
 	Set(name string, value interface{})
@@ -64,21 +94,25 @@ type rendererContext struct {
 	cn   *BlockNode
 }
 
+func (c rendererContext) Context() context.Context {
+	return c.ctx.ctx
+}
+
 func (c rendererContext) Errorf(format string, a ...interface{}) Error {
-	return renderErrorf(c.node, format, a...)
+	return renderErrorf(c.locatable(), format, a...)
 }
 
 func (c rendererContext) WrapError(err error) Error {
-	return wrapRenderError(err, c.node)
+	return wrapRenderError(err, c.locatable())
 }
 
 func (c rendererContext) Evaluate(expr expressions.Expression) (out interface{}, err error) {
-	return c.ctx.Evaluate(expr)
+	return c.ctx.Evaluate(expr, c.locatable())
 }
 
 // EvaluateString evaluates an expression within the template context.
 func (c rendererContext) EvaluateString(source string) (out interface{}, err error) {
-	return expressions.EvaluateString(source, expressions.NewContext(c.ctx.bindings, c.ctx.config.Config.Config))
+	return expressions.EvaluateString(source, c.ctx.expressionContext(c.locatable()))
 }
 
 // Get gets a variable value within an evaluation context.
@@ -105,16 +139,21 @@ func (c rendererContext) GetState(key string, defaulter func() interface{}) inte
 	}
 }
 
-func (c rendererContext) sourceLoc() parser.SourceLoc {
+// locatable returns the node of the current tag or block.
+func (c rendererContext) locatable() parser.Locatable {
 	if c.cn != nil { // nolint: gocritic
-		return c.cn.SourceLoc
+		return c.cn
 	} else if c.node != nil {
-		return c.node.SourceLoc
+		return c.node
 	} else {
 		panic("could not determine SourceLoc")
 	}
 }
 
+func (c rendererContext) sourceLoc() parser.SourceLoc {
+	return c.locatable().SourceLocation()
+}
+
 func (c rendererContext) ExpandTagArg() (string, error) {
 	args := c.TagArgs()
 	if strings.Contains(args, "{{") {
@@ -122,9 +161,8 @@ func (c rendererContext) ExpandTagArg() (string, error) {
 		if err != nil {
 			return "", err
 		}
-		buf := new(bytes.Buffer)
-		err = RenderWithState(root, buf, c.ctx.bindings, c.ctx.state, c.ctx.config)
-		if err != nil {
+		buf := c.ctx.limits.newBuffer()
+		if err := c.ctx.render(buf, root); err != nil {
 			return "", err
 		}
 		return buf.String(), nil
@@ -146,31 +184,94 @@ func (c rendererContext) RenderChildren(w io.Writer) Error {
 }
 
 func (c rendererContext) RenderFile(filename string, b map[string]interface{}) (string, error) {
-	source, err := ioutil.ReadFile(filename)
-	if err != nil {
-		return "", err
+	bindings := map[string]interface{}{}
+	for k, v := range c.ctx.bindings {
+		bindings[k] = v
 	}
-	root, err := c.ctx.config.Compile(string(source), c.node.SourceLoc)
-	if err != nil {
-		return "", err
+	for k, v := range b {
+		bindings[k] = v
 	}
+	return c.renderFile(filename, bindings)
+}
+
+func (c rendererContext) RenderPartial(filename string, b map[string]interface{}) (string, error) {
 	bindings := map[string]interface{}{}
-	for k, v := range c.ctx.bindings {
+	for k, v := range c.ctx.globals {
 		bindings[k] = v
 	}
 	for k, v := range b {
 		bindings[k] = v
 	}
-	buf := new(bytes.Buffer)
-	if err := RenderWithState(root, buf, bindings, c.ctx.state, c.ctx.config); err != nil {
+	return c.renderFile(filename, bindings)
+}
+
+// renderFile renders the named template with bindings.
+func (c rendererContext) renderFile(filename string, bindings map[string]interface{}) (string, error) {
+	depth := c.ctx.includeDepth + 1
+	if err := c.ctx.limits.checkIncludeDepth(depth); err != nil {
+		return "", c.WrapError(err)
+	}
+	path, root, err := c.ctx.config.loadTemplate(filename, c.SourceFile())
+	if err != nil {
+		return "", err
+	}
+	ctx := c.ctx.withBindings(bindings)
+	ctx.includeDepth = depth
+	buf := c.ctx.limits.newBuffer()
+	if err := ctx.renderTemplate(buf, path, root, nil); err != nil {
 		return "", err
 	}
 	return buf.String(), nil
 }
 
+func (c rendererContext) SetLayout(filename string) error {
+	path, root, err := c.ctx.config.loadTemplate(filename, c.SourceFile())
+	if err != nil {
+		return c.WrapError(err)
+	}
+	if err := c.ctx.frame.setLayout(path, root); err != nil {
+		return c.WrapError(err)
+	}
+	return nil
+}
+
+func (c rendererContext) Layout() string {
+	return c.ctx.frame.layoutPath
+}
+
+func (c rendererContext) LayoutState() map[string]interface{} {
+	f := c.ctx.frame
+	if f.state == nil {
+		f.state = map[string]interface{}{}
+	}
+	return f.state
+}
+
+func (c rendererContext) CheckLoopIteration() error {
+	if err := c.ctx.checkCanceled(c.locatable()); err != nil {
+		return err
+	}
+	if err := c.ctx.limits.addLoopIteration(); err != nil {
+		return c.WrapError(err)
+	}
+	return nil
+}
+
+func (c rendererContext) Limits() Limits {
+	return c.ctx.limits.Limits
+}
+
 // InnerString renders the children to a string.
 func (c rendererContext) InnerString() (string, error) {
-	buf := new(bytes.Buffer)
+	return c.renderChildrenTo(c.ctx.limits.newBuffer())
+}
+
+// CaptureString renders the children to a string, that is bounded by MaxCaptureBytes.
+func (c rendererContext) CaptureString() (string, error) {
+	return c.renderChildrenTo(c.ctx.limits.newCaptureBuffer())
+}
+
+func (c rendererContext) renderChildrenTo(buf *limitedBuffer) (string, error) {
 	if err := c.RenderChildren(buf); err != nil {
 		return "", err
 	}
@@ -183,7 +284,7 @@ func (c rendererContext) Set(name string, value interface{}) {
 }
 
 func (c rendererContext) SourceFile() string {
-	return c.node.SourceLoc.Pathname
+	return c.sourceLoc().Pathname
 }
 
 func (c rendererContext) TagArgs() string {
@@ -1,6 +1,8 @@
 package render
 
 import (
+	"context"
+
 	"github.com/etecs-ru/liquid/v2/parser"
 )
 
@@ -8,10 +10,29 @@ import (
 type Error interface {
 	Path() string
 	LineNumber() int
+	ColumnNumber() int
+	Offset() int
 	Cause() error
 	Error() string
 }
 
+// A ControlFlowError is an error that a tag returns to transfer control to an enclosing tag,
+// such as {% break %} within a {% for %} loop. It is never replaced by an ErrorPlaceholder.
+type ControlFlowError interface {
+	error
+	ControlFlow()
+}
+
+// isRecoverable returns true if rendering can continue past err.
+func isRecoverable(err Error) bool {
+	switch cause := err.Cause(); cause.(type) {
+	case ControlFlowError, *LimitError:
+		return false
+	default:
+		return cause != context.Canceled && cause != context.DeadlineExceeded
+	}
+}
+
 func renderErrorf(loc parser.Locatable, format string, a ...interface{}) Error {
 	return parser.Errorf(loc, format, a...)
 }
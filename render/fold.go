@@ -0,0 +1,118 @@
+package render
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/etecs-ru/liquid/v2/expressions"
+)
+
+// A BlockFolder selects, when a template is compiled, the body that a control tag renders, if
+// this doesn't depend on the render. constant evaluates one of the tag's expressions; it reports
+// false if the expression isn't constant. The folder returns the block or clause whose body the
+// tag renders, or nil if the tag renders nothing. ok is false if the tag can't be folded.
+type BlockFolder func(node BlockNode, constant func(expressions.Expression) (interface{}, bool)) (body *BlockNode, ok bool)
+
+// Folder sets the folder for a control tag definition.
+func (b blockDefBuilder) Folder(fn BlockFolder) blockDefBuilder {
+	b.tag.folder = fn
+	return b
+}
+
+// FoldedNode is an object, or a control tag, whose output was computed when the template was
+// compiled, because its expressions are constant.
+type FoldedNode struct {
+	// Node is the ObjectNode or BlockNode that was folded. It is used for error reporting and
+	// analysis; it isn't rendered.
+	Node
+	text, escaped string // the output of an object, without and with auto-escaping
+	body          []Node // the body that a control tag renders
+}
+
+func (n *FoldedNode) render(w *trimWriter, ctx nodeContext) Error {
+	switch orig := n.Node.(type) {
+	case *ObjectNode:
+		if err := w.TrimLeft(orig.TrimLeft); err != nil {
+			return wrapRenderError(err, n)
+		}
+		text := n.text
+		if ctx.config.AutoEscape {
+			text = n.escaped
+		}
+		if _, err := io.WriteString(w, text); err != nil {
+			return wrapRenderError(err, n)
+		}
+		w.TrimRight(orig.TrimRight)
+		return nil
+	default:
+		if err := ctx.checkCanceled(n); err != nil {
+			return err
+		}
+		return wrapRenderError(ctx.RenderSequence(w, n.body), n)
+	}
+}
+
+// Constant evaluates expr, if it doesn't use variables, and applies only pure filters. It
+// returns false if expr isn't constant, or if its evaluation fails; the error is then reported
+// when the template is rendered. The compiler uses it to fold constant objects and control tags.
+func (c Config) Constant(expr expressions.Expression) (value interface{}, ok bool) {
+	syntax := expressions.Syntax(expr)
+	if syntax == nil {
+		return nil, false
+	}
+	ok = true
+	expressions.Inspect(syntax, func(n expressions.Node) bool {
+		switch n := n.(type) {
+		case *expressions.Variable:
+			ok = false
+		case *expressions.Filter:
+			ok = ok && c.IsPureFilter(n.Name)
+		}
+		return ok
+	})
+	if !ok {
+		return nil, false
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			value, ok = nil, false
+		}
+	}()
+	cfg := c.Config.Config
+	cfg.FilterErrorMode, cfg.VariableErrorMode = expressions.StrictMode{}, expressions.StrictMode{}
+	value, err := expr.Evaluate(expressions.NewContext(map[string]interface{}{}, cfg))
+	if err != nil {
+		return nil, false
+	}
+	return value, true
+}
+
+// foldObject returns a FoldedNode for n, if its expression is constant.
+func (c compiler) foldObject(n *ObjectNode) Node {
+	value, ok := c.Constant(n.expr)
+	if !ok {
+		return n
+	}
+	var text, escaped bytes.Buffer
+	if writeObject(&text, value) != nil || writeObject(htmlEscapeWriter{&escaped}, value) != nil {
+		return n
+	}
+	return &FoldedNode{Node: n, text: text.String(), escaped: escaped.String()}
+}
+
+// foldBlock returns a FoldedNode for n, if its definition has a folder, and the folder selects
+// the body that n renders.
+func (c compiler) foldBlock(n *BlockNode, cd *blockSyntax) Node {
+	if cd.folder == nil {
+		return n
+	}
+	body, ok := cd.folder(*n, c.Constant)
+	if !ok {
+		return n
+	}
+	folded := &FoldedNode{Node: n}
+	if body != nil {
+		folded.body = body.Body
+	}
+	return folded
+}
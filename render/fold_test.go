@@ -0,0 +1,127 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/etecs-ru/liquid/v2/expressions"
+	"github.com/etecs-ru/liquid/v2/parser"
+	"github.com/stretchr/testify/require"
+)
+
+func addFoldTestFilters(cfg *Config) *int {
+	calls := 0
+	cfg.AddPureFilter("up", strings.ToUpper)
+	cfg.AddPureFilter("fail", func(interface{}) (interface{}, error) { return nil, fmt.Errorf("fail error") })
+	cfg.AddFilter("count", func(s string) string {
+		calls++
+		return s
+	})
+	return &calls
+}
+
+// foldTestNodes returns the types of the children of a compiled sequence.
+func foldTestNodes(root Node) (types []string) {
+	for _, n := range root.(*SeqNode).Children {
+		types = append(types, fmt.Sprintf("%T", n))
+	}
+	return
+}
+
+func TestCompile_foldObjects(t *testing.T) {
+	cfg := NewConfig()
+	calls := addFoldTestFilters(&cfg)
+	root, err := cfg.Compile(`{{ "a<b" | up }}, {{- "" -}} ,{{ "x" | count }}{{ s | up }}{{ 2 }}`, parser.SourceLoc{})
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"*render.FoldedNode", "*render.TextNode", "*render.FoldedNode", "*render.TextNode",
+		"*render.ObjectNode", "*render.ObjectNode", "*render.FoldedNode",
+	}, foldTestNodes(root))
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, Render(root, buf, map[string]interface{}{"s": "s"}, cfg))
+	require.Equal(t, "A<B,,xS2", buf.String())
+	require.Equal(t, 1, *calls)
+
+	cfg.AutoEscape = true
+	buf.Reset()
+	require.NoError(t, Render(root, buf, map[string]interface{}{"s": "s"}, cfg))
+	require.Equal(t, "A&lt;B,,xS2", buf.String())
+
+	// an expression whose evaluation fails isn't folded; it fails when it is rendered
+	root, err = cfg.Compile(`{{ 1 | fail }}`, parser.SourceLoc{})
+	require.NoError(t, err)
+	require.IsType(t, &ObjectNode{}, root.(*SeqNode).Children[0])
+	err = Render(root, ioutil.Discard, map[string]interface{}{}, cfg)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "fail error")
+	require.Equal(t, 1, err.ColumnNumber())
+}
+
+func TestCompile_foldBlocks(t *testing.T) {
+	cfg := NewConfig()
+	addFoldTestFilters(&cfg)
+	cfg.AddBlock("choose").Clause("otherwise").Folder(func(n BlockNode, constant func(expressions.Expression) (interface{}, bool)) (*BlockNode, bool) {
+		expr, err := expressions.Parse(n.Args)
+		if err != nil {
+			return nil, false
+		}
+		value, ok := constant(expr)
+		switch {
+		case !ok:
+			return nil, false
+		case value != nil && value != false:
+			return &n, true
+		case len(n.Clauses) > 0:
+			return n.Clauses[0], true
+		default:
+			return nil, true
+		}
+	}).Compiler(func(BlockNode) (func(io.Writer, Context) error, error) {
+		return func(w io.Writer, ctx Context) error {
+			_, err := io.WriteString(w, "rendered")
+			return err
+		}, nil
+	})
+
+	tests := []struct{ in, out, node string }{
+		{`{% choose "a" | up %}yes{% otherwise %}no{% endchoose %}`, "yes", "*render.FoldedNode"},
+		{`{% choose false %}yes{% otherwise %}{{ "no" | up }}{% endchoose %}`, "NO", "*render.FoldedNode"},
+		{`{% choose false %}yes{% endchoose %}`, "", "*render.FoldedNode"},
+		{`{% choose a %}yes{% otherwise %}no{% endchoose %}`, "rendered", "*render.BlockNode"},
+		{`{% choose "a" | count %}yes{% endchoose %}`, "rendered", "*render.BlockNode"},
+	}
+	for _, test := range tests {
+		root, err := cfg.Compile(test.in, parser.SourceLoc{})
+		require.NoError(t, err, test.in)
+		require.Equal(t, []string{test.node}, foldTestNodes(root), test.in)
+		buf := new(bytes.Buffer)
+		require.NoError(t, Render(root, buf, map[string]interface{}{}, cfg), test.in)
+		require.Equal(t, test.out, buf.String(), test.in)
+	}
+}
+
+func TestCompile_foldAnalyze(t *testing.T) {
+	cfg := NewConfig()
+	addFoldTestFilters(&cfg)
+	root, err := cfg.Compile(`{{ "a" | up }}`, parser.SourceLoc{})
+	require.NoError(t, err)
+	require.IsType(t, &FoldedNode{}, root.(*SeqNode).Children[0])
+
+	// the analysis and the walk see the node that was folded
+	a := Analyze(root, cfg)
+	require.Len(t, a.Filters, 1)
+	require.Equal(t, "up", a.Filters[0].Name)
+	var visited []string
+	Inspect(root, func(n Node) bool {
+		if n != nil {
+			visited = append(visited, fmt.Sprintf("%T", n))
+		}
+		return true
+	})
+	require.Equal(t, []string{"*render.SeqNode", "*render.FoldedNode", "*render.ObjectNode"}, visited)
+}
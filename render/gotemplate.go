@@ -0,0 +1,458 @@
+package render
+
+import (
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/etecs-ru/liquid/v2/expressions"
+	"github.com/etecs-ru/liquid/v2/parser"
+	"github.com/etecs-ru/liquid/v2/values"
+)
+
+// A GoTemplate is a template that has been compiled to Go source code, by the codegen package.
+//
+// The generated code renders text, objects, and the standard control flow tags, directly. It
+// renders the other tags, its fragments, with the interpreter. Its nodes are numbered in the
+// order that parser.Inspect visits the template's syntax tree.
+type GoTemplate struct {
+	Path        string         // the path of the template, for error reporting and the {% include %} tag
+	Source      string         // the source of the template
+	AST         []byte         // the parser.MarshalASTBinary of the template's syntax tree, or nil
+	Fingerprint string         // the Config.Fingerprint of the configuration that the code was generated with
+	Checksum    uint64         // the parser.Checksum of the template's syntax tree, after it was rewritten
+	Fragments   []int          // the nodes that Render renders with the interpreter
+	Render      func(*Runtime) // the generated code
+}
+
+// A GoNode is the root of a template that was loaded from a GoTemplate.
+type GoNode struct {
+	cfg       Config
+	root      parser.ASTNode
+	tree      Node // the template, compiled for the interpreter; see renderTree
+	treeErr   Error
+	treeOnce  sync.Once
+	renderFn  func(*Runtime)
+	nodes     []parser.ASTNode
+	tokens    []parser.Token
+	fragments []Node
+	sourcelessNode
+}
+
+// LoadGoTemplate loads a template that was compiled to Go. It rewrites the template's syntax
+// tree, as Compile does, and compiles its fragments. It decodes the syntax tree from the
+// template's AST, if the template was generated with a configuration that has the same
+// Fingerprint; otherwise it parses the template's source. It returns an error if the syntax tree
+// isn't the one that the code was generated from; for example, if the template was compiled to
+// Go with different tags or rewriters.
+//
+// The rest of the template is compiled for the interpreter only if it's needed: to find the
+// template's variables, or to render it past errors.
+func (c Config) LoadGoTemplate(t *GoTemplate) (Node, parser.Error) {
+	loc := parser.SourceLoc{Pathname: t.Path, LineNo: 1}
+	var (
+		root parser.ASTNode
+		err  parser.Error
+	)
+	if t.AST != nil && t.Fingerprint == c.Fingerprint() {
+		var derr error
+		if root, derr = c.UnmarshalASTBinary(t.AST, t.Source); derr != nil {
+			return nil, parser.WrapError(derr, parser.Token{SourceLoc: loc})
+		}
+	} else if root, err = c.Parse(t.Source, loc); err != nil {
+		return nil, err
+	}
+	if root, err = c.Rewrite(root, loc); err != nil {
+		return nil, err
+	}
+	if parser.Checksum(root) != t.Checksum {
+		return nil, parser.Errorf(parser.Token{SourceLoc: loc}, "the template doesn't match its generated code; compile it to Go again")
+	}
+	n := &GoNode{cfg: c, root: root, renderFn: t.Render}
+	parser.Inspect(root, func(node parser.ASTNode) bool {
+		if node != nil {
+			n.nodes = append(n.nodes, node)
+			n.tokens = append(n.tokens, nodeToken(node))
+		}
+		return true
+	})
+	n.fragments = make([]Node, len(n.nodes))
+	for _, i := range t.Fragments {
+		if i < 0 || i >= len(n.nodes) {
+			return nil, parser.Errorf(parser.Token{SourceLoc: loc}, "the template doesn't match its generated code; compile it to Go again")
+		}
+		f, err := compiler{c, nil}.compileNode(n.nodes[i])
+		if err != nil {
+			return nil, err
+		}
+		n.fragments[i] = f
+	}
+	return n, nil
+}
+
+// renderTree returns the template, compiled for the interpreter. It compiles it the first time
+// that it's called.
+func (n *GoNode) renderTree() (Node, Error) {
+	n.treeOnce.Do(func() {
+		var err parser.Error
+		if n.tree, err = (compiler{n.cfg, nil}).compileNode(n.root); err != nil {
+			n.treeErr = err
+		}
+	})
+	return n.tree, n.treeErr
+}
+
+func nodeToken(node parser.ASTNode) parser.Token {
+	switch n := node.(type) {
+	case *parser.ASTBlock:
+		return n.Token
+	case *parser.ASTRaw:
+		return n.Token
+	case *parser.ASTComment:
+		return n.Token
+	case *parser.ASTTag:
+		return n.Token
+	case *parser.ASTText:
+		return n.Token
+	case *parser.ASTObject:
+		return n.Token
+	default:
+		return parser.Token{}
+	}
+}
+
+func (n *GoNode) render(w *trimWriter, ctx nodeContext) (err Error) {
+	if ctx.findVariablesOnly || ctx.errors != nil {
+		// The generated code doesn't find variables, or continue past errors.
+		tree, err := n.renderTree()
+		if err != nil {
+			return err
+		}
+		return tree.render(w, ctx)
+	}
+	r := &Runtime{n: n, ctx: ctx, w: w}
+	r.cfg = ctx.expressionConfig(runtimeNode{r})
+	r.ectx = expressions.NewContext(ctx.bindings, r.cfg)
+	defer func() {
+		if rec := recover(); rec != nil {
+			err = r.recover(rec)
+		}
+	}()
+	n.renderFn(r)
+	return nil
+}
+
+// A Runtime is the state of a render of a GoTemplate. The generated code calls its methods.
+//
+// A method panics if the render fails. The GoNode recovers the panic, and returns the error;
+// as it does a panic from the expression evaluation that Expression.Evaluate would recover.
+type Runtime struct {
+	n     *GoNode
+	ctx   nodeContext
+	cfg   expressions.Config
+	ectx  expressions.Context
+	w     *trimWriter
+	stack []runtimeWriter
+	i     int // the current node
+}
+
+// A runtimeWriter is an entry of the stack of writers of the enclosing blocks.
+type runtimeWriter struct {
+	w   *trimWriter    // the writer of the enclosing block
+	buf *limitedBuffer // the buffer of a capture, or nil
+}
+
+// A runtimeError is a render error, that a Runtime method panics with.
+type runtimeError struct{ err Error }
+
+// runtimeNode locates warnings at the current node of a Runtime.
+type runtimeNode struct{ r *Runtime }
+
+func (n runtimeNode) SourceLocation() parser.SourceLoc { return n.r.token().SourceLoc }
+func (n runtimeNode) SourceText() string               { return n.r.token().Source }
+
+func (r *Runtime) token() parser.Token { return r.n.tokens[r.i] }
+
+func (r *Runtime) fail(err error) {
+	if err != nil {
+		panic(runtimeError{wrapRenderError(err, r.token())})
+	}
+}
+
+func (r *Runtime) recover(rec interface{}) Error {
+	switch e := rec.(type) {
+	case runtimeError:
+		return e.err
+	case values.TypeError, expressions.InterpreterError, expressions.UndefinedFilter, expressions.UndefinedVariable, expressions.FilterError:
+		return wrapRenderError(e.(error), r.token())
+	}
+	panic(rec)
+}
+
+// At starts the node i. It fails if the render has been canceled.
+func (r *Runtime) At(i int) {
+	r.i = i
+	if err := r.ctx.checkCanceled(&r.n.tokens[i]); err != nil {
+		panic(runtimeError{err})
+	}
+}
+
+// Text renders the text node i.
+func (r *Runtime) Text(i int) {
+	r.At(i)
+	_, err := io.WriteString(r.w, r.token().Source)
+	r.fail(err)
+}
+
+// Raw renders the raw tag i.
+func (r *Runtime) Raw(i int) {
+	r.At(i)
+	for _, s := range r.n.nodes[i].(*parser.ASTRaw).Slices {
+		_, err := io.WriteString(r.w, s)
+		r.fail(err)
+	}
+}
+
+// Tag starts the object or tag i, whose start is trimmed if trimLeft is true.
+func (r *Runtime) Tag(i int, trimLeft bool) {
+	r.At(i)
+	r.fail(r.w.TrimLeft(trimLeft))
+}
+
+// TrimRight trims the whitespace that follows the current object or tag.
+func (r *Runtime) TrimRight() {
+	r.w.TrimRight(true)
+}
+
+// Fragment renders node i with the interpreter.
+func (r *Runtime) Fragment(i int) {
+	r.At(i)
+	if err := r.n.fragments[i].render(r.w, r.ctx); err != nil {
+		panic(runtimeError{err})
+	}
+}
+
+// Write writes the value of an object.
+func (r *Runtime) Write(v interface{}) {
+	var out io.Writer = r.w
+	if r.ctx.config.AutoEscape {
+		out = htmlEscapeWriter{r.w}
+	}
+	r.fail(writeObject(out, interfaceOf(v)))
+}
+
+// Push starts the body of a block.
+func (r *Runtime) Push() {
+	r.stack = append(r.stack, runtimeWriter{w: r.w})
+	r.w = &trimWriter{w: r.w}
+}
+
+// Pop ends the body of the block i.
+func (r *Runtime) Pop(i int) {
+	if err := r.w.Flush(); err != nil {
+		r.i = i
+		r.fail(err)
+	}
+	r.w = r.stack[len(r.stack)-1].w
+	r.stack = r.stack[:len(r.stack)-1]
+}
+
+// Discard ends the bodies of the n innermost blocks, without the whitespace that they hold back.
+// It's used by {% break %} and {% continue %}.
+func (r *Runtime) Discard(n int) {
+	r.w = r.stack[len(r.stack)-n].w
+	r.stack = r.stack[:len(r.stack)-n]
+}
+
+// BeginCapture starts the body of a {% capture %} block.
+func (r *Runtime) BeginCapture() {
+	buf := r.ctx.limits.newCaptureBuffer()
+	r.stack = append(r.stack, runtimeWriter{r.w, buf})
+	r.w = &trimWriter{w: buf}
+}
+
+// EndCapture ends the body of the {% capture %} block i, and assigns its output to a variable.
+func (r *Runtime) EndCapture(i int, name string) {
+	buf := r.stack[len(r.stack)-1].buf
+	r.Pop(i)
+	r.ctx.bindings[name] = buf.String()
+}
+
+// LoopIteration is called before each iteration of the loop i. It fails if the render has been
+// canceled, or if it has exceeded its MaxLoopIterations.
+func (r *Runtime) LoopIteration(i int) {
+	r.At(i)
+	r.fail(r.ctx.limits.addLoopIteration())
+}
+
+// The generated code evaluates an expression to an interface{}, that holds either a Go value or
+// a values.Value, and stands for values.ValueOf of what it holds. It creates the Value only if an
+// operation needs it, since values.ValueOf allocates; as the expression VM does.
+
+// Get returns the value of a variable.
+func (r *Runtime) Get(name string) interface{} {
+	return r.ectx.Get(name)
+}
+
+// GetDirect returns the value of a variable, ignoring the configuration's VariableErrorMode.
+func (r *Runtime) GetDirect(name string) interface{} {
+	return r.ctx.bindings[name]
+}
+
+// Set sets the value of a variable.
+func (r *Runtime) Set(name string, value interface{}) {
+	r.ctx.bindings[name] = value
+}
+
+// Assign sets a variable to the value of an expression.
+func (r *Runtime) Assign(name string, v interface{}) {
+	r.ctx.bindings[name] = interfaceOf(v)
+}
+
+// Interface returns the Go value of the value of an expression.
+func (r *Runtime) Interface(v interface{}) interface{} { return interfaceOf(v) }
+
+// interfaceOf returns values.ValueOf(v).Interface(), without creating the Value for common types.
+func interfaceOf(v interface{}) interface{} {
+	switch v.(type) {
+	case nil, bool, int, float64, string, []interface{}, map[string]interface{}:
+		return v
+	}
+	return values.ValueOf(v).Interface()
+}
+
+// isPlain returns true if v is nil, or a Go value whose Value's methods are the functions of the
+// values package.
+func isPlain(v interface{}) bool {
+	switch v.(type) {
+	case nil, bool, int, float64, string:
+		return true
+	}
+	return false
+}
+
+// Property returns the named property of v. key is values.ValueOf of the name.
+func (r *Runtime) Property(v interface{}, key values.Value) interface{} {
+	if m, ok := v.(map[string]interface{}); ok {
+		// This is mapValue.PropertyValue, without creating the Value.
+		name := key.Interface().(string)
+		if p, ok := m[name]; ok {
+			return p
+		}
+		if name == "size" {
+			return len(m)
+		}
+		return nil
+	}
+	return values.ValueOf(v).PropertyValue(key)
+}
+
+// Index returns the element of v at an index.
+func (r *Runtime) Index(v, index interface{}) interface{} {
+	// These are arrayValue.IndexValue and mapValue.IndexValue, without creating the Values.
+	switch v := v.(type) {
+	case []interface{}:
+		if n, ok := index.(int); ok {
+			if n < 0 {
+				n += len(v)
+			}
+			if 0 <= n && n < len(v) {
+				return v[n]
+			}
+			return nil
+		}
+	case map[string]interface{}:
+		if k, ok := index.(string); ok {
+			return v[k]
+		}
+	}
+	return values.ValueOf(v).IndexValue(values.ValueOf(index))
+}
+
+// Int returns the value of the start or end of a range.
+func (r *Runtime) Int(v interface{}) int {
+	if n, ok := v.(int); ok {
+		return n
+	}
+	return values.ValueOf(v).Int()
+}
+
+// Range returns a range.
+func (r *Runtime) Range(start, end int) interface{} {
+	return values.ValueOf(values.NewRange(start, end))
+}
+
+// Truthy returns true unless the value of an expression is nil or false.
+func (r *Runtime) Truthy(v interface{}) bool {
+	x := interfaceOf(v)
+	return x != nil && x != false
+}
+
+// Test returns the test of an operand of and or or: values.ValueOf(v).Test().
+func (r *Runtime) Test(v interface{}) bool {
+	if isPlain(v) {
+		return v != nil && v != false
+	}
+	return values.ValueOf(v).Test()
+}
+
+// Equal implements the == operator.
+func (r *Runtime) Equal(a, b interface{}) bool {
+	if isPlain(a) && isPlain(b) {
+		return values.Equal(a, b)
+	}
+	return values.ValueOf(a).Equal(values.ValueOf(b))
+}
+
+// Less implements the < operator.
+func (r *Runtime) Less(a, b interface{}) bool {
+	if isPlain(a) && isPlain(b) {
+		return values.Less(a, b)
+	}
+	return values.ValueOf(a).Less(values.ValueOf(b))
+}
+
+// Greater implements the > operator.
+func (r *Runtime) Greater(a, b interface{}) bool { return r.Less(b, a) }
+
+// GreaterOrEqual implements the >= operator.
+func (r *Runtime) GreaterOrEqual(a, b interface{}) bool { return r.Less(b, a) || r.Equal(a, b) }
+
+// LessOrEqual implements the <= operator.
+func (r *Runtime) LessOrEqual(a, b interface{}) bool { return r.Less(a, b) || r.Equal(a, b) }
+
+// Contains implements the contains operator.
+func (r *Runtime) Contains(a, b interface{}) bool {
+	if s, ok := a.(string); ok {
+		if substr, ok := b.(string); ok {
+			return strings.Contains(s, substr)
+		}
+	}
+	return values.ValueOf(a).Contains(values.ValueOf(b))
+}
+
+// A FilterRef is a filter that Runtime.Filter has looked up.
+type FilterRef struct {
+	name string
+	fn   interface{}
+}
+
+// Filter looks up a filter. The generated code looks up a filter before it evaluates the filter's
+// receiver and arguments, as the interpreter does.
+func (r *Runtime) Filter(name string) FilterRef {
+	return FilterRef{name, r.cfg.GetFilter(name)}
+}
+
+// Apply applies a filter to a receiver and arguments. It calls a filter whose signature is common
+// among filters directly; see values.CallDirect.
+func (r *Runtime) Apply(f FilterRef, receiver interface{}, args ...interface{}) interface{} {
+	var buf [4]interface{}
+	in := append(buf[:0], interfaceOf(receiver))
+	for _, arg := range args {
+		in = append(in, interfaceOf(arg))
+	}
+	if result, ok, err := values.CallDirect(f.fn, in); ok && err == nil {
+		return result
+	}
+	return expressions.ApplyFilter(r.ectx, f.name, f.fn, in)
+}
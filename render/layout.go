@@ -0,0 +1,107 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+
+	"github.com/etecs-ru/liquid/v2/parser"
+)
+
+// A templateFrame records the layout state of a template while it renders.
+//
+// When a template extends a layout, the frame of the layout inherits its state and chain.
+// The templates in an inheritance chain therefore share a frame state, that the {% block %} tag
+// uses to pass overrides up the chain. A template that is included starts a new chain.
+type templateFrame struct {
+	layout     Node   // the compiled layout that the template extends, or nil
+	layoutPath string // the resolved path of layout
+	chain      []string
+	state      map[string]interface{}
+	written    bool // true once the template has written output other than whitespace
+}
+
+// next returns the frame that the template's layout renders in.
+func (f *templateFrame) next() *templateFrame {
+	return &templateFrame{chain: f.chain, state: f.state}
+}
+
+// setLayout records that the template extends the layout at path.
+func (f *templateFrame) setLayout(path string, root Node) error {
+	if f.layout != nil {
+		return fmt.Errorf("the template already extends %q", f.layoutPath)
+	}
+	if f.written {
+		return fmt.Errorf("the template extends %q after it has written output", path)
+	}
+	for _, p := range f.chain {
+		if p == path {
+			return fmt.Errorf("circular layout: %s -> %s", strings.Join(f.chain, " -> "), path)
+		}
+	}
+	f.layout, f.layoutPath = root, path
+	f.chain = append(f.chain[:len(f.chain):len(f.chain)], path)
+	return nil
+}
+
+// A layoutWriter discards the output of a template once the template has declared its layout.
+//
+// Until the template writes something other than whitespace, the layoutWriter holds back its
+// output, which is discarded if the template then declares a layout. After that, the output
+// has already been written, and the template can no longer declare a layout.
+type layoutWriter struct {
+	w     io.Writer
+	frame *templateFrame
+	space []byte // the leading whitespace, that is held back
+}
+
+func (lw *layoutWriter) Write(b []byte) (int, error) {
+	switch {
+	case lw.frame.layout != nil:
+		return len(b), nil
+	case !lw.frame.written:
+		if len(bytes.TrimLeftFunc(b, unicode.IsSpace)) == 0 {
+			lw.space = append(lw.space, b...)
+			return len(b), nil
+		}
+		lw.frame.written = true
+		if err := lw.Flush(); err != nil {
+			return 0, err
+		}
+	}
+	return lw.w.Write(b)
+}
+
+// Flush writes the whitespace that is held back.
+func (lw *layoutWriter) Flush() error {
+	if len(lw.space) == 0 {
+		return nil
+	}
+	_, err := lw.w.Write(lw.space)
+	lw.space = nil
+	return err
+}
+
+// renderTemplate renders the root of a template. If the template extends a layout, its output
+// is discarded, and the layout is rendered in its place with the same variables;
+// and so on up the inheritance chain. The path of the template, if it's known, is used to detect
+// circular layouts.
+func (c nodeContext) renderTemplate(w io.Writer, path string, root Node, limits *limiter) Error {
+	frame := &templateFrame{}
+	if path != "" {
+		frame.chain = []string{path}
+	}
+	for {
+		c.frame = frame
+		lw := &layoutWriter{w: w, frame: frame}
+		if err := c.renderTo(&trimWriter{w: lw, limits: limits}, root); err != nil {
+			return err
+		}
+		if frame.layout == nil {
+			return wrapRenderError(lw.Flush(), parser.Token{})
+		}
+		root, frame = frame.layout, frame.next()
+	}
+}
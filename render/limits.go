@@ -0,0 +1,120 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+)
+
+// Limits bound the resources that a render may use. This lets an application render
+// templates that it doesn't trust. A zero field means that there is no limit.
+type Limits struct {
+	// MaxOutputBytes is the maximum number of bytes of output. It also bounds each piece of output
+	// that the render holds in memory, such as the content of a {% capture %} tag or of an
+	// included template.
+	MaxOutputBytes int
+	// MaxLoopIterations is the maximum number of iterations, summed over all the loops in the render.
+	MaxLoopIterations int
+	// MaxIncludeDepth is the maximum nesting depth of {% include %} tags.
+	MaxIncludeDepth int
+	// MaxCaptureBytes is the maximum size of a {% capture %} tag's content.
+	MaxCaptureBytes int
+	// Timeout is the maximum wall-clock time of the render.
+	Timeout time.Duration
+}
+
+// A LimitError is the cause of the error from a render that exceeds one of its Limits.
+type LimitError struct {
+	Limit string      // the name of the Limits field, e.g. "MaxLoopIterations"
+	Value interface{} // the value of the field
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("render limit exceeded: %s is %v", e.Limit, e.Value)
+}
+
+// A limiter keeps track of a render's use of the resources that are bounded by Limits.
+// It is shared by all the node contexts of a render, including those of included templates.
+type limiter struct {
+	Limits
+	deadline       time.Time
+	outputBytes    int
+	loopIterations int
+}
+
+func newLimiter(limits Limits) *limiter {
+	l := limiter{Limits: limits}
+	if limits.Timeout > 0 {
+		l.deadline = time.Now().Add(limits.Timeout)
+	}
+	return &l
+}
+
+// addOutput records n bytes of output.
+func (l *limiter) addOutput(n int) error {
+	if l == nil {
+		return nil
+	}
+	l.outputBytes += n
+	if l.MaxOutputBytes > 0 && l.outputBytes > l.MaxOutputBytes {
+		return &LimitError{"MaxOutputBytes", l.MaxOutputBytes}
+	}
+	return nil
+}
+
+// addLoopIteration records an iteration of a loop.
+func (l *limiter) addLoopIteration() error {
+	l.loopIterations++
+	if l.MaxLoopIterations > 0 && l.loopIterations > l.MaxLoopIterations {
+		return &LimitError{"MaxLoopIterations", l.MaxLoopIterations}
+	}
+	return nil
+}
+
+// checkIncludeDepth checks the nesting depth of an include.
+func (l *limiter) checkIncludeDepth(depth int) error {
+	if l.MaxIncludeDepth > 0 && depth > l.MaxIncludeDepth {
+		return &LimitError{"MaxIncludeDepth", l.MaxIncludeDepth}
+	}
+	return nil
+}
+
+// timedOut returns true if the render has run past its Timeout.
+func (l *limiter) timedOut() bool {
+	return !l.deadline.IsZero() && !time.Now().Before(l.deadline)
+}
+
+// A limitedBuffer holds output that a render keeps in memory, such as the content of a
+// {% capture %} tag or of an included template. Its content isn't output yet, so it doesn't count
+// toward the render's MaxOutputBytes. Instead, a write fails as soon as the content would exceed
+// MaxOutputBytes or, if it is positive, max.
+type limitedBuffer struct {
+	buf    bytes.Buffer
+	limits *limiter
+	limit  string // the name of the Limits field whose value is max, e.g. "MaxCaptureBytes"
+	max    int
+}
+
+// newBuffer returns a buffer that is bounded by the render's MaxOutputBytes.
+func (l *limiter) newBuffer() *limitedBuffer {
+	return &limitedBuffer{limits: l}
+}
+
+// newCaptureBuffer returns a buffer that is bounded by the render's MaxOutputBytes and
+// MaxCaptureBytes.
+func (l *limiter) newCaptureBuffer() *limitedBuffer {
+	return &limitedBuffer{limits: l, limit: "MaxCaptureBytes", max: l.MaxCaptureBytes}
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	n := b.buf.Len() + len(p)
+	if b.max > 0 && n > b.max {
+		return 0, &LimitError{b.limit, b.max}
+	}
+	if max := b.limits.MaxOutputBytes; max > 0 && n > max {
+		return 0, &LimitError{"MaxOutputBytes", max}
+	}
+	return b.buf.Write(p)
+}
+
+func (b *limitedBuffer) String() string { return b.buf.String() }
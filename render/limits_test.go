@@ -0,0 +1,79 @@
+package render
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/etecs-ru/liquid/v2/parser"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRender_limits(t *testing.T) {
+	cfg := NewConfig()
+	addContextTestTags(cfg)
+	cfg.TemplateLoader = MapLoader{"self.html": `{% test_render_file self.html %}`}
+	cfg.AddTag("sleep", func(string) (func(io.Writer, Context) error, error) {
+		return func(io.Writer, Context) error {
+			time.Sleep(10 * time.Millisecond)
+			return nil
+		}, nil
+	})
+	tests := []struct {
+		in     string
+		limits Limits
+		limit  string
+	}{
+		{`{{ "abcdef" }}`, Limits{MaxOutputBytes: 5}, "MaxOutputBytes"},
+		{`abc{{ "def" }}`, Limits{MaxOutputBytes: 5}, "MaxOutputBytes"},
+		{`{% test_render_file self.html %}`, Limits{MaxIncludeDepth: 3}, "MaxIncludeDepth"},
+		{`{% sleep %}{% sleep %}`, Limits{Timeout: time.Millisecond}, "Timeout"},
+	}
+	for _, test := range tests {
+		root, err := cfg.Compile(test.in, parser.SourceLoc{Pathname: "page.html", LineNo: 1})
+		require.NoError(t, err)
+		c := cfg
+		c.Limits = test.limits
+		err = Render(root, ioutil.Discard, contextTestBindings, c)
+		require.Errorf(t, err, test.in)
+		require.IsTypef(t, &LimitError{}, err.Cause(), test.in)
+		require.Equalf(t, test.limit, err.Cause().(*LimitError).Limit, test.in)
+		require.NotEmptyf(t, err.Path(), test.in)
+	}
+
+	// under the limits
+	root, err := cfg.Compile(`{{ "abcde" }}`, parser.SourceLoc{})
+	require.NoError(t, err)
+	c := cfg
+	c.Limits = Limits{MaxOutputBytes: 5, MaxIncludeDepth: 3, Timeout: time.Second}
+	require.NoError(t, Render(root, ioutil.Discard, contextTestBindings, c))
+}
+
+func TestRender_limitsNestedIncludes(t *testing.T) {
+	cfg := NewConfig()
+	addContextTestTags(cfg)
+	text := strings.Repeat("x", 100)
+	cfg.TemplateLoader = MapLoader{
+		"a.html": text + `{% test_render_file b.html %}`,
+		"b.html": text + `{% test_render_file c.html %}`,
+		"c.html": text,
+	}
+	root, err := cfg.Compile(`{% test_render_file a.html %}`, parser.SourceLoc{Pathname: "page.html", LineNo: 1})
+	require.NoError(t, err)
+
+	// the included output counts once, when it's written
+	c := cfg
+	c.Limits = Limits{MaxOutputBytes: 300}
+	buf := new(bytes.Buffer)
+	require.NoError(t, Render(root, buf, contextTestBindings, c))
+	require.Equal(t, strings.Repeat(text, 3), buf.String())
+
+	c.Limits = Limits{MaxOutputBytes: 299}
+	err = Render(root, ioutil.Discard, contextTestBindings, c)
+	require.Error(t, err)
+	require.IsType(t, &LimitError{}, err.Cause())
+	require.Equal(t, "MaxOutputBytes", err.Cause().(*LimitError).Limit)
+}
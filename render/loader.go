@@ -0,0 +1,121 @@
+package render
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/etecs-ru/liquid/v2/parser"
+)
+
+// A TemplateLoader reads the source of the templates that are named by the {% include %} tag and
+// by Context.RenderFile.
+//
+// ReadTemplate should return an error that satisfies os.IsNotExist if there is no template with
+// that name. The renderer then goes on to the next directory in the search path.
+type TemplateLoader interface {
+	ReadTemplate(name string) ([]byte, error)
+}
+
+// DirLoader is a TemplateLoader that reads templates from the file system.
+// Relative names are resolved against the named directory. The empty DirLoader
+// resolves them against the current working directory.
+type DirLoader string
+
+// ReadTemplate is part of the TemplateLoader interface.
+func (d DirLoader) ReadTemplate(name string) ([]byte, error) {
+	if !filepath.IsAbs(name) {
+		name = filepath.Join(string(d), name)
+	}
+	return ioutil.ReadFile(name)
+}
+
+// MapLoader is a TemplateLoader that reads templates from a map of names to template sources.
+// Names are slash-separated and relative, e.g. "includes/card.html".
+type MapLoader map[string]string
+
+// ReadTemplate is part of the TemplateLoader interface.
+func (m MapLoader) ReadTemplate(name string) ([]byte, error) {
+	if s, ok := m[filepath.ToSlash(filepath.Clean(name))]; ok {
+		return []byte(s), nil
+	}
+	return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+}
+
+type httpLoader struct{ fs http.FileSystem }
+
+// NewHTTPLoader returns a TemplateLoader that reads templates from an http.FileSystem,
+// such as http.Dir or the result of http.FS.
+func NewHTTPLoader(fs http.FileSystem) TemplateLoader {
+	return httpLoader{fs}
+}
+
+func (l httpLoader) ReadTemplate(name string) ([]byte, error) {
+	f, err := l.fs.Open(path.Join("/", filepath.ToSlash(name)))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close() // nolint: errcheck
+	return ioutil.ReadAll(f)
+}
+
+// templateCandidates returns the paths, in search order, that name might refer to
+// from a template at the path from.
+func (c Config) templateCandidates(name, from string) []string {
+	if filepath.IsAbs(name) {
+		return []string{name}
+	}
+	candidates := []string{filepath.Join(filepath.Dir(from), name)}
+	for _, dir := range c.SearchPaths {
+		candidates = append(candidates, filepath.Join(dir, name))
+	}
+	return candidates
+}
+
+// FindTemplate returns the path of the template that name refers to, from a template at the path
+// from. This is the template that {% include name %} would render. If there is no such template,
+// the error satisfies os.IsNotExist.
+func (c Config) FindTemplate(name, from string) (string, error) {
+	filename, _, _, err := c.resolveTemplate(name, from)
+	return filename, err
+}
+
+// loadTemplate returns the path and compiled template that name refers to, from a template at the path from.
+func (c Config) loadTemplate(name, from string) (string, Node, error) {
+	filename, root, source, err := c.resolveTemplate(name, from)
+	if err != nil || root != nil {
+		return filename, root, err
+	}
+	root, perr := c.Compile(string(source), parser.SourceLoc{Pathname: filename, LineNo: 1})
+	if perr != nil {
+		return "", nil, perr
+	}
+	c.TemplateCache.Put(filename, root)
+	return filename, root, nil
+}
+
+// resolveTemplate returns the path that name refers to, from a template at the path from.
+// It consults the template cache before it reads each candidate path. If the template is
+// cached, it returns its compiled root; otherwise it returns its source.
+func (c Config) resolveTemplate(name, from string) (filename string, root Node, source []byte, err error) {
+	var firstErr error
+	for _, filename := range c.templateCandidates(name, from) {
+		if root, ok := c.TemplateCache.Get(filename); ok {
+			return filename, root, nil, nil
+		}
+		source, err := c.TemplateLoader.ReadTemplate(filename)
+		if os.IsNotExist(err) {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		if err != nil {
+			return "", nil, nil, err
+		}
+		return filename, nil, source, nil
+	}
+	return "", nil, nil, firstErr
+}
@@ -0,0 +1,104 @@
+package render
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/etecs-ru/liquid/v2/parser"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplateLoaders(t *testing.T) {
+	loaders := map[string]TemplateLoader{
+		"dir":  DirLoader("testdata"),
+		"map":  MapLoader{"render_file.txt": "rendered shadowed={{ shadowed }}"},
+		"http": NewHTTPLoader(http.Dir("testdata")),
+	}
+	for name, loader := range loaders {
+		loader := loader
+		t.Run(name, func(t *testing.T) {
+			source, err := loader.ReadTemplate("render_file.txt")
+			require.NoError(t, err)
+			require.Equal(t, "rendered shadowed={{ shadowed }}", string(source))
+
+			_, err = loader.ReadTemplate("missing_file")
+			require.Error(t, err)
+			require.True(t, os.IsNotExist(err))
+		})
+	}
+}
+
+func TestRenderFile_loader(t *testing.T) {
+	cfg := NewConfig()
+	addContextTestTags(cfg)
+	cfg.TemplateLoader = MapLoader{
+		"pages/page.html":      `{% test_render_file card.html %}`,
+		"pages/card.html":      `page card`,
+		"includes/card.html":   `shared card`,
+		"includes/other.html":  `shared other`,
+		"includes/nested.html": `{% test_render_file other.html %}`,
+	}
+	cfg.SearchPaths = []string{"includes"}
+	render := func(source, path string) (string, error) {
+		root, err := cfg.Compile(source, parser.SourceLoc{Pathname: path, LineNo: 1})
+		require.NoError(t, err)
+		buf := new(bytes.Buffer)
+		err = Render(root, buf, contextTestBindings, cfg)
+		return buf.String(), err
+	}
+
+	// relative to the including template
+	out, err := render(`{% test_render_file card.html %}`, "pages/page.html")
+	require.NoError(t, err)
+	require.Equal(t, "page card", out)
+
+	// from the search path
+	out, err = render(`{% test_render_file card.html %}`, "index.html")
+	require.NoError(t, err)
+	require.Equal(t, "shared card", out)
+
+	// relative to an included template
+	out, err = render(`{% test_render_file nested.html %}`, "index.html")
+	require.NoError(t, err)
+	require.Equal(t, "shared other", out)
+
+	_, err = render(`{% test_render_file missing.html %}`, "index.html")
+	require.Error(t, err)
+	require.True(t, os.IsNotExist(err.(Error).Cause()))
+	require.Equal(t, "index.html", err.(Error).Path())
+}
+
+func TestRenderFile_loader_error(t *testing.T) {
+	cfg := NewConfig()
+	addContextTestTags(cfg)
+	cfg.TemplateLoader = MapLoader{"error.html": "{{ syntax error }}"}
+	root, err := cfg.Compile(`{% test_render_file error.html %}`, parser.SourceLoc{Pathname: "index.html", LineNo: 1})
+	require.NoError(t, err)
+	err = Render(root, ioutil.Discard, contextTestBindings, cfg)
+	require.Error(t, err)
+	require.Equal(t, "error.html", err.(Error).Path())
+}
+
+func TestConfig_FindTemplate(t *testing.T) {
+	cfg := NewConfig()
+	cfg.TemplateLoader = MapLoader{
+		"pages/card.html":    `page card`,
+		"includes/card.html": `shared card`,
+		"includes/nav.html":  `nav`,
+	}
+	cfg.SearchPaths = []string{"includes"}
+
+	path, err := cfg.FindTemplate("card.html", "pages/index.html")
+	require.NoError(t, err)
+	require.Equal(t, "pages/card.html", path)
+
+	path, err = cfg.FindTemplate("nav.html", "pages/index.html")
+	require.NoError(t, err)
+	require.Equal(t, "includes/nav.html", path)
+
+	_, err = cfg.FindTemplate("missing.html", "pages/index.html")
+	require.True(t, os.IsNotExist(err))
+}
@@ -1,7 +1,12 @@
 package render
 
 import (
+	"context"
+	"html"
+	"io"
+
 	"github.com/etecs-ru/liquid/v2/expressions"
+	"github.com/etecs-ru/liquid/v2/parser"
 )
 
 // nodeContext provides the evaluation context for rendering the AST.
@@ -9,14 +14,21 @@ import (
 // This type has a clumsy name so that render.Context, in the public API, can
 // have a clean name that doesn't stutter.
 type nodeContext struct {
+	ctx               context.Context
 	bindings          map[string]interface{}
+	globals           map[string]interface{} // the variables that the render started with
 	state             map[string]interface{}
 	config            Config
+	limits            *limiter
+	frame             *templateFrame
+	includeDepth      int
+	warnings          *[]Error // if non-nil, expression problems are recorded here instead of failing
+	errors            *[]Error // if non-nil, node errors are recorded here instead of failing
 	findVariablesOnly bool
 }
 
 // newNodeContext creates a new evaluation context.
-func newNodeContext(scope map[string]interface{}, state map[string]interface{}, c Config) nodeContext {
+func newNodeContext(ctx context.Context, scope map[string]interface{}, state map[string]interface{}, c Config) nodeContext {
 	// The assign tag modifies the scope, so make a copy first.
 	// TODO this isn't really the right place for this.
 	vars := map[string]interface{}{}
@@ -24,24 +36,83 @@ func newNodeContext(scope map[string]interface{}, state map[string]interface{},
 		vars[k] = v
 	}
 	return nodeContext{
+		ctx:      ctx,
 		bindings: vars,
+		globals:  scope,
 		config:   c,
 		state:    state,
+		limits:   newLimiter(c.Limits),
 	}
 }
 
 func newFindVariablesNodeContext(c Config) nodeContext {
 	return nodeContext{
+		ctx:               context.Background(),
 		bindings:          make(map[string]interface{}),
 		config:            c,
+		limits:            newLimiter(Limits{}),
+		frame:             &templateFrame{},
 		findVariablesOnly: true,
 	}
 }
 
-// Evaluate evaluates an expression within the template context.
-func (c nodeContext) Evaluate(expr expressions.Expression) (out interface{}, err error) {
+// withBindings returns a copy of the context, that renders with a different variable binding.
+func (c nodeContext) withBindings(bindings map[string]interface{}) nodeContext {
+	c.bindings = bindings
+	return c
+}
+
+// Evaluate evaluates an expression within the template context. Warnings are located at the node n.
+func (c nodeContext) Evaluate(expr expressions.Expression, n parser.Locatable) (out interface{}, err error) {
 	if c.findVariablesOnly {
 		return expr.Evaluate(expressions.NewVariablesContext(c.bindings, c.config.Config.Config))
 	}
-	return expr.Evaluate(expressions.NewContext(c.bindings, c.config.Config.Config))
+	return expr.Evaluate(c.expressionContext(n))
+}
+
+// expressionContext returns the context for evaluating an expression at the node n.
+// If the render collects warnings, the context records its undefined variables, undefined filters
+// and filter errors as warnings that are located at n.
+func (c nodeContext) expressionContext(n parser.Locatable) expressions.Context {
+	return expressions.NewContext(c.bindings, c.expressionConfig(n))
+}
+
+// expressionConfig returns the configuration of expressionContext.
+func (c nodeContext) expressionConfig(n parser.Locatable) expressions.Config {
+	cfg := c.config.Config.Config
+	if c.warnings != nil {
+		warnings := c.warnings
+		mode := expressions.WarnMode{Warn: func(err error) {
+			*warnings = append(*warnings, wrapRenderError(err, n))
+		}}
+		cfg.VariableErrorMode, cfg.FilterErrorMode = mode, mode
+	}
+	return cfg
+}
+
+// checkCanceled returns an error, located at the node n, if the render's context has been
+// canceled or its deadline has passed, or if the render has exceeded its Timeout.
+func (c nodeContext) checkCanceled(n parser.Locatable) Error {
+	if err := c.ctx.Err(); err != nil {
+		if err == context.DeadlineExceeded && c.limits.timedOut() {
+			err = &LimitError{"Timeout", c.limits.Timeout}
+		}
+		return wrapRenderError(err, n)
+	}
+	return nil
+}
+
+// recoverError records err and writes the ErrorPlaceholder in place of the node that failed,
+// if the render continues past errors and err is recoverable. Otherwise it returns err.
+func (c nodeContext) recoverError(w io.Writer, err Error) Error {
+	if c.errors == nil || !isRecoverable(err) {
+		return err
+	}
+	*c.errors = append(*c.errors, err)
+	s := c.config.ErrorPlaceholder(err)
+	if c.config.AutoEscape {
+		s = html.EscapeString(s)
+	}
+	_, werr := io.WriteString(w, s)
+	return wrapRenderError(werr, parser.Token{})
 }
@@ -20,12 +20,18 @@ type BlockNode struct {
 	renderer func(io.Writer, Context) error
 	Body     []Node
 	Clauses  []*BlockNode
+	parses   *expressions.ParseTable // the configuration's ParseTable, while the block is compiled
 }
 
+// ParseTable returns the table that the block's compiler and folder parse the arguments of the
+// block and its clauses with; see Config.ParseTable. It is nil, whose methods parse each source,
+// unless the configuration that compiles the block has a table.
+func (n BlockNode) ParseTable() *expressions.ParseTable { return n.parses }
+
 // RawNode holds the text between the start and end of a raw tag.
 type RawNode struct {
+	parser.Token
 	slices []string
-	sourcelessNode
 }
 
 // TagNode renders itself via a render function that is created during parsing.
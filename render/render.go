@@ -2,12 +2,15 @@
 package render
 
 import (
+	"context"
 	"fmt"
+	"html"
 	"io"
 	"io/ioutil"
 	"reflect"
 	"time"
 
+	"github.com/etecs-ru/liquid/v2/parser"
 	"github.com/etecs-ru/liquid/v2/values"
 )
 
@@ -18,14 +21,66 @@ func Render(node Node, w io.Writer, vars map[string]interface{}, c Config) Error
 
 // RenderWithState renders the render tree with state attached.
 func RenderWithState(node Node, w io.Writer, vars, state map[string]interface{}, c Config) Error {
-	tw := trimWriter{w: w}
-	if err := node.render(&tw, newNodeContext(vars, state, c)); err != nil {
-		return err
+	return RenderContext(context.Background(), node, w, vars, state, c)
+}
+
+// RenderContext renders the render tree with state attached. It checks ctx between nodes, and
+// between loop iterations, and stops with an error that wraps ctx.Err() once ctx is done.
+//
+// The render is also bounded by c.Limits.
+//
+// If the template extends a layout, the layout is rendered in its place.
+func RenderContext(ctx context.Context, node Node, w io.Writer, vars, state map[string]interface{}, c Config) Error {
+	return renderContext(ctx, node, w, vars, state, c, nil)
+}
+
+// RenderWarnings is the same as RenderContext, except that undefined variables, undefined filters,
+// and errors that filters return, don't stop the render. Instead, each is returned as a warning,
+// located at the node where it happened. An undefined variable renders as nil, an undefined filter
+// is the identity function, and a filter that fails returns nil.
+//
+// The warnings are returned even if the render fails.
+func RenderWarnings(ctx context.Context, node Node, w io.Writer, vars, state map[string]interface{}, c Config) ([]Error, Error) {
+	warnings := []Error{}
+	err := renderContext(ctx, node, w, vars, state, c, &warnings)
+	return warnings, err
+}
+
+func renderContext(ctx context.Context, node Node, w io.Writer, vars, state map[string]interface{}, c Config, warnings *[]Error) Error {
+	if c.Limits.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Limits.Timeout)
+		defer cancel()
 	}
-	if err := tw.Flush(); err != nil {
-		panic(err)
+	nc := newNodeContext(ctx, vars, state, c)
+	nc.warnings = warnings
+	if c.ErrorPlaceholder != nil {
+		nc.errors = &[]Error{}
 	}
-	return nil
+	err := nc.renderTemplate(w, "", node, nc.limits)
+	if nc.errors != nil && len(*nc.errors) > 0 {
+		if err != nil {
+			*nc.errors = append(*nc.errors, err)
+		}
+		errs := make(parser.ErrorList, len(*nc.errors))
+		for i, e := range *nc.errors {
+			errs[i] = e
+		}
+		return errs
+	}
+	return err
+}
+
+// render renders a node, that is the root of a template or of a fragment of a template.
+func (c nodeContext) render(w io.Writer, node Node) Error {
+	return c.renderTo(&trimWriter{w: w}, node)
+}
+
+func (c nodeContext) renderTo(tw *trimWriter, node Node) Error {
+	if err := node.render(tw, c); err != nil {
+		return err
+	}
+	return wrapRenderError(tw.Flush(), parser.Token{})
 }
 
 func FindVariables(node Node, c Config) (map[string]interface{}, Error) {
@@ -41,17 +96,22 @@ func FindVariables(node Node, c Config) (map[string]interface{}, Error) {
 func (c nodeContext) RenderSequence(w io.Writer, seq []Node) Error {
 	tw := trimWriter{w: w}
 	for _, n := range seq {
-		if err := n.render(&tw, c); err != nil {
+		if err := c.checkCanceled(n); err != nil {
 			return err
 		}
+		if err := n.render(&tw, c); err != nil {
+			if err := c.recoverError(&tw, err); err != nil {
+				return err
+			}
+		}
 	}
-	if err := tw.Flush(); err != nil {
-		panic(err)
-	}
-	return nil
+	return wrapRenderError(tw.Flush(), parser.Token{})
 }
 
 func (n *BlockNode) render(w *trimWriter, ctx nodeContext) Error {
+	if err := ctx.checkCanceled(n); err != nil {
+		return err
+	}
 	cd, ok := ctx.config.findBlockDef(n.Name)
 	if !ok || cd.parser == nil {
 		// this should have been detected during compilation; it's an implementation error if it happens here
@@ -76,12 +136,18 @@ func (n *RawNode) render(w *trimWriter, ctx nodeContext) Error {
 }
 
 func (n *ObjectNode) render(w *trimWriter, ctx nodeContext) Error {
-	w.TrimLeft(n.TrimLeft)
-	value, err := ctx.Evaluate(n.expr)
+	if err := w.TrimLeft(n.TrimLeft); err != nil {
+		return wrapRenderError(err, n)
+	}
+	value, err := ctx.Evaluate(n.expr, n)
 	if err != nil {
 		return wrapRenderError(err, n)
 	}
-	if err := wrapRenderError(writeObject(w, value), n); err != nil {
+	var out io.Writer = w
+	if ctx.config.AutoEscape {
+		out = htmlEscapeWriter{w}
+	}
+	if err := wrapRenderError(writeObject(out, value), n); err != nil {
 		return err
 	}
 	w.TrimRight(n.TrimRight)
@@ -90,15 +156,22 @@ func (n *ObjectNode) render(w *trimWriter, ctx nodeContext) Error {
 
 func (n *SeqNode) render(w *trimWriter, ctx nodeContext) Error {
 	for _, c := range n.Children {
-		if err := c.render(w, ctx); err != nil {
+		if err := ctx.checkCanceled(c); err != nil {
 			return err
 		}
+		if err := c.render(w, ctx); err != nil {
+			if err := ctx.recoverError(w, err); err != nil {
+				return err
+			}
+		}
 	}
 	return nil
 }
 
 func (n *TagNode) render(w *trimWriter, ctx nodeContext) Error {
-	w.TrimLeft(n.TrimLeft)
+	if err := w.TrimLeft(n.TrimLeft); err != nil {
+		return wrapRenderError(err, n)
+	}
 	err := wrapRenderError(n.renderer(w, rendererContext{ctx, n, nil}), n)
 	w.TrimRight(n.TrimRight)
 	return err
@@ -116,6 +189,15 @@ func writeObject(w io.Writer, value interface{}) error {
 		return nil
 	}
 	switch value := value.(type) {
+	case string:
+		_, err := io.WriteString(w, value)
+		return err
+	case values.SafeString:
+		if ew, ok := w.(htmlEscapeWriter); ok {
+			w = ew.w
+		}
+		_, err := io.WriteString(w, string(value))
+		return err
 	case time.Time:
 		_, err := io.WriteString(w, value.Format("2006-01-02 15:04:05 -0700"))
 		return err
@@ -144,3 +226,13 @@ func writeObject(w io.Writer, value interface{}) error {
 		return err
 	}
 }
+
+// An htmlEscapeWriter HTML-escapes the text that is written to it.
+type htmlEscapeWriter struct{ w io.Writer }
+
+func (ew htmlEscapeWriter) Write(b []byte) (int, error) {
+	if _, err := io.WriteString(ew.w, html.EscapeString(string(b))); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
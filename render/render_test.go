@@ -2,6 +2,7 @@ package render
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -9,6 +10,7 @@ import (
 	"time"
 
 	"github.com/etecs-ru/liquid/v2/parser"
+	"github.com/etecs-ru/liquid/v2/values"
 	"github.com/stretchr/testify/require"
 )
 
@@ -117,6 +119,68 @@ func TestRenderErrors(t *testing.T) {
 	}
 }
 
+func TestRender_autoEscape(t *testing.T) {
+	cfg := NewConfig()
+	cfg.AutoEscape = true
+	addRenderTestTags(cfg)
+	bindings := map[string]interface{}{
+		"html":  "<b>Tom & Jerry</b>",
+		"safe":  values.SafeString("<b>safe</b>"),
+		"array": []interface{}{"<i>", values.SafeString("<i>")},
+		"int":   1,
+	}
+	tests := []struct{ in, out string }{
+		{`<p>{{ html }}</p>`, "<p>&lt;b&gt;Tom &amp; Jerry&lt;/b&gt;</p>"},
+		{`{{ safe }}`, "<b>safe</b>"},
+		{`{{ array }}`, "&lt;i&gt;<i>"},
+		{`{{ int }}{% y %}`, "1y"},
+		{` {{- html -}} `, "&lt;b&gt;Tom &amp; Jerry&lt;/b&gt;"},
+	}
+	for _, test := range tests {
+		root, err := cfg.Compile(test.in, parser.SourceLoc{})
+		require.NoError(t, err)
+		buf := new(bytes.Buffer)
+		require.NoError(t, Render(root, buf, bindings, cfg))
+		require.Equal(t, test.out, buf.String(), test.in)
+	}
+}
+
+func TestRender_errorPlaceholder(t *testing.T) {
+	cfg := NewConfig()
+	addRenderTestTags(cfg)
+	cfg.AddTag("stop", func(string) (func(io.Writer, Context) error, error) {
+		return func(io.Writer, Context) error { return &LimitError{"MaxTest", 1} }, nil
+	})
+	cfg.ErrorPlaceholder = func(err Error) string { return fmt.Sprintf("[error line %d]", err.LineNumber()) }
+
+	root, err := cfg.Compile("a{% errblock %}{% enderrblock %}b\n{{ 1 | undefined_filter }}c", parser.SourceLoc{LineNo: 1})
+	require.NoError(t, err)
+	buf := new(bytes.Buffer)
+	err = Render(root, buf, renderTestBindings, cfg)
+	require.Error(t, err)
+	require.Equal(t, "a[error line 1]b\n[error line 2]c", buf.String())
+	errs, ok := err.(parser.ErrorList)
+	require.True(t, ok)
+	require.Len(t, errs, 2)
+	require.Contains(t, errs[0].Error(), "errblock error")
+	require.Contains(t, errs[1].Error(), "undefined_filter")
+
+	// an unrecoverable error stops the render, and is added to the list
+	root, err = cfg.Compile("{% errblock %}{% enderrblock %}{% stop %}b", parser.SourceLoc{})
+	require.NoError(t, err)
+	buf.Reset()
+	err = Render(root, buf, renderTestBindings, cfg)
+	require.Equal(t, "[error line 0]", buf.String())
+	errs = err.(parser.ErrorList)
+	require.Len(t, errs, 2)
+	require.IsType(t, &LimitError{}, errs[1].Cause())
+
+	// no errors
+	root, err = cfg.Compile("ok", parser.SourceLoc{})
+	require.NoError(t, err)
+	require.NoError(t, Render(root, ioutil.Discard, renderTestBindings, cfg))
+}
+
 func addRenderTestTags(cfg Config) {
 	cfg.AddTag("y", func(string) (func(io.Writer, Context) error, error) {
 		return func(w io.Writer, _ Context) error {
@@ -133,3 +197,29 @@ func addRenderTestTags(cfg Config) {
 		}, nil
 	})
 }
+
+func TestRenderContext(t *testing.T) {
+	cfg := NewConfig()
+	addRenderTestTags(cfg)
+	root, err := cfg.Compile("line 1\n{{ int }}{% y %}", parser.SourceLoc{Pathname: "page.html", LineNo: 1})
+	require.NoError(t, err)
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, RenderContext(context.Background(), root, buf, renderTestBindings, map[string]interface{}{}, cfg))
+	require.Equal(t, "line 1\n123y", buf.String())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = RenderContext(ctx, root, ioutil.Discard, renderTestBindings, map[string]interface{}{}, cfg)
+	require.Error(t, err)
+	require.Equal(t, context.Canceled, err.Cause())
+	require.Equal(t, "page.html", err.Path())
+	require.Equal(t, 1, err.LineNumber())
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	<-ctx.Done()
+	err = RenderContext(ctx, root, ioutil.Discard, renderTestBindings, map[string]interface{}{}, cfg)
+	require.Error(t, err)
+	require.Equal(t, context.DeadlineExceeded, err.Cause())
+}
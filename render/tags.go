@@ -2,15 +2,29 @@ package render
 
 import (
 	"io"
+
+	"github.com/etecs-ru/liquid/v2/expressions"
 )
 
 // TagCompiler is a function that parses the tag arguments, and returns a renderer.
 // TODO instead of using the bare function definition, use a structure that defines how to parse
 type TagCompiler func(expr string) (func(io.Writer, Context) error, error)
 
+// A ParsingTagCompiler is a TagCompiler that parses the tag's expressions with the table that
+// the configuration compiles with; see Config.ParseTable.
+type ParsingTagCompiler func(expr string, table *expressions.ParseTable) (func(io.Writer, Context) error, error)
+
 // AddTag creates a tag definition.
 func (c *Config) AddTag(name string, td TagCompiler) {
 	c.tags[name] = td
+	delete(c.parsingTags, name)
+}
+
+// AddParsingTag creates a tag definition, whose compiler parses with the configuration's
+// ParseTable.
+func (c *Config) AddParsingTag(name string, td ParsingTagCompiler) {
+	c.tags[name] = func(expr string) (func(io.Writer, Context) error, error) { return td(expr, nil) }
+	c.parsingTags[name] = td
 }
 
 // FindTagDefinition looks up a tag definition.
@@ -3,16 +3,26 @@ package render
 import (
 	"bytes"
 	"io"
+	"strings"
 	"unicode"
 )
 
 // A trimWriter provides whitespace control around a wrapped io.Writer.
 // The caller should call TrimLeft(bool) and TrimRight(bool) respectively
 // before and after processing a tag or expression, and Flush() at completion.
+//
+// A trimWriter streams its output. It writes text through to the wrapped writer as soon
+// as it receives it, except for a trailing run of whitespace, which a following {%- or {{-
+// might remove. It holds that whitespace back until the next call to Write, TrimLeft or Flush
+// determines whether it is written or discarded.
+//
+// The trimWriter at the root of a render also counts the bytes that it writes, and
+// returns an error once they exceed the render's MaxOutputBytes.
 type trimWriter struct {
 	w         io.Writer
 	buf       bytes.Buffer
 	trimRight bool
+	limits    *limiter // nil except at the root of a render
 }
 
 // This violates the letter of the protocol by returning the count of the
@@ -36,25 +46,57 @@ func (tw *trimWriter) Write(b []byte) (int, error) {
 			return 0, err
 		}
 	}
+	if err := tw.limits.addOutput(len(nonWS)); err != nil {
+		return 0, err
+	}
 	_, err := tw.w.Write(nonWS)
 	return n, err
 }
+
+// WriteString is Write, for a string. It doesn't copy the string into a byte slice, so text
+// nodes are written without allocating.
+func (tw *trimWriter) WriteString(s string) (int, error) {
+	n := len(s)
+	if tw.trimRight {
+		s = strings.TrimLeftFunc(s, unicode.IsSpace)
+	} else if tw.buf.Len() > 0 {
+		if err := tw.Flush(); err != nil {
+			return 0, err
+		}
+	}
+	nonWS := strings.TrimRightFunc(s, unicode.IsSpace)
+	if len(nonWS) < len(s) {
+		if _, err := tw.buf.WriteString(s[len(nonWS):]); err != nil {
+			return 0, err
+		}
+	}
+	if err := tw.limits.addOutput(len(nonWS)); err != nil {
+		return 0, err
+	}
+	_, err := io.WriteString(tw.w, nonWS)
+	return n, err
+}
+
 func (tw *trimWriter) Flush() (err error) {
 	if tw.buf.Len() > 0 {
+		if err := tw.limits.addOutput(tw.buf.Len()); err != nil {
+			return err
+		}
 		_, err = tw.buf.WriteTo(tw.w)
 		tw.buf.Reset()
 	}
 	return
 }
 
-func (tw *trimWriter) TrimLeft(f bool) {
+// TrimLeft discards the held-back whitespace if f is true, and writes it otherwise.
+func (tw *trimWriter) TrimLeft(f bool) error {
+	var err error
 	if !f && tw.buf.Len() > 0 {
-		if err := tw.Flush(); err != nil {
-			panic(err)
-		}
+		err = tw.Flush()
 	}
 	tw.buf.Reset()
 	tw.trimRight = false
+	return err
 }
 
 func (tw *trimWriter) TrimRight(f bool) {
@@ -0,0 +1,88 @@
+package render
+
+import "github.com/etecs-ru/liquid/v2/parser"
+
+// A Visitor's Visit method is invoked for each node that Walk encounters. If the result visitor
+// w is not nil, Walk visits each of the children of node with w, followed by a call of
+// w.Visit(nil).
+type Visitor interface {
+	Visit(node Node) (w Visitor)
+}
+
+// Walk traverses a render tree in depth-first order. It starts by calling v.Visit(node). The
+// children of a SeqNode, and the body and then the clauses of a BlockNode, are visited in order.
+// The child of a GoNode is the render tree of its template, and the child of a FoldedNode is the
+// node that was folded.
+func Walk(v Visitor, node Node) {
+	if v = v.Visit(node); v == nil {
+		return
+	}
+	switch n := node.(type) {
+	case *SeqNode:
+		for _, c := range n.Children {
+			Walk(v, c)
+		}
+	case *BlockNode:
+		for _, c := range n.Body {
+			Walk(v, c)
+		}
+		for _, clause := range n.Clauses {
+			Walk(v, clause)
+		}
+	case *GoNode:
+		if tree, err := n.renderTree(); err == nil {
+			Walk(v, tree)
+		}
+	case *FoldedNode:
+		Walk(v, n.Node)
+	}
+	v.Visit(nil)
+}
+
+type inspector func(Node) bool
+
+func (f inspector) Visit(node Node) Visitor {
+	if f(node) {
+		return f
+	}
+	return nil
+}
+
+// Inspect traverses a render tree in depth-first order. It starts by calling f(node). If f
+// returns true, Inspect invokes f for each of the children of node, followed by a call of f(nil).
+//
+// For example, this finds the names of the templates that a template includes:
+//
+//	render.Inspect(root, func(n render.Node) bool {
+//		if tag, ok := n.(*render.TagNode); ok && tag.Name == "include" {
+//			names = append(names, tag.Args)
+//		}
+//		return true
+//	})
+func Inspect(node Node, f func(Node) bool) {
+	Walk(inspector(f), node)
+}
+
+// A Rewriter transforms the AST of a template, after the template is parsed and before it is
+// compiled. It can modify the AST in place and return its root, or return a new root.
+// See parser.Inspect, for finding the nodes to rewrite.
+type Rewriter func(root parser.ASTNode) (parser.ASTNode, error)
+
+// AddRewriter adds a rewriter, that is applied to each template that is compiled with the
+// configuration, including the templates that the {% include %} tag reads. Rewriters are applied
+// in the order that they are added.
+func (c *Config) AddRewriter(fn Rewriter) {
+	c.rewriters = append(c.rewriters, fn)
+}
+
+// Rewrite applies the rewriters to an AST, as Compile does. An error is reported at loc, unless
+// it has its own location.
+func (c Config) Rewrite(root parser.ASTNode, loc parser.SourceLoc) (parser.ASTNode, parser.Error) {
+	for _, fn := range c.rewriters {
+		var err error
+		if root, err = fn(root); err != nil {
+			return nil, parser.WrapError(err, parser.Token{SourceLoc: loc})
+		}
+	}
+	return root, nil
+}
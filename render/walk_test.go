@@ -0,0 +1,91 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/etecs-ru/liquid/v2/parser"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInspect(t *testing.T) {
+	cfg := NewConfig()
+	addAnalyzerTestTags(cfg)
+	root, err := cfg.Compile(`a{{ b }}{% each list %}{% set x = 1 %}{% empty %}{{ c }}{% endeach %}`, parser.SourceLoc{})
+	require.NoError(t, err)
+
+	var visited []string
+	Inspect(root, func(n Node) bool {
+		switch n := n.(type) {
+		case nil:
+			visited = append(visited, "end")
+		case *SeqNode:
+			visited = append(visited, "seq")
+		default:
+			visited = append(visited, fmt.Sprintf("%T:%s", n, n.SourceText()))
+		}
+		return true
+	})
+	require.Equal(t, []string{
+		"seq",
+		"*render.TextNode:a", "end",
+		"*render.ObjectNode:{{ b }}", "end",
+		"*render.BlockNode:{% each list %}",
+		"*render.TagNode:{% set x = 1 %}", "end",
+		"*render.BlockNode:{% empty %}",
+		"*render.ObjectNode:{{ c }}", "end",
+		"end",
+		"end",
+		"end",
+	}, visited)
+
+	// returning false skips a node's children
+	visited = nil
+	Inspect(root, func(n Node) bool {
+		if n != nil {
+			visited = append(visited, fmt.Sprintf("%T", n))
+		}
+		_, ok := n.(*BlockNode)
+		return !ok
+	})
+	require.Equal(t, []string{"*render.SeqNode", "*render.TextNode", "*render.ObjectNode", "*render.BlockNode"}, visited)
+}
+
+func TestConfig_AddRewriter(t *testing.T) {
+	cfg := NewConfig()
+	upcase := func(root parser.ASTNode) (parser.ASTNode, error) {
+		parser.Inspect(root, func(n parser.ASTNode) bool {
+			if text, ok := n.(*parser.ASTText); ok {
+				text.Source = text.Source + "!"
+			}
+			return true
+		})
+		return root, nil
+	}
+	clone := cfg.Clone()
+	cfg.AddRewriter(upcase)
+	root, err := cfg.Compile("a{{ 1 }}b", parser.SourceLoc{})
+	require.NoError(t, err)
+	buf := new(bytes.Buffer)
+	require.NoError(t, Render(root, buf, map[string]interface{}{}, cfg))
+	require.Equal(t, "a!1b!", buf.String())
+
+	// the rewriter doesn't apply to a clone that was made before it was added
+	root, err = clone.Compile("a", parser.SourceLoc{})
+	require.NoError(t, err)
+	buf.Reset()
+	require.NoError(t, Render(root, buf, map[string]interface{}{}, clone))
+	require.Equal(t, "a", buf.String())
+
+	cfg.AddRewriter(func(parser.ASTNode) (parser.ASTNode, error) {
+		return nil, fmt.Errorf("rewrite failed")
+	})
+	_, err = cfg.Compile("a", parser.SourceLoc{Pathname: "t.html", LineNo: 1})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "rewrite failed")
+	require.Equal(t, "t.html", err.Path())
+	_, errs := cfg.CompileAll("a", parser.SourceLoc{Pathname: "t.html", LineNo: 1})
+	require.Len(t, errs, 1)
+	require.Contains(t, errs[0].Error(), "rewrite failed")
+}
@@ -4,6 +4,7 @@ import (
 	"io"
 
 	e "github.com/etecs-ru/liquid/v2/expressions"
+	"github.com/etecs-ru/liquid/v2/parser"
 	"github.com/etecs-ru/liquid/v2/render"
 	"github.com/etecs-ru/liquid/v2/values"
 )
@@ -40,7 +41,7 @@ func (c elseCase) test(interface{}, render.Context) (bool, error) { return true,
 
 func caseTagCompiler(node render.BlockNode) (func(io.Writer, render.Context) error, error) {
 	// TODO syntax error on non-empty node.Body
-	expr, err := e.Parse(node.Args)
+	expr, err := node.ParseTable().Parse(node.Args)
 	if err != nil {
 		return nil, err
 	}
@@ -48,7 +49,7 @@ func caseTagCompiler(node render.BlockNode) (func(io.Writer, render.Context) err
 	for _, clause := range node.Clauses {
 		switch clause.Token.Name {
 		case "when":
-			stmt, err := e.ParseStatement(e.WhenStatementSelector, clause.Args)
+			stmt, err := node.ParseTable().ParseStatement(e.WhenStatementSelector, clause.Args)
 			if err != nil {
 				return nil, err
 			}
@@ -75,13 +76,82 @@ func caseTagCompiler(node render.BlockNode) (func(io.Writer, render.Context) err
 	}, nil
 }
 
+// caseTagFolder selects the clause of a case tag whose selector and when values are constant.
+func caseTagFolder(node render.BlockNode, constant func(e.Expression) (interface{}, bool)) (*render.BlockNode, bool) {
+	expr, err := node.ParseTable().Parse(node.Args)
+	if err != nil {
+		return nil, false
+	}
+	sel, ok := constant(expr)
+	if !ok {
+		return nil, false
+	}
+	for _, clause := range node.Clauses {
+		if clause.Name != "when" {
+			return clause, true
+		}
+		stmt, err := node.ParseTable().ParseStatement(e.WhenStatementSelector, clause.Args)
+		if err != nil {
+			return nil, false
+		}
+		for _, expr := range stmt.When.Exprs {
+			whenValue, ok := constant(expr)
+			if !ok {
+				return nil, false
+			}
+			if values.Equal(sel, whenValue) {
+				return clause, true
+			}
+		}
+	}
+	return nil, true
+}
+
+func caseTagAnalyzer(node render.BlockNode) (a render.NodeAnalysis) {
+	switch node.Name {
+	case "case":
+		if expr, err := e.Parse(node.Args); err == nil {
+			a.Arguments = []e.Expression{expr}
+		}
+		a.Warnings = checkElseIsLast(node)
+	case "when":
+		if stmt, err := e.ParseStatement(e.WhenStatementSelector, node.Args); err == nil {
+			a.Arguments = stmt.When.Exprs
+		}
+	}
+	return
+}
+
+func ifTagAnalyzer(node render.BlockNode) (a render.NodeAnalysis) {
+	if node.Name != "else" {
+		if expr, err := e.Parse(node.Args); err == nil {
+			a.Arguments = []e.Expression{expr}
+		}
+	}
+	if node.Name == "if" || node.Name == "unless" {
+		a.Warnings = checkElseIsLast(node)
+	}
+	return
+}
+
+// checkElseIsLast returns a warning for each else clause that isn't the block's last clause.
+// The clauses after it are never rendered.
+func checkElseIsLast(node render.BlockNode) (warnings []error) {
+	for i, c := range node.Clauses {
+		if c.Name == "else" && i < len(node.Clauses)-1 {
+			warnings = append(warnings, parser.Errorf(c, "else isn't the last clause of %s; the clauses after it are never rendered", node.Name))
+		}
+	}
+	return
+}
+
 func ifTagCompiler(polarity bool) func(render.BlockNode) (func(io.Writer, render.Context) error, error) { // nolint: gocyclo
 	return func(node render.BlockNode) (func(io.Writer, render.Context) error, error) {
 		type branchRec struct {
 			test e.Expression
 			body *render.BlockNode
 		}
-		expr, err := e.Parse(node.Args)
+		expr, err := node.ParseTable().Parse(node.Args)
 		if err != nil {
 			return nil, err
 		}
@@ -97,7 +167,7 @@ func ifTagCompiler(polarity bool) func(render.BlockNode) (func(io.Writer, render
 			case "else":
 			// TODO syntax error if this isn't the last branch
 			case "elsif":
-				t, err := e.Parse(c.Args)
+				t, err := node.ParseTable().Parse(c.Args)
 				if err != nil {
 					return nil, err
 				}
@@ -119,3 +189,28 @@ func ifTagCompiler(polarity bool) func(render.BlockNode) (func(io.Writer, render
 		}, nil
 	}
 }
+
+// ifTagFolder selects the branch of an if or unless tag, if the tests up to the branch that is
+// taken are constant.
+func ifTagFolder(polarity bool) render.BlockFolder {
+	return func(node render.BlockNode, constant func(e.Expression) (interface{}, bool)) (*render.BlockNode, bool) {
+		branches := append([]*render.BlockNode{&node}, node.Clauses...)
+		for i, b := range branches {
+			if b.Name == "else" {
+				return b, true
+			}
+			test, err := node.ParseTable().Parse(b.Args)
+			if err != nil {
+				return nil, false
+			}
+			value, ok := constant(test)
+			if !ok {
+				return nil, false
+			}
+			if (value != nil && value != false) == (i > 0 || polarity) {
+				return b, true
+			}
+		}
+		return nil, true
+	}
+}
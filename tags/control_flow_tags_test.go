@@ -113,3 +113,31 @@ func TestControlFlowTags_errors(t *testing.T) {
 		})
 	}
 }
+
+func TestControlFlowTags_fold(t *testing.T) {
+	cfg := render.NewConfig()
+	AddStandardTags(cfg)
+	tests := []struct {
+		in     string
+		folded bool
+	}{
+		{`{% if true %}a{% endif %}`, true},
+		{`{% if false %}a{% endif %}`, true},
+		{`{% if false %}a{% elsif 1 == 1 %}b{% endif %}`, true},
+		{`{% if true %}a{% elsif x %}b{% endif %}`, true},
+		{`{% if false %}a{% elsif x %}b{% else %}c{% endif %}`, false},
+		{`{% if x %}a{% else %}c{% endif %}`, false},
+		{`{% unless false %}a{% endunless %}`, true},
+		{`{% unless y %}a{% endunless %}`, false},
+		{`{% case 2 %}{% when 1, 2 %}a{% when x %}b{% endcase %}`, true},
+		{`{% case 3 %}{% when 1 %}a{% else %}b{% endcase %}`, true},
+		{`{% case 3 %}{% when x %}a{% else %}b{% endcase %}`, false},
+		{`{% case x %}{% when 1 %}a{% endcase %}`, false},
+	}
+	for _, test := range tests {
+		root, err := cfg.Compile(test.in, parser.SourceLoc{})
+		require.NoError(t, err, test.in)
+		_, folded := root.(*render.SeqNode).Children[0].(*render.FoldedNode)
+		require.Equal(t, test.folded, folded, test.in)
+	}
+}
@@ -2,30 +2,20 @@ package tags
 
 import (
 	"io"
-	"path/filepath"
 
+	"github.com/etecs-ru/liquid/v2/expressions"
 	"github.com/etecs-ru/liquid/v2/render"
 )
 
-func includeTag(source string) (func(io.Writer, render.Context) error, error) {
+// includeTag implements the {% include %} tag. The included template sees the variables of the
+// including template, and the tag's parameters: Jekyll's key=value parameters as include.key,
+// and Shopify's key: value parameters as variables.
+func includeTag(source string, table *expressions.ParseTable) (func(io.Writer, render.Context) error, error) {
+	args, err := parsePartialArgs(source, table)
+	if err != nil {
+		return nil, err
+	}
 	return func(w io.Writer, ctx render.Context) error {
-		// It might be more efficient to add a context interface to render bytes
-		// to a writer. The status quo keeps the interface light at the expense of some overhead
-		// here.
-		value, err := ctx.EvaluateString(ctx.TagArgs())
-		if err != nil {
-			return err
-		}
-		rel, ok := value.(string)
-		if !ok {
-			return ctx.Errorf("include requires a string argument; got %v", value)
-		}
-		filename := filepath.Join(filepath.Dir(ctx.SourceFile()), rel)
-		s, err := ctx.RenderFile(filename, map[string]interface{}{})
-		if err != nil {
-			return err
-		}
-		_, err = io.WriteString(w, s)
-		return err
+		return args.render(w, ctx, ctx.RenderFile)
 	}, nil
 }
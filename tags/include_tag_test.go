@@ -58,3 +58,74 @@ func TestIncludeTag_file_not_found_error(t *testing.T) {
 	require.Error(t, err)
 	require.True(t, os.IsNotExist(err.Cause()))
 }
+
+func TestIncludeTag_loader(t *testing.T) {
+	config := render.NewConfig()
+	config.TemplateLoader = render.MapLoader{
+		"_includes/card.html": `card {{ var }}`,
+	}
+	config.SearchPaths = []string{"_includes"}
+	loc := parser.SourceLoc{Pathname: "pages/index.html", LineNo: 1}
+	AddStandardTags(config)
+
+	root, err := config.Compile(`{% include "card.html" %}`, loc)
+	require.NoError(t, err)
+	buf := new(bytes.Buffer)
+	err = render.Render(root, buf, includeTestBindings, config)
+	require.NoError(t, err)
+	require.Equal(t, "card value", buf.String())
+
+	root, err = config.Compile(`{% include "missing.html" %}`, loc)
+	require.NoError(t, err)
+	err = render.Render(root, ioutil.Discard, includeTestBindings, config)
+	require.Error(t, err)
+	require.True(t, os.IsNotExist(err.Cause()))
+	require.Equal(t, "pages/index.html", err.Path())
+	require.Equal(t, 1, err.LineNumber())
+}
+
+var includeParamTests = []struct{ in, expected string }{
+	{`{% include "note.html" %}`, `[||value]`},
+	{`{% include "note.html" style="warn" %}`, `[warn||value]`},
+	{`{% include "note.html" style=var title='a b' %}`, `[value|a b|value]`},
+	{`{% include "note.html", var: "shopify" %}`, `[||shopify]`},
+	{`{% include 'product' with "p" %}`, `p`},
+	{`{% include 'product' with "p" as product %}`, `p`},
+	{`{% include 'note.html' with "p" as var %}`, `[||p]`},
+	{`{% include 'note.html' with "p" as var style="warn" %}`, `[warn||p]`},
+	{`{% include 'product' for array %}`, `1:a 2:b `},
+	{`{% include {{ name }}.html style="x" %}`, `[x||value]`},
+	{`{% include "no" | append: "te.html" %}`, `[||value]`},
+	{`{% assign x = "assigned" %}{% include "note.html", var: x %}`, `[||assigned]`},
+}
+
+func TestIncludeTag_params(t *testing.T) {
+	config := render.NewConfig()
+	config.TemplateLoader = render.MapLoader{
+		"note.html": `[{{ include.style }}|{{ include.title }}|{{ var }}]`,
+		"product":   `{{ forloop.index }}{% if forloop %}:{% endif %}{{ product }}{% if forloop %} {% endif %}`,
+	}
+	config.AddFilter("append", func(a, b string) string { return a + b })
+	AddStandardTags(config)
+	loc := parser.SourceLoc{Pathname: "index.html", LineNo: 1}
+	bindings := map[string]interface{}{
+		"var":   "value",
+		"name":  "note",
+		"array": []string{"a", "b"},
+	}
+	for i, test := range includeParamTests {
+		t.Run(test.in, func(t *testing.T) {
+			root, err := config.Compile(test.in, loc)
+			require.NoErrorf(t, err, "%d: %s", i, test.in)
+			buf := new(bytes.Buffer)
+			err = render.Render(root, buf, bindings, config)
+			require.NoErrorf(t, err, "%d: %s", i, test.in)
+			require.Equalf(t, test.expected, buf.String(), "%d: %s", i, test.in)
+		})
+	}
+
+	_, err := config.Compile(`{% include "note.html" style= %}`, loc)
+	require.Error(t, err)
+	_, err = config.Compile(`{% include {{ name .html %}`, loc)
+	require.Error(t, err)
+}
@@ -17,8 +17,16 @@ type IterationKeyedMap map[string]interface{}
 
 const forloopVarName = "forloop"
 
-var errLoopContinueLoop = fmt.Errorf("continue outside a loop")
-var errLoopBreak = fmt.Errorf("break outside a loop")
+var errLoopContinueLoop = loopControlError("continue outside a loop")
+var errLoopBreak = loopControlError("break outside a loop")
+
+// A loopControlError is returned by the {% break %} and {% continue %} tags to the enclosing loop.
+type loopControlError string
+
+func (e loopControlError) Error() string { return string(e) }
+
+// ControlFlow is part of the render.ControlFlowError interface.
+func (e loopControlError) ControlFlow() {}
 
 type iterable interface {
 	Len() int
@@ -37,8 +45,8 @@ func continueTag(string) (func(io.Writer, render.Context) error, error) {
 	}, nil
 }
 
-func cycleTag(args string) (func(io.Writer, render.Context) error, error) {
-	stmt, err := expressions.ParseStatement(expressions.CycleStatementSelector, args)
+func cycleTag(args string, table *expressions.ParseTable) (func(io.Writer, render.Context) error, error) {
+	stmt, err := table.ParseStatement(expressions.CycleStatementSelector, args)
 	if err != nil {
 		return nil, err
 	}
@@ -62,18 +70,37 @@ func cycleTag(args string) (func(io.Writer, render.Context) error, error) {
 }
 
 func loopTagCompiler(node render.BlockNode) (func(io.Writer, render.Context) error, error) {
-	stmt, err := expressions.ParseStatement(expressions.LoopStatementSelector, node.Args)
+	stmt, err := node.ParseTable().ParseStatement(expressions.LoopStatementSelector, node.Args)
 	if err != nil {
 		return nil, err
 	}
 	loop := stmt.Loop
 	dec := makeLoopDecorator(node.Name, loop)
-	return loopRenderer{loop, dec}.render, nil
+	var elseBlock *render.BlockNode
+	for _, c := range node.Clauses {
+		if c.Name == "else" && elseBlock == nil {
+			elseBlock = c
+		}
+	}
+	return loopRenderer{loop, dec, elseBlock}.render, nil
+}
+
+func loopTagAnalyzer(node render.BlockNode) (a render.NodeAnalysis) {
+	if node.Name != "for" && node.Name != "tablerow" {
+		return
+	}
+	a.Warnings = checkElseIsLast(node)
+	if stmt, err := expressions.ParseStatement(expressions.LoopStatementSelector, node.Args); err == nil {
+		a.Arguments = []expressions.Expression{stmt.Loop.Expr}
+		a.Locals = []string{stmt.Loop.Variable, forloopVarName}
+	}
+	return
 }
 
 type loopRenderer struct {
 	expressions.Loop
 	loopDecorator
+	elseBlock *render.BlockNode // the else clause, or nil
 }
 
 func (loop loopRenderer) render(w io.Writer, ctx render.Context) error {
@@ -81,30 +108,25 @@ func (loop loopRenderer) render(w io.Writer, ctx render.Context) error {
 	if err != nil {
 		return err
 	}
-	iter := makeIterator(val)
-	if iter == nil {
+	iter := NewLoopIterator(val, loop.Reversed, loop.Offset, loop.Limit)
+	if iter == nil || iter.Len() == 0 {
+		if loop.elseBlock != nil {
+			return ctx.RenderBlock(w, loop.elseBlock)
+		}
 		return nil
 	}
-	iter = applyLoopModifiers(loop.Loop, iter)
 	// shallow-bind the loop variables; restore on exit
 	defer func(index, forloop interface{}) {
 		ctx.Set(forloopVarName, index)
 		ctx.Set(loop.Variable, forloop)
 	}(ctx.GetDirect(forloopVarName), ctx.GetDirect(loop.Variable))
-	cycleMap := map[string]int{}
 loop:
 	for i, len := 0, iter.Len(); i < len; i++ {
+		if err := ctx.CheckLoopIteration(); err != nil {
+			return err
+		}
 		ctx.Set(loop.Variable, iter.Index(i))
-		ctx.Set(forloopVarName, map[string]interface{}{
-			"first":   i == 0,
-			"last":    i == len-1,
-			"index":   i + 1,
-			"index0":  i,
-			"rindex":  len - i,
-			"rindex0": len - i - 1,
-			"length":  len,
-			".cycles": cycleMap,
-		})
+		ctx.Set(forloopVarName, iter.Forloop(i))
 		loop.before(w, i)
 		err := ctx.RenderChildren(w)
 		loop.after(w, i, len)
@@ -122,6 +144,54 @@ loop:
 	return nil
 }
 
+// A LoopIterator iterates over the collection of a {% for %} loop, after the loop's modifiers
+// have been applied. It's used by the for loops of templates that are compiled to Go.
+type LoopIterator struct {
+	iter     iterable
+	len      int
+	cycleMap map[string]int
+}
+
+// NewLoopIterator returns an iterator over a collection, with the modifiers of a loop applied.
+// A nil limit is no limit. It returns nil if the collection can't be iterated. The loop renders
+// its else clause, if it has one, instead of its body, if the iterator is nil or has no
+// iterations.
+func NewLoopIterator(collection interface{}, reversed bool, offset int, limit *int) *LoopIterator {
+	iter := makeIterator(collection)
+	if iter == nil {
+		return nil
+	}
+	var loop expressions.Loop
+	loop.Reversed, loop.Offset, loop.Limit = reversed, offset, limit
+	iter = applyLoopModifiers(loop, iter)
+	return &LoopIterator{iter, iter.Len(), map[string]int{}}
+}
+
+// Len returns the number of iterations.
+func (it *LoopIterator) Len() int { return it.len }
+
+// Index returns the value of the loop variable in the i'th iteration.
+func (it *LoopIterator) Index(i int) interface{} { return it.iter.Index(i) }
+
+// Forloop returns the value of the forloop variable in the i'th iteration.
+func (it *LoopIterator) Forloop(i int) map[string]interface{} {
+	return makeForloop(i, it.len, it.cycleMap)
+}
+
+// makeForloop returns the value of the forloop variable for the i'th of n iterations.
+func makeForloop(i, n int, cycleMap map[string]int) map[string]interface{} {
+	return map[string]interface{}{
+		"first":   i == 0,
+		"last":    i == n-1,
+		"index":   i + 1,
+		"index0":  i,
+		"rindex":  n - i,
+		"rindex0": n - i - 1,
+		"length":  n,
+		".cycles": cycleMap,
+	}
+}
+
 func makeLoopDecorator(tagName string, loop expressions.Loop) loopDecorator {
 	if tagName == "tablerow" {
 		return tableRowDecorator(loop.Cols)
@@ -2,7 +2,9 @@ package tags
 
 import (
 	"bytes"
+	"context"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"regexp"
 	"strings"
@@ -82,6 +84,18 @@ var iterationTests = []struct{ in, expected string }{
 	{`{% for i in (3 .. 5) %}{{i}}.{% endfor %}`, "3.4.5."},
 	{`{% for i in (3..5) %}{{i}}.{% endfor %}`, "3.4.5."},
 
+	// else
+	{`{% for a in array %}{{ a }}.{% else %}empty{% endfor %}`, "first.second.third."},
+	{`{% for a in nil %}{{ a }}.{% else %}empty{% endfor %}`, "empty"},
+	{`{% for a in 2 %}{{ a }}.{% else %}empty{% endfor %}`, "empty"},
+	{`{% for a in empty_array %}{{ a }}.{% else %}empty{% endfor %}`, "empty"},
+	{`{% for a in array offset: 3 %}{{ a }}.{% else %}empty{% endfor %}`, "empty"},
+	{`{% for a in array limit: 0 %}{{ a }}.{% else %}empty{% endfor %}`, "empty"},
+	{`{% for a in array limit: 1 %}{{ a }}.{% else %}empty{% endfor %}`, "first."},
+	{`{% for a in array %}[{% for b in empty_array %}{% else %}{% break %}{% endfor %}{{ a }}]{% endfor %}`, "["},
+	{`{% tablerow a in empty_array %}{{ a }}{% else %}empty{% endtablerow %}`, "empty"},
+	{`{% tablerow a in array limit: 1 %}{{ a }}{% else %}empty{% endtablerow %}`, `<tr class="row1"><td class="col1">first</td></tr>`},
+
 	// tablerow
 	{`{% tablerow product in products %}{{ product }}{% endtablerow %}`,
 		`<tr class="row1"><td class="col1">Cool Shirt</td>
@@ -112,7 +126,8 @@ var iterationErrorTests = []struct{ in, expected string }{
 }
 
 var iterationTestBindings = map[string]interface{}{
-	"array": []string{"first", "second", "third"},
+	"array":       []string{"first", "second", "third"},
+	"empty_array": []string{},
 	// hash has only one element, since iteration order is non-deterministic
 	"map":       map[string]interface{}{"a": 1},
 	"keyed_map": IterationKeyedMap(map[string]interface{}{"a": 1, "b": 2}),
@@ -178,3 +193,27 @@ func TestIterationTags_errors(t *testing.T) {
 		})
 	}
 }
+
+func TestLoopTag_canceled(t *testing.T) {
+	cfg := render.NewConfig()
+	AddStandardTags(cfg)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	count := 0
+	cfg.AddTag("count", func(string) (func(io.Writer, render.Context) error, error) {
+		return func(io.Writer, render.Context) error {
+			count++
+			if count == 3 {
+				cancel()
+			}
+			return nil
+		}, nil
+	})
+	root, err := cfg.Compile(`{% for i in (1..1000000) %}{% count %}{% endfor %}`, parser.SourceLoc{Pathname: "loop.html", LineNo: 1})
+	require.NoError(t, err)
+	err = render.RenderContext(ctx, root, ioutil.Discard, iterationTestBindings, map[string]interface{}{}, cfg)
+	require.Error(t, err)
+	require.Equal(t, context.Canceled, err.Cause())
+	require.Equal(t, "loop.html", err.Path())
+	require.Equal(t, 3, count)
+}
@@ -0,0 +1,105 @@
+package tags
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/etecs-ru/liquid/v2/expressions"
+	"github.com/etecs-ru/liquid/v2/render"
+)
+
+// Keys into the layout state of an inheritance chain.
+const (
+	blockOverridesKey = "block_overrides" // map[string][]*render.BlockNode, innermost template first
+	superStackKey     = "block_super"     // [][]*render.BlockNode, the remaining overrides of each rendering block
+)
+
+func extendsTag(source string, table *expressions.ParseTable) (func(io.Writer, render.Context) error, error) {
+	expr, err := table.Parse(source)
+	if err != nil {
+		return nil, err
+	}
+	return func(w io.Writer, ctx render.Context) error {
+		value, err := ctx.Evaluate(expr)
+		if err != nil {
+			return err
+		}
+		rel, ok := value.(string)
+		if !ok {
+			return ctx.Errorf("%s requires a string argument; got %v", ctx.TagName(), value)
+		}
+		if err := ctx.SetLayout(rel); err != nil {
+			return ctx.WrapError(err)
+		}
+		return nil
+	}, nil
+}
+
+func extendsTagAnalyzer(source string) (a render.NodeAnalysis) {
+	expr, err := expressions.Parse(source)
+	if err != nil {
+		return
+	}
+	if lit, ok := expressions.Syntax(expr).(*expressions.Literal); ok {
+		if name, ok := lit.Value.(string); ok {
+			a.Includes = []string{name}
+		}
+		return
+	}
+	a.Arguments = []expressions.Expression{expr}
+	return
+}
+
+func blockTagCompiler(node render.BlockNode) (func(io.Writer, render.Context) error, error) {
+	fields := strings.Fields(node.Args)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("block requires a name")
+	}
+	name := strings.Trim(fields[0], `"'`)
+	return func(w io.Writer, ctx render.Context) error {
+		state := ctx.LayoutState()
+		overrides, ok := state[blockOverridesKey].(map[string][]*render.BlockNode)
+		if !ok {
+			overrides = map[string][]*render.BlockNode{}
+			state[blockOverridesKey] = overrides
+		}
+		// A template that extends a layout renders its blocks in the layout, in place of the
+		// layout's own blocks. A block that renders more than once, such as a block in a loop,
+		// overrides the layout's block once.
+		if ctx.Layout() != "" {
+			for _, b := range overrides[name] {
+				if b == &node {
+					return nil
+				}
+			}
+			overrides[name] = append(overrides[name], &node)
+			return nil
+		}
+		chain := append(append([]*render.BlockNode{}, overrides[name]...), &node)
+		return renderBlockChain(w, ctx, chain)
+	}, nil
+}
+
+// renderBlockChain renders the first block of chain. Within it, {% super %} renders the rest.
+func renderBlockChain(w io.Writer, ctx render.Context, chain []*render.BlockNode) error {
+	state := ctx.LayoutState()
+	stack, _ := state[superStackKey].([][]*render.BlockNode)
+	state[superStackKey] = append(stack, chain[1:])
+	defer func() { state[superStackKey] = stack }()
+	return ctx.RenderBlock(w, chain[0])
+}
+
+func superTag(string) (func(io.Writer, render.Context) error, error) {
+	return func(w io.Writer, ctx render.Context) error {
+		stack, _ := ctx.LayoutState()[superStackKey].([][]*render.BlockNode)
+		if len(stack) == 0 {
+			return ctx.Errorf("super is only allowed inside a block")
+		}
+		rest := stack[len(stack)-1]
+		if len(rest) == 0 {
+			return nil
+		}
+		return renderBlockChain(w, ctx, rest)
+	}, nil
+}
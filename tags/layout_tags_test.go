@@ -0,0 +1,102 @@
+package tags
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/etecs-ru/liquid/v2/parser"
+	"github.com/etecs-ru/liquid/v2/render"
+	"github.com/stretchr/testify/require"
+)
+
+var layoutTestTemplates = render.MapLoader{
+	"_layouts/base.html":    `<title>{% block title %}Site{% endblock %}</title><main>{% block content %}{% endblock %}</main>`,
+	"_layouts/page.html":    `{% extends "base.html" %}{% block title %}Page - {% super %}{% endblock %}{% block content %}<article>{% block body %}{% endblock %}</article>{% endblock %}`,
+	"_layouts/loop-a.html":  `{% extends "loop-b.html" %}`,
+	"_layouts/loop-b.html":  `{% extends "loop-a.html" %}`,
+	"_includes/header.html": `{% block title %}header{% endblock %}`,
+}
+
+var layoutTagTests = []struct{ in, expected string }{
+	{`{% block title %}Home{% endblock %}`, `Home`},
+	{`{% extends "base.html" %}`, `<title>Site</title><main></main>`},
+	{`{% layout "base.html" %}`, `<title>Site</title><main></main>`},
+	{`{% extends "base.html" %}ignored{% block content %}{{ var }}{% endblock %}`, `<title>Site</title><main>value</main>`},
+	{`{% extends "base.html" %}{% block title %}{% super %} | Home{% endblock %}`, `<title>Site | Home</title><main></main>`},
+	{`{% assign var = "assigned" %}{% extends "base.html" %}{% block content %}{{ var }}{% endblock %}`, `<title>Site</title><main>assigned</main>`},
+	{"\n  {% extends \"base.html\" %}", `<title>Site</title><main></main>`},
+	{"\n  {% block title %}Home{% endblock %} ", "\n  Home "},
+
+	// multi-level chains
+	{`{% extends "page.html" %}{% block body %}text{% endblock %}`, `<title>Page - Site</title><main><article>text</article></main>`},
+	{`{% extends "page.html" %}{% block title %}About | {% super %}{% endblock %}`, `<title>About | Page - Site</title><main><article></article></main>`},
+	{`{% extends "page.html" %}{% block content %}{% super %}!{% endblock %}`, `<title>Page - Site</title><main><article></article>!</main>`},
+
+	// a block in a loop overrides the layout's block once
+	{`{% extends "base.html" %}{% for i in (1..3) %}{% block title %}{% super %}!{% endblock %}{% endfor %}`, `<title>Site!</title><main></main>`},
+	{`{% extends "page.html" %}{% for i in (1..2) %}{% block body %}text{% endblock %}{% endfor %}`, `<title>Page - Site</title><main><article>text</article></main>`},
+
+	// an included template's blocks aren't overridden by the including template
+	{`{% extends "base.html" %}{% block title %}{% include "header.html" %}{% endblock %}`, `<title>header</title><main></main>`},
+}
+
+var layoutTagErrorTests = []struct{ in, expected string }{
+	{`{% extends 10 %}`, `extends requires a string argument`},
+	{`{% extends "missing.html" %}`, `missing.html`},
+	{`{% extends "base.html" %}{% extends "base.html" %}`, `already extends`},
+	{`Preamble {% extends "base.html" %}{% block content %}child{% endblock %}`, `after it has written output`},
+	{`{% block title %}Home{% endblock %}{% extends "base.html" %}`, `after it has written output`},
+	{`{% extends "loop-a.html" %}`, `circular layout`},
+	{`{% super %}`, `only allowed inside a block`},
+}
+
+func layoutTestConfig() render.Config {
+	config := render.NewConfig()
+	config.TemplateLoader = layoutTestTemplates
+	config.SearchPaths = []string{"_layouts", "_includes"}
+	AddStandardTags(config)
+	return config
+}
+
+func TestLayoutTags(t *testing.T) {
+	config := layoutTestConfig()
+	loc := parser.SourceLoc{Pathname: "index.html", LineNo: 1}
+	for i, test := range layoutTagTests {
+		t.Run(test.in, func(t *testing.T) {
+			root, err := config.Compile(test.in, loc)
+			require.NoErrorf(t, err, "%d: %s", i, test.in)
+			buf := new(bytes.Buffer)
+			err = render.Render(root, buf, includeTestBindings, config)
+			require.NoErrorf(t, err, "%d: %s", i, test.in)
+			require.Equalf(t, test.expected, buf.String(), "%d: %s", i, test.in)
+		})
+	}
+}
+
+func TestLayoutTags_errors(t *testing.T) {
+	config := layoutTestConfig()
+	loc := parser.SourceLoc{Pathname: "index.html", LineNo: 1}
+	for i, test := range layoutTagErrorTests {
+		t.Run(test.in, func(t *testing.T) {
+			root, err := config.Compile(test.in, loc)
+			require.NoErrorf(t, err, "%d: %s", i, test.in)
+			err = render.Render(root, ioutil.Discard, includeTestBindings, config)
+			require.Errorf(t, err, "%d: %s", i, test.in)
+			require.Containsf(t, err.Error(), test.expected, "%d: %s", i, test.in)
+		})
+	}
+
+	root, err := config.Compile("\n{% extends \"missing.html\" %}", loc)
+	require.NoError(t, err)
+	err = render.Render(root, ioutil.Discard, includeTestBindings, config)
+	require.True(t, os.IsNotExist(err.(render.Error).Cause()))
+	require.Equal(t, "index.html", err.(render.Error).Path())
+	require.Equal(t, 2, err.(render.Error).LineNumber())
+	require.Contains(t, err.Error(), "(line 2)")
+
+	_, err = config.Compile(`{% block %}{% endblock %}`, loc)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "block requires a name")
+}
@@ -0,0 +1,383 @@
+package tags
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"unicode"
+
+	"github.com/etecs-ru/liquid/v2/expressions"
+	"github.com/etecs-ru/liquid/v2/render"
+)
+
+// partialArgs are the compiled arguments of a tag that renders another template, e.g.
+// {% render 'card' with product as item, size: 'small' %} or {% include note.html style="warn" %}.
+type partialArgs struct {
+	name          templateName
+	value         expressions.Expression // the argument to with or for, or nil
+	isFor         bool
+	alias         string         // the variable that value is bound to, or "" for the default
+	params        []partialParam // Shopify key: value parameters, that are bound as variables
+	includeParams []partialParam // Jekyll key=value parameters, that are bound as include.key
+}
+
+// A partialParam is a named parameter, e.g. size: 'small'.
+type partialParam struct {
+	name  string
+	value expressions.Expression
+}
+
+// A templateName is either an expression, such as "card.html" or page.card; or text with
+// {{ expression }} substitutions, such as {{ page.card }}.html.
+type templateName struct {
+	expr     expressions.Expression // nil if the name is text
+	segments []nameSegment
+}
+
+// A nameSegment is literal text, followed by an optional expression.
+type nameSegment struct {
+	text string
+	expr expressions.Expression
+}
+
+// parsePartialArgs parses the arguments of the {% render %} and {% include %} tags:
+//
+//	name [key=value…] [with|for expr [as alias]] [key=value…] [, key: value]…
+//
+// The name ends at the first key=value parameter, with or for keyword, or top-level comma. The
+// expressions are parsed with table.
+func parsePartialArgs(source string, table *expressions.ParseTable) (*partialArgs, error) {
+	segments := splitTopLevel(source, ',')
+	fields := scanFields(segments[0])
+	i := 0
+	for i < len(fields) && fields[i] != "with" && fields[i] != "for" && !isIncludeParam(fields[i]) {
+		i++
+	}
+	if i == 0 {
+		return nil, fmt.Errorf("requires a template name")
+	}
+	name, err := parseTemplateName(strings.Join(fields[:i], " "), table)
+	if err != nil {
+		return nil, err
+	}
+	args := partialArgs{name: name}
+	rest, err := args.parseIncludeParams(fields[i:], table)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 && (rest[0] == "with" || rest[0] == "for") {
+		args.isFor = rest[0] == "for"
+		j := 1
+		for j < len(rest) && rest[j] != "as" && !isIncludeParam(rest[j]) {
+			j++
+		}
+		if j == 1 {
+			return nil, fmt.Errorf("%q requires an expression", rest[0])
+		}
+		if args.value, err = table.Parse(strings.Join(rest[1:j], " ")); err != nil {
+			return nil, err
+		}
+		rest = rest[j:]
+		if len(rest) > 0 && rest[0] == "as" {
+			if len(rest) < 2 || !isIdentifier(rest[1]) {
+				return nil, fmt.Errorf("%q requires a variable name", "as")
+			}
+			args.alias = rest[1]
+			rest = rest[2:]
+		}
+		if rest, err = args.parseIncludeParams(rest, table); err != nil {
+			return nil, err
+		}
+	}
+	if len(rest) > 0 {
+		return nil, fmt.Errorf("unexpected %q", rest[0])
+	}
+	for _, seg := range segments[1:] {
+		i := strings.IndexByte(seg, ':')
+		if i < 0 {
+			return nil, fmt.Errorf("expected a key: value parameter; got %q", strings.TrimSpace(seg))
+		}
+		key := strings.TrimSpace(seg[:i])
+		if !isIdentifier(key) {
+			return nil, fmt.Errorf("invalid parameter name %q", key)
+		}
+		value, err := table.Parse(seg[i+1:])
+		if err != nil {
+			return nil, err
+		}
+		args.params = append(args.params, partialParam{key, value})
+	}
+	return &args, nil
+}
+
+// partialTagAnalyzer analyzes the {% include %} and {% render %} tags.
+func partialTagAnalyzer(source string) (a render.NodeAnalysis) {
+	args, err := parsePartialArgs(source, nil)
+	if err != nil {
+		return
+	}
+	if name, ok := args.name.constant(); ok {
+		a.Includes = []string{name}
+	} else if args.name.expr != nil {
+		a.Arguments = append(a.Arguments, args.name.expr)
+	}
+	for _, seg := range args.name.segments {
+		if seg.expr != nil {
+			a.Arguments = append(a.Arguments, seg.expr)
+		}
+	}
+	if args.value != nil {
+		a.Arguments = append(a.Arguments, args.value)
+	}
+	for _, p := range append(args.includeParams, args.params...) {
+		a.Arguments = append(a.Arguments, p.value)
+	}
+	return
+}
+
+// parseIncludeParams parses the leading key=value fields, and returns the remaining fields.
+func (args *partialArgs) parseIncludeParams(fields []string, table *expressions.ParseTable) ([]string, error) {
+	for len(fields) > 0 && isIncludeParam(fields[0]) {
+		i := strings.IndexByte(fields[0], '=')
+		value, err := table.Parse(fields[0][i+1:])
+		if err != nil {
+			return nil, err
+		}
+		args.includeParams = append(args.includeParams, partialParam{fields[0][:i], value})
+		fields = fields[1:]
+	}
+	return fields, nil
+}
+
+func parseTemplateName(source string, table *expressions.ParseTable) (templateName, error) {
+	if !strings.Contains(source, "{{") {
+		expr, err := table.Parse(source)
+		return templateName{expr: expr}, err
+	}
+	var name templateName
+	for source != "" {
+		i := strings.Index(source, "{{")
+		if i < 0 {
+			name.segments = append(name.segments, nameSegment{text: source})
+			break
+		}
+		j := strings.Index(source[i:], "}}")
+		if j < 0 {
+			return name, fmt.Errorf("unterminated {{ in %q", source)
+		}
+		expr, err := table.Parse(source[i+2 : i+j])
+		if err != nil {
+			return name, err
+		}
+		name.segments = append(name.segments, nameSegment{source[:i], expr})
+		source = source[i+j+2:]
+	}
+	return name, nil
+}
+
+// constant returns the template's filename, if this doesn't depend on the render.
+func (name templateName) constant() (string, bool) {
+	if name.expr != nil {
+		if lit, ok := expressions.Syntax(name.expr).(*expressions.Literal); ok {
+			s, ok := lit.Value.(string)
+			return s, ok
+		}
+		return "", false
+	}
+	buf := new(strings.Builder)
+	for _, seg := range name.segments {
+		if seg.expr != nil {
+			return "", false
+		}
+		buf.WriteString(seg.text)
+	}
+	return buf.String(), true
+}
+
+// evaluate returns the template's filename.
+func (name templateName) evaluate(ctx render.Context) (string, error) {
+	if name.expr != nil {
+		value, err := ctx.Evaluate(name.expr)
+		if err != nil {
+			return "", err
+		}
+		s, ok := value.(string)
+		if !ok {
+			return "", ctx.Errorf("%s requires a string argument; got %v", ctx.TagName(), value)
+		}
+		return s, nil
+	}
+	buf := new(strings.Builder)
+	for _, seg := range name.segments {
+		buf.WriteString(seg.text)
+		if seg.expr == nil {
+			continue
+		}
+		value, err := ctx.Evaluate(seg.expr)
+		if err != nil {
+			return "", err
+		}
+		if value != nil {
+			fmt.Fprint(buf, value)
+		}
+	}
+	return buf.String(), nil
+}
+
+// render renders the template, or renders it once for each item of the value of a for clause.
+// renderFile is either Context.RenderFile or Context.RenderPartial.
+func (args *partialArgs) render(w io.Writer, ctx render.Context, renderFile func(string, map[string]interface{}) (string, error)) error {
+	filename, err := args.name.evaluate(ctx)
+	if err != nil {
+		return err
+	}
+	bindings, err := evaluateParams(ctx, args.params)
+	if err != nil {
+		return err
+	}
+	if len(args.includeParams) > 0 {
+		include, err := evaluateParams(ctx, args.includeParams)
+		if err != nil {
+			return err
+		}
+		bindings["include"] = include
+	}
+	renderOnce := func() error {
+		// It might be more efficient to add a context interface to render bytes
+		// to a writer. The status quo keeps the interface light at the expense of some overhead
+		// here.
+		s, err := renderFile(filename, bindings)
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(w, s)
+		return err
+	}
+	if args.value == nil {
+		return renderOnce()
+	}
+	value, err := ctx.Evaluate(args.value)
+	if err != nil {
+		return err
+	}
+	alias := args.alias
+	if alias == "" {
+		alias = strings.TrimSuffix(path.Base(filename), path.Ext(filename))
+	}
+	iter := makeIterator(value)
+	if !args.isFor || iter == nil {
+		bindings[alias] = value
+		return renderOnce()
+	}
+	cycleMap := map[string]int{}
+	for i, n := 0, iter.Len(); i < n; i++ {
+		if err := ctx.CheckLoopIteration(); err != nil {
+			return err
+		}
+		bindings[alias] = iter.Index(i)
+		bindings[forloopVarName] = makeForloop(i, n, cycleMap)
+		if err := renderOnce(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// evaluateParams evaluates named parameters into a new map.
+func evaluateParams(ctx render.Context, params []partialParam) (map[string]interface{}, error) {
+	bindings := map[string]interface{}{}
+	for _, p := range params {
+		value, err := ctx.Evaluate(p.value)
+		if err != nil {
+			return nil, err
+		}
+		bindings[p.name] = value
+	}
+	return bindings, nil
+}
+
+// splitTopLevel splits s at each sep that is outside a quoted string, and outside brackets,
+// braces and parentheses.
+func splitTopLevel(s string, sep byte) []string {
+	var (
+		parts []string
+		quote byte
+		depth int
+		start int
+	)
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '(' || c == '[' || c == '{':
+			depth++
+		case c == ')' || c == ']' || c == '}':
+			depth--
+		case c == sep && depth == 0:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// scanFields splits s into fields that are separated by white space. White space inside a quoted
+// string, or inside brackets, braces or parentheses, doesn't separate fields.
+func scanFields(s string) []string {
+	var (
+		fields []string
+		quote  byte
+		depth  int
+		start  = -1
+	)
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+			continue
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '(' || c == '[' || c == '{':
+			depth++
+		case c == ')' || c == ']' || c == '}':
+			depth--
+		case unicode.IsSpace(rune(c)) && depth <= 0:
+			if start >= 0 {
+				fields = append(fields, s[start:i])
+				start = -1
+			}
+			continue
+		}
+		if start < 0 {
+			start = i
+		}
+	}
+	if start >= 0 {
+		fields = append(fields, s[start:])
+	}
+	return fields
+}
+
+// isIncludeParam returns true if the field is a Jekyll include parameter, e.g. style="warn".
+func isIncludeParam(field string) bool {
+	i := strings.IndexByte(field, '=')
+	return i > 0 && isIdentifier(field[:i]) && !strings.HasPrefix(field[i:], "==")
+}
+
+func isIdentifier(s string) bool {
+	for i, r := range s {
+		if !(r == '_' || unicode.IsLetter(r) || i > 0 && (r == '-' || unicode.IsDigit(r))) {
+			return false
+		}
+	}
+	return s != ""
+}
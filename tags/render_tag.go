@@ -0,0 +1,24 @@
+package tags
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/etecs-ru/liquid/v2/expressions"
+	"github.com/etecs-ru/liquid/v2/render"
+)
+
+// renderTag implements Shopify's {% render %} tag. Unlike {% include %}, it renders the template
+// in an isolated scope, that has only the render's variables and the tag's parameters.
+func renderTag(source string, table *expressions.ParseTable) (func(io.Writer, render.Context) error, error) {
+	args, err := parsePartialArgs(source, table)
+	if err != nil {
+		return nil, err
+	}
+	if len(args.includeParams) > 0 {
+		return nil, fmt.Errorf("unexpected %q; use key: value parameters", args.includeParams[0].name+"=")
+	}
+	return func(w io.Writer, ctx render.Context) error {
+		return args.render(w, ctx, ctx.RenderPartial)
+	}, nil
+}
@@ -0,0 +1,91 @@
+package tags
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"github.com/etecs-ru/liquid/v2/parser"
+	"github.com/etecs-ru/liquid/v2/render"
+	"github.com/stretchr/testify/require"
+)
+
+var renderTagTemplates = render.MapLoader{
+	"snippets/card":     `[{{ card }}{{ product }}{{ size }}{{ var }}{{ outer }}]`,
+	"snippets/item":     `{{ forloop.index }}/{{ forloop.length }}:{{ product }}{% unless forloop.last %},{% endunless %}`,
+	"snippets/assign":   `{% assign var = "inner" %}{{ var }}`,
+	"snippets/nested":   `{% render 'card', size: size %}`,
+	"snippets/cycle":    `{% cycle 'a', 'b' %}`,
+	"snippets/card.liq": `{{ card }}`,
+}
+
+var renderTagTests = []struct{ in, expected string }{
+	{`{% render 'card' %}`, `[value]`},
+	{`{% assign outer = 1 %}{% render 'card' %}`, `[value]`},
+	{`{% render 'card', size: 'small' %}`, `[smallvalue]`},
+	{`{% render 'card', product: "p", size: 2 | plus: 1 %}`, `[p3value]`},
+	{`{% render 'card', var: "passed" %}`, `[passed]`},
+	{`{% render 'card' with "p" %}`, `[pvalue]`},
+	{`{% render 'card.liq' with "p" %}`, `p`},
+	{`{% render 'card' with "p" as product %}`, `[pvalue]`},
+	{`{% render 'card' with "p" as product, size: "s" %}`, `[psvalue]`},
+	{`{% render 'item' for array as product %}`, `1/3:first,2/3:second,3/3:third`},
+	{`{% render 'card' for "one" as product %}`, `[onevalue]`},
+	{`{% render 'cycle' for array as x %}`, `aba`},
+	{`{% render 'nested', size: 'xl' %}`, `[xlvalue]`},
+
+	// the partial's assignments don't leak out
+	{`{% render 'assign' %}{{ var }}`, `innervalue`},
+}
+
+var renderTagErrorTests = []struct{ in, expected string }{
+	{`{% render %}`, `requires a template name`},
+	{`{% render 'card' product %}`, `syntax error`},
+	{`{% render 'card' size="s" %}`, `use key: value parameters`},
+	{`{% render 'card' with %}`, `requires an expression`},
+	{`{% render 'card' with p as %}`, `requires a variable name`},
+	{`{% render 'card', product %}`, `key: value`},
+	{`{% render 'card', a.b: 1 %}`, `invalid parameter name`},
+}
+
+func TestRenderTag(t *testing.T) {
+	config := render.NewConfig()
+	config.TemplateLoader = renderTagTemplates
+	config.SearchPaths = []string{"snippets"}
+	config.AddFilter("plus", func(a, b int) int { return a + b })
+	AddStandardTags(config)
+	loc := parser.SourceLoc{Pathname: "templates/index.liquid", LineNo: 1}
+	bindings := map[string]interface{}{
+		"var":   "value",
+		"array": []string{"first", "second", "third"},
+	}
+	for i, test := range renderTagTests {
+		t.Run(test.in, func(t *testing.T) {
+			root, err := config.Compile(test.in, loc)
+			require.NoErrorf(t, err, "%d: %s", i, test.in)
+			buf := new(bytes.Buffer)
+			err = render.Render(root, buf, bindings, config)
+			require.NoErrorf(t, err, "%d: %s", i, test.in)
+			require.Equalf(t, test.expected, buf.String(), "%d: %s", i, test.in)
+		})
+	}
+	for i, test := range renderTagErrorTests {
+		t.Run(test.in, func(t *testing.T) {
+			_, err := config.Compile(test.in, loc)
+			require.Errorf(t, err, "%d: %s", i, test.in)
+			require.Containsf(t, err.Error(), test.expected, "%d: %s", i, test.in)
+		})
+	}
+
+	root, err := config.Compile(`{% render 10 %}`, loc)
+	require.NoError(t, err)
+	err = render.Render(root, ioutil.Discard, bindings, config)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "requires a string")
+}
+
+func TestSplitTopLevel(t *testing.T) {
+	require.Equal(t, []string{"a", " b"}, splitTopLevel("a, b", ','))
+	require.Equal(t, []string{"'a,b'", " c(d, e)", " [f,g]"}, splitTopLevel("'a,b', c(d, e), [f,g]", ','))
+	require.Equal(t, []string{"'a b'", "c", `"d e"f`}, scanFields(` 'a b' c  "d e"f `))
+}
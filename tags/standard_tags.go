@@ -11,29 +11,58 @@ import (
 
 // AddStandardTags defines the standard Liquid tags.
 func AddStandardTags(c render.Config) {
-	c.AddTag("assign", assignTag)
-	c.AddTag("include", includeTag)
+	c.AddParsingTag("assign", assignTag)
+	c.AddParsingTag("include", includeTag)
 	c.AddTag("increment", incrementTag)
 	c.AddTag("decrement", decrementTag)
+	c.AddParsingTag("extends", extendsTag)
+	c.AddParsingTag("layout", extendsTag)
+	c.AddParsingTag("render", renderTag)
+	c.AddTag("super", superTag)
 
 	// blocks
 	// The parser only recognize the comment and raw tags if they've been defined,
 	// but it ignores any syntax specified here.
 	c.AddTag("break", breakTag)
 	c.AddTag("continue", continueTag)
-	c.AddTag("cycle", cycleTag)
-	c.AddBlock("capture").Compiler(captureTagCompiler)
-	c.AddBlock("case").Clause("when").Clause("else").Compiler(caseTagCompiler)
+	c.AddParsingTag("cycle", cycleTag)
+	c.AddBlock("block").Compiler(blockTagCompiler)
+	c.AddBlock("capture").Analyzer(captureTagAnalyzer).Compiler(captureTagCompiler)
+	c.AddBlock("case").Clause("when").Clause("else").Analyzer(caseTagAnalyzer).Folder(caseTagFolder).Compiler(caseTagCompiler)
 	c.AddBlock("comment")
-	c.AddBlock("for").Compiler(loopTagCompiler)
-	c.AddBlock("if").Clause("else").Clause("elsif").Compiler(ifTagCompiler(true))
+	c.AddBlock("for").Clause("else").Analyzer(loopTagAnalyzer).Compiler(loopTagCompiler)
+	c.AddBlock("if").Clause("else").Clause("elsif").Analyzer(ifTagAnalyzer).Folder(ifTagFolder(true)).Compiler(ifTagCompiler(true))
 	c.AddBlock("raw")
-	c.AddBlock("tablerow").Compiler(loopTagCompiler)
-	c.AddBlock("unless").Clause("else").Clause("elsif").Compiler(ifTagCompiler(false))
+	c.AddBlock("tablerow").Clause("else").Analyzer(loopTagAnalyzer).Compiler(loopTagCompiler)
+	c.AddBlock("unless").Clause("else").Clause("elsif").Analyzer(ifTagAnalyzer).Folder(ifTagFolder(false)).Compiler(ifTagCompiler(false))
+
+	c.AddTagAnalyzer("assign", assignTagAnalyzer)
+	c.AddTagAnalyzer("extends", extendsTagAnalyzer)
+	c.AddTagAnalyzer("include", partialTagAnalyzer)
+	c.AddTagAnalyzer("layout", extendsTagAnalyzer)
+	c.AddTagAnalyzer("render", partialTagAnalyzer)
 }
 
-func assignTag(source string) (func(io.Writer, render.Context) error, error) {
+// The analyzers ignore arguments that don't parse; the tag's compiler reports these.
+
+func assignTagAnalyzer(source string) (a render.NodeAnalysis) {
 	stmt, err := expressions.ParseStatement(expressions.AssignStatementSelector, source)
+	if err == nil {
+		a.Arguments = []expressions.Expression{stmt.Assignment.ValueFn}
+		a.Assigns = []string{stmt.Assignment.Variable}
+	}
+	return
+}
+
+func captureTagAnalyzer(node render.BlockNode) (a render.NodeAnalysis) {
+	if fields := strings.Fields(node.Args); len(fields) > 0 {
+		a.Assigns = fields[:1]
+	}
+	return
+}
+
+func assignTag(source string, table *expressions.ParseTable) (func(io.Writer, render.Context) error, error) {
+	stmt, err := table.ParseStatement(expressions.AssignStatementSelector, source)
 	if err != nil {
 		return nil, err
 	}
@@ -52,7 +81,7 @@ func captureTagCompiler(node render.BlockNode) (func(io.Writer, render.Context)
 	// only the first "word" gets used as the variable name; i.e., {% capture x y z %} results in a variable named 'x'.
 	varname := strings.Fields(node.Args)[0]
 	return func(w io.Writer, ctx render.Context) error {
-		s, err := ctx.InnerString()
+		s, err := ctx.CaptureString()
 		if err != nil {
 			return err
 		}
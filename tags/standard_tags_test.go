@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"fmt"
 	"io/ioutil"
+	"runtime"
+	"strings"
 	"testing"
 
 	"github.com/etecs-ru/liquid/v2/parser"
@@ -116,3 +118,108 @@ func TestStandardTags_render_errors(t *testing.T) {
 		})
 	}
 }
+
+func TestStandardTags_limits(t *testing.T) {
+	config := render.NewConfig()
+	AddStandardTags(config)
+	tests := []struct {
+		in     string
+		limits render.Limits
+		limit  string
+	}{
+		{`{% for i in (1..100) %}{% endfor %}`, render.Limits{MaxLoopIterations: 10}, "MaxLoopIterations"},
+		{`{% for i in (1..5) %}{% for j in (1..5) %}{% endfor %}{% endfor %}`, render.Limits{MaxLoopIterations: 20}, "MaxLoopIterations"},
+		{`{% capture x %}{% for i in (1..10) %}{{ i }}{% endfor %}{% endcapture %}`, render.Limits{MaxCaptureBytes: 5}, "MaxCaptureBytes"},
+	}
+	for _, test := range tests {
+		root, err := config.Compile(test.in, parser.SourceLoc{})
+		require.NoError(t, err)
+		c := config
+		c.Limits = test.limits
+		err = render.Render(root, ioutil.Discard, tagTestBindings, c)
+		require.Errorf(t, err, test.in)
+		require.IsTypef(t, &render.LimitError{}, err.Cause(), test.in)
+		require.Equalf(t, test.limit, err.Cause().(*render.LimitError).Limit, test.in)
+	}
+}
+
+func TestStandardTags_limitsMemory(t *testing.T) {
+	config := render.NewConfig()
+	AddStandardTags(config)
+	in := `{% capture c %}{% for i in (1..2000000) %}` + strings.Repeat("x", 67) + `{% endfor %}{% endcapture %}`
+	root, err := config.Compile(in, parser.SourceLoc{})
+	require.NoError(t, err)
+
+	// the render fails as soon as the capture passes a limit, before it has buffered the loop's output
+	for _, limits := range []render.Limits{{MaxCaptureBytes: 1000}, {MaxOutputBytes: 1000}} {
+		c := config
+		c.Limits = limits
+		var before, after runtime.MemStats
+		runtime.ReadMemStats(&before)
+		err = render.Render(root, ioutil.Discard, tagTestBindings, c)
+		runtime.ReadMemStats(&after)
+		require.Error(t, err)
+		require.IsType(t, &render.LimitError{}, err.Cause())
+		require.Less(t, after.TotalAlloc-before.TotalAlloc, uint64(1<<20))
+	}
+}
+
+var analyzeTests = []struct {
+	in                string
+	globals, locals   []string
+	defined, includes []string
+}{
+	{`{% assign x = a.b | upcase %}{{ x }}`, []string{"a.b"}, []string{"x"}, []string{"x"}, nil},
+	{`{% capture c %}{{ d }}{% endcapture %}{{ c }}`, []string{"d"}, []string{"c"}, []string{"c"}, nil},
+	{`{% for p in products limit: 2 %}{{ p.title }}{{ forloop.index }}{% endfor %}{{ p }}`, []string{"products", "p"}, []string{"p.title", "forloop.index"}, []string{"p", "forloop"}, nil},
+	{`{% tablerow p in ps %}{{ p }}{% endtablerow %}`, []string{"ps"}, []string{"p"}, []string{"p", "forloop"}, nil},
+	{`{% if a %}{% elsif b == c %}{% else %}{{ d }}{% endif %}`, []string{"a", "b", "c", "d"}, nil, nil, nil},
+	{`{% unless a %}{% endunless %}`, []string{"a"}, nil, nil, nil},
+	{`{% case a %}{% when b, 1 %}{% else %}{% endcase %}`, []string{"a", "b"}, nil, nil, nil},
+	{`{% include 'card' with item, size: s %}{% render "x.html" for xs as y %}`, []string{"item", "s", "xs"}, nil, nil, []string{"card", "x.html"}},
+	{`{% include {{ page.card }}.html k=v %}{% include name %}`, []string{"page.card", "v", "name"}, nil, nil, nil},
+	{`{% extends "base.html" %}{% layout page.layout %}`, []string{"page.layout"}, nil, nil, []string{"base.html"}},
+	{`{% cycle 'a', 'b' %}{% increment n %}`, nil, nil, nil, nil},
+}
+
+func TestStandardTags_analyze(t *testing.T) {
+	config := render.NewConfig()
+	AddStandardTags(config)
+	for i, test := range analyzeTests {
+		testV := test
+		t.Run(fmt.Sprintf("%02d", i+1), func(t *testing.T) {
+			root, err := config.Compile(testV.in, parser.SourceLoc{})
+			require.NoErrorf(t, err, testV.in)
+			a := render.Analyze(root, config)
+			var globals, locals, defined, includes []string
+			for _, ref := range a.Globals {
+				globals = append(globals, ref.String())
+			}
+			for _, ref := range a.Locals {
+				locals = append(locals, ref.String())
+			}
+			for _, def := range a.Definitions {
+				defined = append(defined, def.Name)
+			}
+			for _, ref := range a.Includes {
+				includes = append(includes, ref.Name)
+			}
+			require.Equalf(t, testV.globals, globals, testV.in)
+			require.Equalf(t, testV.locals, locals, testV.in)
+			require.Equalf(t, testV.defined, defined, testV.in)
+			require.Equalf(t, testV.includes, includes, testV.in)
+		})
+	}
+}
+
+func TestStandardTags_analyze_warnings(t *testing.T) {
+	config := render.NewConfig()
+	AddStandardTags(config)
+	root, err := config.Compile("{% if a %}{% else %}\n{% elsif b %}{% endif %}{% case c %}{% when 1 %}{% else %}{% endcase %}{% for x in d %}{% else %}{% else %}{% endfor %}", parser.SourceLoc{LineNo: 1})
+	require.NoError(t, err)
+	a := render.Analyze(root, config)
+	require.Len(t, a.Warnings, 2)
+	require.Contains(t, a.Warnings[0].Error(), "else isn't the last clause of if")
+	require.Equal(t, 11, a.Warnings[0].ColumnNumber())
+	require.Contains(t, a.Warnings[1].Error(), "else isn't the last clause of for")
+}
@@ -2,7 +2,13 @@ package liquid
 
 import (
 	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"io"
 
+	"github.com/etecs-ru/liquid/v2/expressions"
 	"github.com/etecs-ru/liquid/v2/parser"
 	"github.com/etecs-ru/liquid/v2/render"
 )
@@ -11,8 +17,15 @@ import (
 //
 // Use Engine.ParseTemplate to create a template.
 type Template struct {
-	root render.Node
-	cfg  *render.Config
+	root   render.Node
+	cfg    *render.Config
+	source *templateSource // nil if the template was compiled from a syntax tree, or from Go code
+}
+
+// A templateSource is the source of a template, and its location, for MarshalBinary.
+type templateSource struct {
+	text string
+	loc  parser.SourceLoc
 }
 
 func newTemplate(cfg *render.Config, source []byte, path string, line int) (*Template, SourceError) {
@@ -21,7 +34,35 @@ func newTemplate(cfg *render.Config, source []byte, path string, line int) (*Tem
 	if err != nil {
 		return nil, err
 	}
-	return &Template{root, cfg}, nil
+	return &Template{root, cfg, &templateSource{string(source), loc}}, nil
+}
+
+// newTemplateAll is the same as newTemplate, except that its error is an ErrorList of all the
+// template's errors.
+func newTemplateAll(cfg *render.Config, source []byte, path string, line int) (*Template, SourceError) {
+	loc := parser.SourceLoc{Pathname: path, LineNo: line}
+	root, errs := cfg.CompileAll(string(source), loc)
+	if errs != nil {
+		return nil, errs
+	}
+	return &Template{root, cfg, &templateSource{string(source), loc}}, nil
+}
+
+// WithLimits returns a copy of the template that renders with different resource limits.
+// See Engine.Limits.
+func (t *Template) WithLimits(limits render.Limits) *Template {
+	cfg := *t.cfg
+	cfg.Limits = limits
+	return &Template{t.root, &cfg, t.source}
+}
+
+// renderedOutput returns the output of a render that failed with err. This is nil, unless the
+// render continued past its errors; see Engine.ContinueOnError.
+func renderedOutput(buf *bytes.Buffer, err SourceError) []byte {
+	if _, ok := err.(ErrorList); ok {
+		return buf.Bytes()
+	}
+	return nil
 }
 
 // Render executes the template with the specified variable bindings.
@@ -31,13 +72,75 @@ func (t *Template) Render(vars Bindings) ([]byte, SourceError) {
 
 func (t *Template) RenderWithState(vars, state Bindings) ([]byte, SourceError) {
 	buf := new(bytes.Buffer)
-	err := render.RenderWithState(t.root, buf, vars, state, *t.cfg)
+	err := t.FRenderWithState(buf, vars, state)
 	if err != nil {
-		return nil, err
+		return renderedOutput(buf, err), err
 	}
 	return buf.Bytes(), nil
 }
 
+// FRender executes the template with the specified variable bindings, and writes the output to w.
+//
+// The output is streamed to w as the template is rendered. The only output that is held back is
+// whitespace that a following {%- or {{- could remove; this is written once the next tag or object
+// has been rendered, or at the end of the template. If rendering fails, w may already have received
+// part of the output.
+func (t *Template) FRender(w io.Writer, vars Bindings) SourceError {
+	return t.FRenderWithState(w, vars, map[string]interface{}{})
+}
+
+// FRenderWithState is the same as FRender, with additional renderer state.
+func (t *Template) FRenderWithState(w io.Writer, vars, state Bindings) SourceError {
+	err := render.RenderWithState(t.root, w, vars, state, *t.cfg)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// RenderContext is the same as Render, except that rendering stops once ctx is done.
+//
+// The renderer checks ctx before each node, and before each iteration of a loop. If ctx is done,
+// it returns a SourceError that records where rendering stopped, and whose Cause is ctx.Err().
+func (t *Template) RenderContext(ctx context.Context, vars Bindings) ([]byte, SourceError) {
+	buf := new(bytes.Buffer)
+	err := t.FRenderContext(ctx, buf, vars)
+	if err != nil {
+		return renderedOutput(buf, err), err
+	}
+	return buf.Bytes(), nil
+}
+
+// FRenderContext is the same as FRender, except that rendering stops once ctx is done.
+// See RenderContext.
+func (t *Template) FRenderContext(ctx context.Context, w io.Writer, vars Bindings) SourceError {
+	err := render.RenderContext(ctx, t.root, w, vars, map[string]interface{}{}, *t.cfg)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// RenderWithWarnings is the same as Render, except that undefined variables, undefined filters,
+// and errors that filters return, are returned as warnings instead of stopping the render.
+// Each warning records the source location where it happened.
+//
+// An undefined variable renders as nil, an undefined filter leaves its input unchanged, and a
+// filter that fails returns nil. Other errors, such as a syntax error in a tag argument, still
+// stop the render; the warnings up to that point are returned with the error.
+func (t *Template) RenderWithWarnings(vars Bindings) ([]byte, []SourceError, SourceError) {
+	buf := new(bytes.Buffer)
+	ws, err := render.RenderWarnings(context.Background(), t.root, buf, vars, map[string]interface{}{}, *t.cfg)
+	warnings := make([]SourceError, len(ws))
+	for i, w := range ws {
+		warnings[i] = w
+	}
+	if err != nil {
+		return renderedOutput(buf, err), warnings, err
+	}
+	return buf.Bytes(), warnings, nil
+}
+
 // RenderString is a convenience wrapper for Render, that has string input and output.
 func (t *Template) RenderString(b Bindings) (string, SourceError) {
 	return t.RenderStringWithState(b, map[string]interface{}{})
@@ -45,12 +148,130 @@ func (t *Template) RenderString(b Bindings) (string, SourceError) {
 
 func (t *Template) RenderStringWithState(b, state Bindings) (string, SourceError) {
 	bs, err := t.RenderWithState(b, state)
-	if err != nil {
-		return "", err
-	}
-	return string(bs), nil
+	return string(bs), err
 }
 
+// FindVariables returns the dotted names of the variables that the template reads.
+//
+// Deprecated: Analyze reports the variables together with their locations, and distinguishes
+// the template's own variables from those that it expects to be bound.
 func (t *Template) FindVariables() (map[string]interface{}, SourceError) {
 	return render.FindVariables(t.root, *t.cfg)
 }
+
+// Analyze reports the variables, filters, tags, and included templates that the template uses,
+// without rendering it.
+func (t *Template) Analyze() Analysis {
+	return render.Analyze(t.root, *t.cfg)
+}
+
+// Inspect traverses the template's render tree, as render.Inspect does.
+func (t *Template) Inspect(f func(render.Node) bool) {
+	render.Inspect(t.root, f)
+}
+
+// compiledVersion is the version of the format that MarshalBinary writes.
+const compiledVersion = 1
+
+// compiledTemplate is the header of the format that MarshalBinary writes, and Engine.LoadCompiled
+// reads. The header is followed by the length and the binary representation of the template's
+// syntax tree, and by the binary representation of the table of the parses of its tags'
+// arguments; see parser.MarshalASTBinary and expressions.ParseTable. An integer is a varint, and
+// a string is its length followed by its bytes.
+type compiledTemplate struct {
+	Version     int
+	Fingerprint string // render.Config.Fingerprint of the engine
+	Hash        [sha256.Size]byte
+	Path        string
+	Line        int
+}
+
+func (ct *compiledTemplate) append(b []byte) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	b = append(b, buf[:binary.PutUvarint(buf[:], uint64(ct.Version))]...)
+	b = append(b, buf[:binary.PutUvarint(buf[:], uint64(len(ct.Fingerprint)))]...)
+	b = append(b, ct.Fingerprint...)
+	b = append(b, ct.Hash[:]...)
+	b = append(b, buf[:binary.PutUvarint(buf[:], uint64(len(ct.Path)))]...)
+	b = append(b, ct.Path...)
+	return append(b, buf[:binary.PutVarint(buf[:], int64(ct.Line))]...)
+}
+
+// decode decodes the header from the front of data, and returns the rest of data. It decodes
+// the version first, so that the caller can report a different version.
+func (ct *compiledTemplate) decode(data []byte) ([]byte, error) {
+	truncated := errors.New("the compiled template is truncated")
+	version, n := binary.Uvarint(data)
+	if n <= 0 {
+		return nil, truncated
+	}
+	ct.Version, data = int(version), data[n:]
+	if ct.Version != compiledVersion {
+		return nil, nil
+	}
+	str := func() string {
+		size, n := binary.Uvarint(data)
+		if n <= 0 || size > uint64(len(data)-n) {
+			data = nil
+			return ""
+		}
+		s := string(data[n : n+int(size)])
+		data = data[n+int(size):]
+		return s
+	}
+	if ct.Fingerprint = str(); len(data) < sha256.Size {
+		return nil, truncated
+	}
+	copy(ct.Hash[:], data)
+	data = data[sha256.Size:]
+	ct.Path = str()
+	line, n := binary.Varint(data)
+	if n <= 0 {
+		return nil, truncated
+	}
+	ct.Line = int(line)
+	return data[n:], nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler. It serializes the template's syntax tree,
+// together with a hash of its source and a fingerprint of the engine's configuration, for
+// Engine.LoadCompiled. The syntax tree refers to the text of its tokens by their offsets in the
+// source, and it holds the syntax trees of its objects' expressions and the results of parsing
+// its tags' arguments, so that LoadCompiled compiles the template without parsing it.
+//
+// A template that was created by CompileAST or LoadGoTemplate has no source, and can't be
+// marshaled.
+func (t *Template) MarshalBinary() ([]byte, error) {
+	if t.source == nil {
+		return nil, errors.New("liquid: a template that wasn't parsed from source can't be marshaled")
+	}
+	root, perr := t.cfg.Parse(t.source.text, t.source.loc)
+	if perr != nil {
+		return nil, perr
+	}
+	ast, err := parser.MarshalASTBinary(root, t.source.text)
+	if err != nil {
+		return nil, err
+	}
+	cfg := *t.cfg
+	cfg.ParseTable = expressions.NewParseTable()
+	if _, perr = cfg.CompileAST(root); perr != nil {
+		return nil, perr
+	}
+	table, err := cfg.ParseTable.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	ct := compiledTemplate{
+		Version:     compiledVersion,
+		Fingerprint: t.cfg.Fingerprint(),
+		Hash:        sha256.Sum256([]byte(t.source.text)),
+		Path:        t.source.loc.Pathname,
+		Line:        t.source.loc.LineNo,
+	}
+	b := ct.append(nil)
+	var buf [binary.MaxVarintLen64]byte
+	b = append(b, buf[:binary.PutUvarint(buf[:], uint64(len(ast)))]...)
+	b = append(b, ast...)
+	return append(b, table...), nil
+}
@@ -1,7 +1,11 @@
 package liquid
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"sync"
 	"testing"
 
@@ -108,3 +112,190 @@ func BenchmarkTemplate_Render(b *testing.B) {
 		tpl.Render(bindings) // nolint: errcheck
 	}
 }
+
+type writeRecorder struct{ writes []string }
+
+func (w *writeRecorder) Write(b []byte) (int, error) {
+	w.writes = append(w.writes, string(b))
+	return len(b), nil
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("write failed") }
+
+func TestTemplate_FRender(t *testing.T) {
+	engine := NewEngine()
+	tpl, err := engine.ParseString(`{% for i in (1..3) %}{{ i }}  {{- "," }} {% endfor %}.`)
+	require.NoError(t, err)
+	w := &writeRecorder{}
+	require.NoError(t, tpl.FRender(w, emptyBindings))
+	require.Equal(t, "1, 2, 3, .", strings.Join(w.writes, ""))
+	// the output is written as it is produced, not all at once
+	require.True(t, len(w.writes) > 1)
+
+	err = tpl.FRender(failingWriter{}, emptyBindings)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "write failed")
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, engine.ParseAndFRender(buf, []byte(`{{ "hello" | upcase }}`), emptyBindings))
+	require.Equal(t, "HELLO", buf.String())
+}
+
+func TestTemplate_RenderContext(t *testing.T) {
+	engine := NewEngine()
+	tpl, err := engine.ParseString(`{% for i in (1..10) %}{{ i }}{% endfor %}`)
+	require.NoError(t, err)
+	out, err := tpl.RenderContext(context.Background(), emptyBindings)
+	require.NoError(t, err)
+	require.Equal(t, "12345678910", string(out))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	engine.RegisterTag("stop_at", func(c render.Context) (string, error) {
+		require.Equal(t, ctx, c.Context())
+		if c.Get("i") == 3 {
+			cancel()
+		}
+		return "", nil
+	})
+	tpl, err = engine.ParseTemplateLocation([]byte(`{% for i in (1..10) %}{% stop_at %}{{ i }}{% endfor %}`), "page.html", 1)
+	require.NoError(t, err)
+	buf := new(bytes.Buffer)
+	err = tpl.FRenderContext(ctx, buf, emptyBindings)
+	require.Error(t, err)
+	require.Equal(t, context.Canceled, err.Cause())
+	require.Equal(t, "page.html", err.Path())
+	require.Equal(t, "12", buf.String())
+}
+
+func TestTemplate_RenderWithWarnings(t *testing.T) {
+	engine := NewEngine()
+	engine.RegisterFilter("fail", func(s string) (string, error) { return "", errors.New("failed") })
+	tpl, err := engine.ParseTemplateLocation([]byte("a{{ missing }}b\n{{ \"x\" | nofilter }}\n{% if true %}{{ \"x\" | fail }}{% endif %}"), "page.html", 1)
+	require.NoError(t, err)
+	out, warnings, err := tpl.RenderWithWarnings(emptyBindings)
+	require.NoError(t, err)
+	require.Equal(t, "ab\nx\n", string(out))
+	require.Len(t, warnings, 3)
+	require.Contains(t, warnings[0].Error(), `undefined variable "missing"`)
+	require.Equal(t, 1, warnings[0].LineNumber())
+	require.Equal(t, "page.html", warnings[0].Path())
+	require.Contains(t, warnings[1].Error(), "nofilter")
+	require.Equal(t, 2, warnings[1].LineNumber())
+	require.Contains(t, warnings[2].Error(), "failed")
+	require.Equal(t, 3, warnings[2].LineNumber())
+
+	// the same template fails without warnings mode
+	_, err = tpl.Render(emptyBindings)
+	require.Error(t, err)
+
+	_, warnings, err = tpl.RenderWithWarnings(Bindings{"missing": 1})
+	require.NoError(t, err)
+	require.Len(t, warnings, 2)
+
+	// an argument that the filter can't take is a filter error
+	for _, src := range []string{`a{{ 'x' | divided_by: 0 }}b`, `a{{ x | divided_by: 0 }}b`} {
+		tpl, err = engine.ParseTemplate([]byte(src))
+		require.NoError(t, err, src)
+		out, warnings, err = tpl.RenderWithWarnings(Bindings{"x": "x"})
+		require.NoError(t, err, src)
+		require.Equal(t, "ab", string(out), src)
+		require.Len(t, warnings, 1, src)
+		require.Contains(t, warnings[0].Error(), "divided_by", src)
+	}
+}
+
+func TestTemplate_errorColumn(t *testing.T) {
+	engine := NewEngine()
+	source := []byte("Dear {{ name }},\nYour order {{ order.id | nope }} shipped.")
+	tpl, err := engine.ParseTemplateLocation(source, "email.txt", 1)
+	require.NoError(t, err)
+	_, err = tpl.Render(Bindings{"name": "A", "order": map[string]interface{}{"id": 1}})
+	require.Error(t, err)
+	require.Equal(t, 2, err.LineNumber())
+	require.Equal(t, 12, err.ColumnNumber())
+	require.Equal(t, 28, err.Offset())
+	require.Equal(t, ""+
+		`Liquid error (line 2): undefined filter "nope" in email.txt`+"\n"+
+		"   2 | Your order {{ order.id | nope }} shipped.\n"+
+		"     |            ^", FormatError(err, "email.txt", source))
+}
+
+func TestTemplate_errorColumnInclude(t *testing.T) {
+	engine := NewEngine().TemplateLoader(render.MapLoader{"bad.html": `{% if %}`})
+	source := []byte("line one\n{% include 'bad.html' %}")
+	tpl, err := engine.ParseTemplateLocation(source, "page.html", 1)
+	require.NoError(t, err)
+	_, err = tpl.Render(emptyBindings)
+	require.Error(t, err)
+	require.Equal(t, "bad.html", err.Path())
+
+	// the error isn't drawn against the text of the including template
+	require.Equal(t, err.Error(), FormatError(err, "page.html", source))
+	require.Equal(t, ""+
+		err.Error()+"\n"+
+		"   1 | {% if %}\n"+
+		"     | ^", FormatError(err, "bad.html", []byte(`{% if %}`)))
+}
+
+func TestTemplate_Analyze(t *testing.T) {
+	engine := NewEngine()
+	source := []byte("{% assign total = order.items | size %}\n{% for item in order.items %}{{ item.price | money }}{% endfor %}{{ total }}")
+	tpl, err := engine.ParseTemplateLocation(source, "order.html", 1)
+	require.NoError(t, err)
+	a := tpl.Analyze()
+	require.Len(t, a.Globals, 2)
+	require.Equal(t, []interface{}{"order", "items"}, a.Globals[1].Path)
+	require.Equal(t, 2, a.Globals[1].Loc.LineNo)
+	require.Len(t, a.Locals, 2)
+	require.Equal(t, "item.price", a.Locals[0].String())
+	require.Equal(t, "total", a.Locals[1].String())
+	require.Equal(t, "size", a.Filters[0].Name)
+	require.Equal(t, "money", a.Filters[1].Name)
+}
+
+func TestTemplate_MarshalBinary(t *testing.T) {
+	engine := NewEngine().TemplateLoader(render.MapLoader{"greeting.html": `Hello, {{ name }}!`})
+	source := []byte("{% if x > 1 %}{{ x | plus: 1 }}{% endif %}{{ \"a\" | upcase }} {% include \"greeting.html\" %}\n{{ x | undefined }}")
+	tpl, err := engine.ParseTemplateLocation(source, "page.html", 3)
+	require.NoError(t, err)
+	data, merr := tpl.MarshalBinary()
+	require.NoError(t, merr)
+
+	loaded, err := NewEngine().TemplateLoader(render.MapLoader{"greeting.html": `Hello, {{ name }}!`}).LoadCompiled(data, source)
+	require.NoError(t, err)
+	out, err := loaded.RenderString(Bindings{"x": 2, "name": "World"})
+	require.Error(t, err)
+	require.Equal(t, "page.html", err.Path())
+	require.Equal(t, 4, err.LineNumber())
+	require.Contains(t, err.Error(), "undefined filter")
+	expected, _ := tpl.RenderString(Bindings{"x": 2, "name": "World"})
+	require.Equal(t, expected, out)
+
+	// a loaded template can be marshaled again
+	again, merr := loaded.MarshalBinary()
+	require.NoError(t, merr)
+	require.Equal(t, string(data), string(again))
+
+	// stale data is rejected
+	_, err = NewEngine().LoadCompiled(data, append(source, '!'))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "doesn't match its source")
+	changed := NewEngine()
+	changed.RegisterFilter("shout", strings.ToUpper)
+	_, err = changed.LoadCompiled(data, source)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "different configuration")
+	_, err = NewEngine().LoadCompiled(data[:len(data)/2], source)
+	require.Error(t, err)
+
+	// a template without source can't be marshaled
+	root, err := engine.ParseAST(source, "page.html")
+	require.NoError(t, err)
+	fromAST, err := engine.CompileAST(root)
+	require.NoError(t, err)
+	_, merr = fromAST.MarshalBinary()
+	require.Error(t, merr)
+}
@@ -13,6 +13,9 @@ import (
 // The function should return one or two values; the second value,
 // if present, should be an error.
 func Call(fn reflect.Value, args []interface{}) (interface{}, error) {
+	if result, ok, err := CallDirect(fn.Interface(), args); ok {
+		return result, err
+	}
 	in, err := convertCallArguments(fn, args)
 	if err != nil {
 		return nil, err
@@ -21,6 +24,72 @@ func Call(fn reflect.Value, args []interface{}) (interface{}, error) {
 	return convertCallResults(results)
 }
 
+var (
+	stringType    = reflect.TypeOf("")
+	interfaceType = reflect.TypeOf([]interface{}{}).Elem()
+)
+
+// CallDirect calls a function whose type is common among filters, without reflection. It converts
+// the arguments as Call does, and returns a TypeError if one can't be converted. It returns false,
+// without calling fn, for other functions.
+func CallDirect(fn interface{}, args []interface{}) (result interface{}, ok bool, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			e, isTypeError := r.(TypeError)
+			if !isTypeError {
+				panic(r)
+			}
+			result, ok, err = nil, true, e
+		}
+	}()
+	var n int
+	switch fn.(type) {
+	case func(string) string, func(interface{}) string, func(interface{}) interface{}:
+		n = 1
+	case func(string, string) string, func(interface{}, interface{}) interface{}:
+		n = 2
+	case func(string, string, string) string:
+		n = 3
+	default:
+		return nil, false, nil
+	}
+	if len(args) > n {
+		return nil, true, &CallParityError{NumArgs: len(args), NumParams: n}
+	}
+	switch f := fn.(type) {
+	case func(string) string:
+		return f(stringArg(args, 0)), true, nil
+	case func(interface{}) string:
+		return f(interfaceArg(args, 0)), true, nil
+	case func(interface{}) interface{}:
+		return f(interfaceArg(args, 0)), true, nil
+	case func(string, string) string:
+		return f(stringArg(args, 0), stringArg(args, 1)), true, nil
+	case func(interface{}, interface{}) interface{}:
+		return f(interfaceArg(args, 0), interfaceArg(args, 1)), true, nil
+	case func(string, string, string) string:
+		return f(stringArg(args, 0), stringArg(args, 1), stringArg(args, 2)), true, nil
+	}
+	return nil, false, nil
+}
+
+// stringArg returns the argument i, converted to a string parameter; or "" if it is missing.
+func stringArg(args []interface{}, i int) string {
+	if i >= len(args) || args[i] == nil {
+		return ""
+	}
+	return MustConvert(args[i], stringType).(string)
+}
+
+// interfaceArg returns the argument i, converted to an interface{} parameter; or nil if it is
+// missing.
+func interfaceArg(args []interface{}, i int) interface{} {
+	if i >= len(args) || args[i] == nil {
+		return nil
+	}
+	return MustConvert(args[i], interfaceType)
+}
+
 // A CallParityError is a mismatch between the argument and parameter counts.
 type CallParityError struct{ NumArgs, NumParams int }
 
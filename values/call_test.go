@@ -76,3 +76,42 @@ func TestCall_variadic(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, "[]", value)
 }
+
+func TestCall_direct(t *testing.T) {
+	fns := []interface{}{
+		func(s string) string { return "<" + s + ">" },
+		func(v interface{}) string { return fmt.Sprintf("<%v>", v) },
+		func(v interface{}) interface{} { return []interface{}{v} },
+		func(a, b string) string { return a + "," + b },
+		func(a, b interface{}) interface{} { return []interface{}{a, b} },
+		func(a, b, c string) string { return a + "," + b + "," + c },
+	}
+	argLists := [][]interface{}{
+		{},
+		{"a"},
+		{nil},
+		{1, 2.5},
+		{[]byte("a"), true, nil},
+		{"a", "b", "c", "d"},
+	}
+	for _, fn := range fns {
+		fv := reflect.ValueOf(fn)
+		for _, args := range argLists {
+			// the same as the reflection path of Call
+			var expected interface{}
+			in, experr := convertCallArguments(fv, args)
+			if experr == nil {
+				expected, experr = convertCallResults(fv.Call(in))
+			}
+			value, err := Call(fv, args)
+			require.Equal(t, experr, err, "%T %#v", fn, args)
+			require.Equal(t, expected, value, "%T %#v", fn, args)
+			value, ok, err := CallDirect(fn, args)
+			require.True(t, ok)
+			require.Equal(t, experr, err, "%T %#v", fn, args)
+			require.Equal(t, expected, value, "%T %#v", fn, args)
+		}
+	}
+	_, ok, _ := CallDirect(func(n int) int { return n }, []interface{}{1})
+	require.False(t, ok)
+}
@@ -140,6 +140,15 @@ func convertValueToNumber(value interface{}, typ reflect.Type) Number {
 // handle circular references.
 func Convert(value interface{}, typ reflect.Type) (interface{}, error) { // nolint: gocyclo
 	value = ToLiquid(value)
+	// fast paths, for the common cases of filter arguments, that don't allocate
+	switch {
+	case typ == stringType:
+		if _, ok := value.(string); ok {
+			return value, nil
+		}
+	case typ == interfaceType && value != nil:
+		return value, nil
+	}
 	rv := reflect.ValueOf(value)
 	// int.Convert(string) returns "\x01" not "1", so guard against that in the following test
 	if typ.Kind() != reflect.String && value != nil && rv.Type().ConvertibleTo(typ) {
@@ -0,0 +1,8 @@
+package values
+
+// SafeString is a string that is safe to include in HTML as-is. When a template is rendered with
+// auto-escaping enabled, the renderer escapes the output of {{ objects }} except for values of this type.
+type SafeString string
+
+// String is part of the fmt.Stringer interface.
+func (s SafeString) String() string { return string(s) }